@@ -0,0 +1,62 @@
+package paymail
+
+import (
+	"context"
+	"fmt"
+)
+
+// AssetCapabilities are the (tokenized) asset endpoints advertised by a paymail provider
+//
+// The {alias} and {domain.tld} placeholders are resolved, the {asset} placeholder of the
+// AssetInformation URL is left for the token id. An endpoint that is not advertised is empty
+type AssetCapabilities struct {
+	AssetInformation        string `json:"asset_information,omitempty"`          // Asset information URL (with the {asset} placeholder)
+	AuthoriseAction         string `json:"authorise_action,omitempty"`           // Simple Fabriik Protocol (SFP) authorise action URL
+	BuildAction             string `json:"build_action,omitempty"`               // Simple Fabriik Protocol (SFP) build action URL
+	P2PDestinationWithToken string `json:"p2p_destination_with_token,omitempty"` // P2P payment destination (with tokens support) URL
+}
+
+// AssetCapabilities will return the advertised asset endpoints (nil if none are advertised)
+//
+// The capability URL templates are returned as advertised (see GetAssetCapabilities() to resolve them)
+func (c *CapabilitiesPayload) AssetCapabilities() *AssetCapabilities {
+	assets := &AssetCapabilities{
+		AssetInformation:        c.GetString(BRFCSFPAssetInformation, ""),
+		AuthoriseAction:         c.GetString(BRFCSFPAuthoriseAction, ""),
+		BuildAction:             c.GetString(BRFCSFPBuildAction, ""),
+		P2PDestinationWithToken: c.GetString(BRFCP2PPaymentDestinationWithToken, ""),
+	}
+	if *assets == (AssetCapabilities{}) {
+		return nil
+	}
+	return assets
+}
+
+// GetAssetCapabilities will discover the asset endpoints of the paymail address (only the discovery, no token flow)
+//
+// The capabilities are discovered using the SRV record of the domain (cached capabilities are reused).
+// Returns ErrCapabilityNotSupported if the provider does not advertise any asset capability
+// Specs: https://docs.moneybutton.com/docs/paymail/paymail-08-asset-information.html
+func (c *Client) GetAssetCapabilities(ctx context.Context, alias, domain string) (*AssetCapabilities, error) {
+	srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var capabilities *CapabilitiesResponse
+	if capabilities, err = c.GetCapabilities(ctx, srv.Target, int(srv.Port)); err != nil {
+		return nil, err
+	}
+
+	assets := capabilities.AssetCapabilities()
+	if assets == nil {
+		return nil, fmt.Errorf("paymail provider %s does not support the %s capability: %w",
+			domain, BRFCSFPAssetInformation, ErrCapabilityNotSupported)
+	}
+
+	assets.AssetInformation = replaceAliasDomain(assets.AssetInformation, alias, domain)
+	assets.AuthoriseAction = replaceAliasDomain(assets.AuthoriseAction, alias, domain)
+	assets.BuildAction = replaceAliasDomain(assets.BuildAction, alias, domain)
+	assets.P2PDestinationWithToken = replaceAliasDomain(assets.P2PDestinationWithToken, alias, domain)
+	return assets, nil
+}
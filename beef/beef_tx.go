@@ -87,15 +87,20 @@ func decodeBUMPs(beefBytes []byte) ([]*BUMP, []byte, error) {
 		return nil, nil, errors.New("invalid BEEF- lack of BUMPs")
 	}
 
-	beefBytes = beefBytes[bytesUsed:]
+	return decodeBUMPList(beefBytes[bytesUsed:], uint64(nBump))
+}
 
-	bumps := make([]*BUMP, 0, uint64(nBump))
-	for i := uint64(0); i < uint64(nBump); i++ {
+func decodeBUMPList(beefBytes []byte, nBump uint64) ([]*BUMP, []byte, error) {
+	bumps := make([]*BUMP, 0, nBump)
+	for i := uint64(0); i < nBump; i++ {
 		if len(beefBytes) == 0 {
 			return nil, nil, errors.New("insufficient bytes to extract BUMP blockHeight")
 		}
 		blockHeight, bytesUsed := util.NewVarIntFromBytes(beefBytes)
 		beefBytes = beefBytes[bytesUsed:]
+		if len(beefBytes) == 0 {
+			return nil, nil, errors.New("insufficient bytes to extract BUMP treeHeight")
+		}
 
 		treeHeight := beefBytes[0]
 		if int(treeHeight) > maxTreeHeight {
@@ -197,16 +202,22 @@ func decodeTransactionsWithPathIndexes(bytes []byte) ([]*TxData, error) {
 		return nil, errors.New("invalid BEEF- not enough transactions provided to decode BEEF")
 	}
 
-	bytes = bytes[offset:]
+	transactions, _, err := decodeTransactionList(bytes[offset:], uint64(nTransactions))
+	return transactions, err
+}
 
-	transactions := make([]*TxData, 0, int(nTransactions))
+func decodeTransactionList(bytes []byte, nTransactions uint64) ([]*TxData, []byte, error) {
+	transactions := make([]*TxData, 0, nTransactions)
 
 	for i := 0; i < int(nTransactions); i++ {
 		tx, offset, err := sdk.NewTransactionFromStream(bytes)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		bytes = bytes[offset:]
+		if len(bytes) == 0 {
+			return nil, nil, fmt.Errorf("insufficient bytes to extract HasCMP flag for transaction at index %d", i)
+		}
 
 		var pathIndex *util.VarInt
 
@@ -218,7 +229,7 @@ func decodeTransactionsWithPathIndexes(bytes []byte) ([]*TxData, error) {
 		case HasNoBump:
 			bytes = bytes[1:]
 		default:
-			return nil, fmt.Errorf("invalid HasCMP flag for transaction at index %d", i)
+			return nil, nil, fmt.Errorf("invalid HasCMP flag for transaction at index %d", i)
 		}
 
 		transactions = append(transactions, &TxData{
@@ -227,7 +238,7 @@ func decodeTransactionsWithPathIndexes(bytes []byte) ([]*TxData, error) {
 		})
 	}
 
-	return transactions, nil
+	return transactions, bytes, nil
 }
 
 func extractBytesWithoutVersionAndMarker(hexStream string) ([]byte, error) {
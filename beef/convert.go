@@ -0,0 +1,144 @@
+package beef
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+	util "github.com/bsv-blockchain/go-sdk/util"
+)
+
+// beefVersion is the (little endian) version of the encoded BEEF (0100BEEF)
+var beefVersion = []byte{0x01, 0x00, BEEFMarkerPart1, BEEFMarkerPart2}
+
+// MerkleProof is the merkle proof (BUMP) of a mined transaction
+type MerkleProof = BUMP
+
+// ToBEEF will convert the raw transaction (hex) into a BEEF (hex) with its merkle proofs
+//
+// Every proof must contain the transaction and calculate a merkle root, an unmined transaction has no proofs.
+// The txid of the transaction is preserved (the transaction is encoded as is)
+func ToBEEF(txHex string, proofs ...MerkleProof) (string, error) {
+	tx, err := sdk.NewTransactionFromHex(txHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hex: %w", err)
+	}
+	txID := tx.TxID().String()
+
+	for i, proof := range proofs {
+		if len(proof.Path) == 0 || len(proof.Path) > maxTreeHeight {
+			return "", fmt.Errorf("invalid merkle proof %d - tree height must be 1 to %d", i, maxTreeHeight)
+		} else if !proofContainsTx(proof, txID) {
+			return "", fmt.Errorf("invalid merkle proof %d - transaction %s not found", i, txID)
+		} else if _, err = proof.CalculateMerkleRoot(); err != nil {
+			return "", fmt.Errorf("invalid merkle proof %d: %w", i, err)
+		}
+	}
+
+	beefBytes := append([]byte{}, beefVersion...)
+	beefBytes = append(beefBytes, util.VarInt(len(proofs)).Bytes()...)
+	for _, proof := range proofs {
+		var proofBytes []byte
+		if proofBytes, err = encodeBUMP(proof); err != nil {
+			return "", err
+		}
+		beefBytes = append(beefBytes, proofBytes...)
+	}
+
+	beefBytes = append(beefBytes, util.VarInt(1).Bytes()...)
+	beefBytes = append(beefBytes, tx.Bytes()...)
+	if len(proofs) > 0 {
+		beefBytes = append(beefBytes, HasBump)
+		beefBytes = append(beefBytes, util.VarInt(0).Bytes()...)
+	} else {
+		beefBytes = append(beefBytes, HasNoBump)
+	}
+
+	return hex.EncodeToString(beefBytes), nil
+}
+
+// FromBEEF will convert the BEEF (hex) into the raw transaction (hex) of the subject transaction and its merkle proof
+//
+// The subject transaction is the last transaction of the BEEF, the proofs are empty if it is not mined.
+// Unlike DecodeBEEF(), the ancestors and their proofs are not required
+func FromBEEF(beefHex string) (txHex string, proofs []MerkleProof, err error) {
+	var beefBytes []byte
+	if beefBytes, err = extractBytesWithoutVersionAndMarker(beefHex); err != nil {
+		return "", nil, err
+	} else if len(beefBytes) == 0 {
+		return "", nil, errors.New("cannot decode BUMP - no bytes provided")
+	}
+
+	nBump, bytesUsed := util.NewVarIntFromBytes(beefBytes)
+	var bumps BUMPs
+	if bumps, beefBytes, err = decodeBUMPList(beefBytes[bytesUsed:], uint64(nBump)); err != nil {
+		return "", nil, err
+	} else if len(beefBytes) == 0 {
+		return "", nil, errors.New("invalid BEEF - no transactions provided")
+	}
+
+	nTransactions, bytesUsed := util.NewVarIntFromBytes(beefBytes)
+	if nTransactions == 0 {
+		return "", nil, errors.New("invalid BEEF - no transactions provided")
+	}
+
+	var transactions []*TxData
+	if transactions, beefBytes, err = decodeTransactionList(beefBytes[bytesUsed:], uint64(nTransactions)); err != nil {
+		return "", nil, err
+	} else if len(beefBytes) > 0 {
+		return "", nil, errors.New("invalid BEEF - unexpected bytes after the transactions")
+	}
+
+	subject := transactions[len(transactions)-1]
+	if !subject.Unmined() {
+		index := uint64(*subject.BumpIndex)
+		if index >= uint64(len(bumps)) {
+			return "", nil, fmt.Errorf("invalid BEEF - BUMP index %d not found", index)
+		}
+		proofs = []MerkleProof{*bumps[index]}
+	}
+
+	return subject.Transaction.Hex(), proofs, nil
+}
+
+// proofContainsTx will return true if the base level of the proof has the txid
+func proofContainsTx(proof MerkleProof, txID string) bool {
+	for _, leaf := range proof.Path[0] {
+		if leaf.TxId && leaf.Hash == txID {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBUMP will encode the BUMP in the BEEF (BRC-74) binary format
+func encodeBUMP(bump BUMP) ([]byte, error) {
+	bumpBytes := util.VarInt(bump.BlockHeight).Bytes()
+	bumpBytes = append(bumpBytes, byte(len(bump.Path)))
+
+	for _, level := range bump.Path {
+		bumpBytes = append(bumpBytes, util.VarInt(len(level)).Bytes()...)
+		for _, leaf := range level {
+			bumpBytes = append(bumpBytes, util.VarInt(leaf.Offset).Bytes()...)
+			if leaf.Duplicate {
+				bumpBytes = append(bumpBytes, duplicateFlag)
+				continue
+			}
+
+			if leaf.TxId {
+				bumpBytes = append(bumpBytes, txIDFlag)
+			} else {
+				bumpBytes = append(bumpBytes, dataFlag)
+			}
+
+			hash, err := hex.DecodeString(leaf.Hash)
+			if err != nil || len(hash) != hashBytesCount {
+				return nil, fmt.Errorf("invalid BUMP leaf hash at offset %d: %s", leaf.Offset, leaf.Hash)
+			}
+			bumpBytes = append(bumpBytes, util.ReverseBytes(hash)...)
+		}
+	}
+
+	return bumpBytes, nil
+}
@@ -0,0 +1,127 @@
+package beef
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// testSiblingHash is the hash of the other transaction of the (two transactions) block
+const testSiblingHash = "3ecead27a44d013ad1aae40038acbb1883ac9242406808bb4667c15b4f164eac"
+
+// testTransaction will return a transaction with a single input and P2PKH output
+func testTransaction(t *testing.T) *sdk.Transaction {
+	t.Helper()
+	lockingScript, err := script.NewFromHex("76a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockingScript, err := script.NewFromHex("020102")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := sdk.NewTransaction()
+	tx.AddInput(&sdk.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: unlockingScript,
+		SequenceNumber:  0xffffffff,
+	})
+	tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: 1000})
+	return tx
+}
+
+// testProof will return the merkle proof of the transaction (first of a two transactions block)
+func testProof(txID string) MerkleProof {
+	return MerkleProof{
+		BlockHeight: 800_000,
+		Path: [][]BUMPLeaf{{
+			{Hash: txID, Offset: 0, TxId: true},
+			{Hash: testSiblingHash, Offset: 1},
+		}},
+	}
+}
+
+func TestToBEEF_RoundTrip(t *testing.T) {
+	tx := testTransaction(t)
+	txID := tx.TxID().String()
+
+	t.Run("mined transaction", func(t *testing.T) {
+		proof := testProof(txID)
+		beefHex, err := ToBEEF(tx.Hex(), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		txHex, proofs, err := FromBEEF(beefHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txHex != tx.Hex() {
+			t.Errorf("expected the transaction %s, got %s", tx.Hex(), txHex)
+		}
+		if len(proofs) != 1 || !reflect.DeepEqual(proofs[0], proof) {
+			t.Errorf("expected the proof %+v, got %+v", proof, proofs)
+		}
+
+		// The txid of the subject transaction is preserved
+		converted, err := sdk.NewTransactionFromHex(txHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if convertedTxID := converted.TxID().String(); convertedTxID != txID {
+			t.Errorf("expected the txid %s, got %s", txID, convertedTxID)
+		}
+	})
+
+	t.Run("unmined transaction", func(t *testing.T) {
+		beefHex, err := ToBEEF(tx.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		txHex, proofs, err := FromBEEF(beefHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txHex != tx.Hex() || len(proofs) != 0 {
+			t.Errorf("expected the transaction without proofs, got %s %+v", txHex, proofs)
+		}
+	})
+}
+
+func TestToBEEF_Invalid(t *testing.T) {
+	tx := testTransaction(t)
+
+	if _, err := ToBEEF("zz"); err == nil {
+		t.Error("expected an invalid transaction hex to be rejected")
+	}
+	if _, err := ToBEEF(tx.Hex(), testProof(testSiblingHash)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a proof without the transaction to be rejected, got %v", err)
+	}
+	if _, err := ToBEEF(tx.Hex(), MerkleProof{BlockHeight: 1}); err == nil {
+		t.Error("expected a proof without a path to be rejected")
+	}
+}
+
+func TestFromBEEF_Invalid(t *testing.T) {
+	beefHex, err := ToBEEF(testTransaction(t).Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, invalid := range map[string]string{
+		"not hex":          "zz",
+		"raw transaction":  testTransaction(t).Hex(),
+		"trailing bytes":   beefHex + "00",
+		"no transactions":  "0100beef0000",
+		"truncated":        beefHex[:len(beefHex)-10],
+		"version and mark": "0100beef",
+	} {
+		if _, _, err = FromBEEF(invalid); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
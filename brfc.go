@@ -117,6 +117,37 @@ func (b *BRFCSpec) Generate() error {
 	return nil
 }
 
+// GenerateBRFCs will generate the BRFC ID for all specifications (in place)
+//
+// Specs with an ID already set are left untouched, unless force is true
+// Returns an error listing any duplicate IDs across the set (author/title/version collisions)
+func GenerateBRFCs(specs []*BRFCSpec, force bool) error {
+
+	// Generate the missing IDs
+	for _, spec := range specs {
+		if len(spec.ID) > 0 && !force {
+			continue
+		}
+		if err := spec.Generate(); err != nil {
+			return fmt.Errorf("brfc: [%s] failed to generate: %w", spec.Title, err)
+		}
+	}
+
+	// Detect the duplicate IDs (in order of first occurrence)
+	seen := make(map[string]int, len(specs))
+	var duplicates []string
+	for _, spec := range specs {
+		if seen[spec.ID]++; seen[spec.ID] == 2 {
+			duplicates = append(duplicates, spec.ID)
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("brfc: duplicate ids found: %s", strings.Join(duplicates, ", "))
+	}
+
+	return nil
+}
+
 // Validate will check if the BRFC is valid or not (and set b.Valid)
 //
 // Returns the ID that was generated to compare against the existing id
@@ -146,3 +177,24 @@ func (b *BRFCSpec) Validate() (valid bool, id string, err error) {
 
 	return
 }
+
+// VerifyID will check that the ID is well-formed and matches the ID generated from the title, author and version
+//
+// Returns a descriptive error if the title or author is empty, or if the ID does not match
+func (b *BRFCSpec) VerifyID() error {
+	if len(strings.TrimSpace(b.Title)) == 0 {
+		return fmt.Errorf("brfc: title is required")
+	} else if len(strings.TrimSpace(b.Author)) == 0 {
+		return fmt.Errorf("brfc: [%s] author is required", b.Title)
+	} else if !isValidBRFCID(b.ID) {
+		return fmt.Errorf("brfc: [%s] id is not a %d character hex string: %s", b.Title, brfcIDLength, b.ID)
+	}
+
+	valid, id, err := b.Validate()
+	if err != nil {
+		return err
+	} else if !valid {
+		return fmt.Errorf("brfc: [%s] id %s does not match the generated id %s", b.Title, b.ID, id)
+	}
+	return nil
+}
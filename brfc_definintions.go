@@ -3,6 +3,7 @@ package paymail
 // All BRFC IDs that have been used/referenced in the library
 const (
 	BRFCBasicAddressResolution         = "759684b1a19a"       // more info: http://bsvalias.org/04-01-basic-address-resolution.html
+	BRFCCurrentAddress                 = "e3f557059186"       // current deposit address (no amount), see GetCurrentAddress()
 	BRFCP2PPaymentDestination          = "2a40af698840"       // more info: https://docs.moneybutton.com/docs/paymail/paymail-07-p2p-payment-destination.html
 	BRFCP2PPaymentDestinationWithToken = "f792b6eff07a"       // more info: https://docs.moneybutton.com/docs/paymail/paymail-11-p2p-payment-destination-tokens.html
 	BRFCP2PTransactions                = "5f1323cddf31"       // more info: https://docs.moneybutton.com/docs/paymail/paymail-06-p2p-transactions.html
@@ -200,6 +201,13 @@ const BRFCKnownSpecifications = `
    "url": "https://bsv.brc.dev/payments/0070",
    "version": "1.0.0"
   },
+  {
+   "author": "go-paymail",
+   "id": "e3f557059186",
+   "title": "Current Address",
+   "url": "https://github.com/AmanTrance/go-paymail/blob/master/current_address.go",
+   "version": "1"
+  },
   {
     "author": "Damian Orzepowski",
     "id": "8c4ed5ef8ace",
@@ -0,0 +1,96 @@
+package paymail
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// brfcIDLength is the length of a BRFC ID (hex characters)
+const brfcIDLength = 12
+
+// BRFCRegistry is a concurrency-safe lookup of BRFC specifications by ID and title
+type BRFCRegistry struct {
+	byID  map[string]*BRFCSpec
+	mu    sync.RWMutex
+	specs []*BRFCSpec
+}
+
+// NewBRFCRegistry will create a new empty registry
+func NewBRFCRegistry() *BRFCRegistry {
+	return &BRFCRegistry{
+		byID: make(map[string]*BRFCSpec),
+	}
+}
+
+// DefaultRegistry will create a new registry preloaded with the known BRFC specifications
+func DefaultRegistry() (*BRFCRegistry, error) {
+	specs, err := LoadBRFCs("")
+	if err != nil {
+		return nil, err
+	}
+
+	registry := NewBRFCRegistry()
+	for _, spec := range specs {
+		if err = registry.Add(spec); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Add will add the specification to the registry
+//
+// The ID must be a 12 character hex string, and an ID that is already registered is rejected
+func (r *BRFCRegistry) Add(spec *BRFCSpec) error {
+	if spec == nil {
+		return fmt.Errorf("brfc spec cannot be nil")
+	} else if !isValidBRFCID(spec.ID) {
+		return fmt.Errorf("brfc: [%s] has an invalid id: %s", spec.Title, spec.ID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[spec.ID]; ok {
+		return fmt.Errorf("brfc: [%s] id is already registered: %s", spec.Title, spec.ID)
+	}
+
+	r.byID[spec.ID] = spec
+	r.specs = append(r.specs, spec)
+	return nil
+}
+
+// GetByID will return the specification for the BRFC ID (if found)
+func (r *BRFCRegistry) GetByID(id string) (*BRFCSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	spec, ok := r.byID[strings.ToLower(strings.TrimSpace(id))]
+	return spec, ok
+}
+
+// GetByTitle will return all specifications (e.g. all versions) with the title (case-insensitive)
+func (r *BRFCRegistry) GetByTitle(title string) []*BRFCSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	title = strings.TrimSpace(title)
+	var specs []*BRFCSpec
+	for _, spec := range r.specs {
+		if strings.EqualFold(strings.TrimSpace(spec.Title), title) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// isValidBRFCID will return true if the id is a lowercase 12 character hex string
+func isValidBRFCID(id string) bool {
+	if len(id) != brfcIDLength || strings.ToLower(id) != id {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
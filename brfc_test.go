@@ -0,0 +1,54 @@
+package paymail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateBRFCs(t *testing.T) {
+	t.Run("ids are generated in place", func(t *testing.T) {
+		specs := []*BRFCSpec{
+			{Author: "andy (nChain)", Title: "BRFC Specifications", Version: "1"},
+			{Author: "andy (nChain)", Title: "bsvalias Payment Addressing (Payer Validation)", Version: "1"},
+		}
+		if err := GenerateBRFCs(specs, false); err != nil {
+			t.Fatal(err)
+		}
+		if specs[0].ID != "57dd1f54fc67" {
+			t.Errorf("expected the id 57dd1f54fc67, got %s", specs[0].ID)
+		}
+		if len(specs[1].ID) == 0 || specs[1].ID == specs[0].ID {
+			t.Errorf("expected a different id, got %s", specs[1].ID)
+		}
+	})
+
+	t.Run("colliding specs", func(t *testing.T) {
+		specs := []*BRFCSpec{
+			{Author: "andy (nChain)", Title: "BRFC Specifications", Version: "1"},
+			{Author: "andy (nChain)", Title: "BRFC Specifications", Version: "1"},
+			{Author: "andy (nChain)", Title: "BRFC Specifications", Version: "2"},
+		}
+		err := GenerateBRFCs(specs, false)
+		if err == nil || !strings.Contains(err.Error(), "57dd1f54fc67") {
+			t.Fatalf("expected the duplicate id 57dd1f54fc67, got %v", err)
+		}
+		if strings.Contains(err.Error(), specs[2].ID) {
+			t.Errorf("expected only the duplicate id, got %s", err)
+		}
+	})
+
+	t.Run("pre-set ids are kept unless forced", func(t *testing.T) {
+		specs := []*BRFCSpec{{Author: "andy (nChain)", ID: "custom", Title: "BRFC Specifications", Version: "1"}}
+		if err := GenerateBRFCs(specs, false); err != nil {
+			t.Fatal(err)
+		} else if specs[0].ID != "custom" {
+			t.Errorf("expected the pre-set id, got %s", specs[0].ID)
+		}
+
+		if err := GenerateBRFCs(specs, true); err != nil {
+			t.Fatal(err)
+		} else if specs[0].ID != "57dd1f54fc67" {
+			t.Errorf("expected the generated id, got %s", specs[0].ID)
+		}
+	})
+}
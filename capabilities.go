@@ -1,9 +1,13 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -29,7 +33,7 @@ type CapabilitiesResponse struct {
 // CapabilitiesPayload is the actual payload response
 type CapabilitiesPayload struct {
 	BsvAlias     string                 `json:"bsvalias"`     // Version of the bsvalias
-	Capabilities map[string]interface{} `json:"capabilities"` // Raw list of the capabilities
+	Capabilities map[string]interface{} `json:"capabilities"` // Raw list of the capabilities (including non-standard keys)
 	Pike         *PikeCapability        `json:"pike,omitempty"`
 }
 
@@ -70,28 +74,43 @@ func (c *CapabilitiesPayload) getValue(brfcID, alternateID string) (bool, interf
 
 // GetString will perform getValue() but cast to a string if found
 //
-// Returns an empty string if not found
+// Returns an empty string if not found (or not a string)
 func (c *CapabilitiesPayload) GetString(brfcID, alternateID string) string {
 	if ok, val := c.getValue(brfcID, alternateID); ok {
-		return val.(string)
+		str, _ := val.(string)
+		return str
 	}
 	return ""
 }
 
 // GetBool will perform getValue() but cast to a bool if found
 //
-// Returns false if not found
+// Returns false if not found (or not a bool)
 func (c *CapabilitiesPayload) GetBool(brfcID, alternateID string) bool {
 	if ok, val := c.getValue(brfcID, alternateID); ok {
-		return val.(bool)
+		b, _ := val.(bool)
+		return b
 	}
 	return false
 }
 
+// GetMap will perform getValue() but cast to a map if found (nested or custom capabilities, e.g. PIKE)
+//
+// Returns nil if not found (or not an object)
+func (c *CapabilitiesPayload) GetMap(brfcID, alternateID string) map[string]interface{} {
+	if ok, val := c.getValue(brfcID, alternateID); ok {
+		m, _ := val.(map[string]interface{})
+		return m
+	}
+	return nil
+}
+
 // GetCapabilities will return a list of capabilities for a given domain & port
 //
+// Results are served from the capabilities cache until the configured TTL expires.
+//
 // Specs: http://bsvalias.org/02-02-capability-discovery.html
-func (c *Client) GetCapabilities(target string, port int) (response *CapabilitiesResponse, err error) {
+func (c *Client) GetCapabilities(ctx context.Context, target string, port int) (response *CapabilitiesResponse, err error) {
 
 	// Basic requirements for the request
 	if len(target) == 0 {
@@ -102,13 +121,43 @@ func (c *Client) GetCapabilities(target string, port int) (response *Capabilitie
 		return
 	}
 
+	// Check the cache first
+	if response = c.capabilities.get(target, port); response != nil {
+		return
+	}
+
+	// Concurrent requests for the same host share a single fetch (the caller's context still applies while waiting)
+	key := cacheKey(target) + ":" + strconv.Itoa(port)
+	results := c.discovery.DoChan(key, func() (interface{}, error) {
+		fetched, fetchErr := c.fetchCapabilities(context.WithoutCancel(ctx), target, port)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		c.capabilities.set(target, port, fetched)
+		return fetched, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case result := <-results:
+		if err = result.Err; err == nil {
+			response = result.Val.(*CapabilitiesResponse)
+		}
+	}
+	return
+}
+
+// fetchCapabilities will request the capabilities document from the paymail provider
+func (c *Client) fetchCapabilities(ctx context.Context, target string, port int) (response *CapabilitiesResponse, err error) {
+
 	// Set the base url and path
 	// https://<host-discovery-target>:<host-discovery-port>/.well-known/bsvalias[network]
-	reqURL := fmt.Sprintf("https://%s:%d/.well-known/%s%s", target, port, DefaultServiceName, c.options.network.URLSuffix())
+	reqURL := fmt.Sprintf("%s://%s:%d/.well-known/%s%s", c.scheme(), target, port, DefaultServiceName, c.options.network.URLSuffix())
 
 	// Fire the GET request
 	var resp StandardResponse
-	if resp, err = c.getRequest(reqURL); err != nil {
+	if resp, err = c.getRequest(ctx, "capabilities", reqURL); err != nil {
 		return
 	}
 
@@ -117,11 +166,7 @@ func (c *Client) GetCapabilities(target string, port int) (response *Capabilitie
 
 	// Test the status code (200 or 304 is valid)
 	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
-		serverError := &ServerError{}
-		if err = json.Unmarshal(resp.Body, serverError); err != nil {
-			return
-		}
-		err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
+		err = c.prepareServerErrorResponse(&resp)
 		return
 	}
 
@@ -152,6 +197,12 @@ func (c *Client) GetCapabilities(target string, port int) (response *Capabilitie
 		return
 	}
 
+	// Incompatible (major) version detected
+	if c.options.strictBsvAlias && !isCompatibleBsvAlias(response.BsvAlias) {
+		err = fmt.Errorf("incompatible %s version: %s (supported: %s)", DefaultServiceName, response.BsvAlias, DefaultBsvAliasVersion)
+		return
+	}
+
 	// Parse PIKE capability
 	if err = parsePikeCapability(response); err != nil {
 		return
@@ -160,6 +211,100 @@ func (c *Client) GetCapabilities(target string, port int) (response *Capabilitie
 	return
 }
 
+// ErrCapabilityNotSupported is returned when the paymail provider does not advertise the capability
+var ErrCapabilityNotSupported = errors.New("capability not supported")
+
+// ErrCapabilityNotFound is the same error as ErrCapabilityNotSupported (e.g. for errors.Is checks of FetchPublicProfile)
+var ErrCapabilityNotFound = ErrCapabilityNotSupported
+
+// discoverCapabilityURL will look up the SRV record and capabilities of the domain
+// and return the URL of the given capability
+//
+// Returns ErrCapabilityNotSupported if the capability is not advertised by the paymail provider
+func (c *Client) discoverCapabilityURL(ctx context.Context, domain, brfcID, alternateID string) (string, error) {
+	srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, domain)
+	if err != nil {
+		return "", err
+	}
+
+	var capabilities *CapabilitiesResponse
+	if capabilities, err = c.GetCapabilities(ctx, srv.Target, int(srv.Port)); err != nil {
+		return "", err
+	}
+
+	capabilityURL := capabilities.GetString(brfcID, alternateID)
+	if len(capabilityURL) == 0 {
+		return "", fmt.Errorf("paymail provider %s does not support the %s capability: %w", domain, brfcID, ErrCapabilityNotSupported)
+	} else if isDiscoveryURL(capabilityURL) {
+		return "", fmt.Errorf("paymail provider %s %s capability points at the capability discovery: %w",
+			domain, brfcID, ErrResolutionLoop)
+	}
+	return capabilityURL, nil
+}
+
+// checkCapability will confirm that the host of the request URL advertises (one of) the capabilities
+//
+// Only the cached capabilities are used (no request is made), a host without cached capabilities is not checked.
+// Returns ErrCapabilityNotSupported (with the BRFC ID) if none of the capabilities are advertised
+func (c *Client) checkCapability(requestURL string, brfcIDs ...string) error {
+	parsedURL, err := url.Parse(requestURL)
+	if err != nil || len(brfcIDs) == 0 {
+		return nil
+	}
+
+	// The cached capabilities of the providers with a capability URL on the host of the request
+	// (the host of the capability URLs can differ from the SRV target)
+	found := c.capabilities.lookupURLHost(parsedURL.Hostname())
+	if len(found) == 0 {
+		return nil
+	}
+
+	for _, capabilities := range found {
+		for _, brfcID := range brfcIDs {
+			if capabilities.Has(brfcID, "") {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("paymail provider %s does not support the %s capability: %w",
+		parsedURL.Hostname(), brfcIDs[0], ErrCapabilityNotSupported)
+}
+
+// hasURLHost will return true if a capability URL (including the nested and PIKE capabilities) is on the host
+func (c *CapabilitiesPayload) hasURLHost(host string) bool {
+	var isOnHost func(value interface{}) bool
+	isOnHost = func(value interface{}) bool {
+		switch typed := value.(type) {
+		case string:
+			capabilityURL, err := url.Parse(typed)
+			return err == nil && cacheKey(capabilityURL.Hostname()) == host
+		case *string:
+			return typed != nil && isOnHost(*typed)
+		case map[string]interface{}:
+			for _, nested := range typed {
+				if isOnHost(nested) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, value := range c.Capabilities {
+		if isOnHost(value) {
+			return true
+		}
+	}
+	return c.Pike != nil && (isOnHost(c.Pike.Invite) || isOnHost(c.Pike.Outputs))
+}
+
+// isCompatibleBsvAlias will return true if the major version matches the supported DefaultBsvAliasVersion
+func isCompatibleBsvAlias(version string) bool {
+	major, _, _ := strings.Cut(strings.TrimSpace(version), ".")
+	supported, _, _ := strings.Cut(DefaultBsvAliasVersion, ".")
+	return major == supported
+}
+
 // ExtractPikeOutputsURL extracts the outputs URL from the PIKE capability
 func (c *CapabilitiesPayload) ExtractPikeOutputsURL() string {
 	if c.Pike != nil {
@@ -0,0 +1,159 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// capabilitiesCache is a concurrency-safe in-memory cache of capabilities by host
+type capabilitiesCache struct {
+	entries map[string]*capabilitiesCacheEntry
+	mu      sync.RWMutex
+	ttl     time.Duration
+}
+
+// capabilitiesCacheEntry is a single cached capabilities response
+type capabilitiesCacheEntry struct {
+	expiresAt time.Time
+	port      int
+	response  *CapabilitiesResponse
+}
+
+// newCapabilitiesCache will create a new cache with the given TTL
+func newCapabilitiesCache(ttl time.Duration) *capabilitiesCache {
+	return &capabilitiesCache{
+		entries: make(map[string]*capabilitiesCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// get will return the cached capabilities (if found and not expired)
+func (c *capabilitiesCache) get(target string, port int) *CapabilitiesResponse {
+	if c == nil || c.ttl <= 0 {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[cacheKey(target)]
+	if !ok || entry.port != port || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.response
+}
+
+// set will store the capabilities for the given target
+func (c *capabilitiesCache) set(target string, port int, response *CapabilitiesResponse) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[cacheKey(target)] = &capabilitiesCacheEntry{
+		expiresAt: time.Now().Add(c.ttl),
+		port:      port,
+		response:  response,
+	}
+}
+
+// lookupURLHost will return the cached capabilities (not expired) with a capability URL on the host
+//
+// The capabilities are cached by SRV target, the capability URLs can be on another host
+func (c *capabilitiesCache) lookupURLHost(host string) []*CapabilitiesResponse {
+	if c == nil || c.ttl <= 0 {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	host = cacheKey(host)
+	now := time.Now()
+	var found []*CapabilitiesResponse
+	for _, entry := range c.entries {
+		if now.Before(entry.expiresAt) && entry.response.hasURLHost(host) {
+			found = append(found, entry.response)
+		}
+	}
+	return found
+}
+
+// clear will remove the cached capabilities for the given target
+func (c *capabilitiesCache) clear(target string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, cacheKey(target))
+}
+
+// cacheKey will standardize the host used as a cache key
+func cacheKey(target string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(target)), ".")
+}
+
+// ClearCapabilitiesCache will remove any cached capabilities for the given domain
+//
+// The capabilities are cached by SRV target, so the SRV record of the domain is looked up to clear its target
+func (c *Client) ClearCapabilitiesCache(domain string) {
+	c.capabilities.clear(domain)
+	if srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, domain); err == nil {
+		c.capabilities.clear(srv.Target)
+	}
+}
+
+// WarmCapabilities will fetch and cache the capabilities of the domains concurrently (e.g. at startup)
+//
+// The cache is populated for every domain that succeeds, the failures are returned as a combined error.
+// Capabilities that are already cached are not fetched again, the concurrency is set using WithResolveConcurrency()
+func (c *Client) WarmCapabilities(ctx context.Context, domains []string) error {
+	jobs := make(chan int)
+	failures := make([]error, len(domains))
+	var wg sync.WaitGroup
+	for i := 0; i < c.resolveWorkers() && i < len(domains); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if err := c.warmDomain(ctx, domains[index]); err != nil {
+					failures[index] = fmt.Errorf("failed to warm the capabilities of %s: %w", domains[index], err)
+				}
+			}
+		}()
+	}
+
+	// Queue the domains (stop when the context is done)
+	for index := range domains {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// warmDomain will look up the SRV record of the domain and fetch (and cache) its capabilities
+func (c *Client) warmDomain(ctx context.Context, domain string) error {
+	srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, domain)
+	if err != nil {
+		return err
+	}
+	_, err = c.GetCapabilities(ctx, srv.Target, int(srv.Port))
+	return err
+}
@@ -0,0 +1,78 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckCapability_URLHost(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := client.(*Client)
+
+	// The capability URLs are on another host than the SRV target
+	c.capabilities.set("srv.example.com", DefaultPort, &CapabilitiesResponse{
+		CapabilitiesPayload: CapabilitiesPayload{
+			BsvAlias: DefaultBsvAliasVersion,
+			Capabilities: map[string]interface{}{
+				BRFCPki: "https://api.example.com/v1/bsvalias/id/{alias}@{domain.tld}",
+			},
+		},
+	})
+
+	if err = c.checkCapability("https://api.example.com/v1/bsvalias/id/alice@example.com", BRFCPki); err != nil {
+		t.Errorf("expected the capability to be supported, got %s", err)
+	}
+	if err = c.checkCapability("https://api.example.com/v1/bsvalias/public-profile/alice@example.com",
+		BRFCPublicProfile); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("expected ErrCapabilityNotSupported, got %v", err)
+	}
+
+	// A host without cached capabilities is not checked
+	if err = c.checkCapability("https://other.example.com/public-profile/alice@example.com", BRFCPublicProfile); err != nil {
+		t.Errorf("expected the unknown host to be skipped, got %s", err)
+	}
+}
+
+func TestClearCapabilitiesCache_SRVTarget(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bsvalias":"` + DefaultBsvAliasVersion + `","capabilities":{"pki":"https://test.com/id"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	// The SRV target (127.0.0.1) differs from the domain
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	target := &net.SRV{Target: addr.IP.String(), Port: uint16(addr.Port)}
+	client, err := NewClient(WithInsecureHTTP(true), WithResolver(&srvResolver{target: target}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getCapabilities := func() {
+		t.Helper()
+		if _, err = client.GetCapabilities(context.Background(), target.Target, int(target.Port)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	getCapabilities()
+	getCapabilities()
+	if count := fetches.Load(); count != 1 {
+		t.Fatalf("expected the capabilities to be cached, got %d fetches", count)
+	}
+
+	client.ClearCapabilitiesCache("test.com")
+	getCapabilities()
+	if count := fetches.Load(); count != 2 {
+		t.Errorf("expected the capabilities to be fetched again, got %d fetches", count)
+	}
+}
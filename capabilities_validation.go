@@ -0,0 +1,109 @@
+package paymail
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// urlTemplatePlaceholders are the placeholders required in the URL template of the known capabilities
+var urlTemplatePlaceholders = map[string][]string{
+	BRFCBasicAddressResolution: {aliasPlaceholder, domainPlaceholder},
+	BRFCBeefTransaction:        {aliasPlaceholder, domainPlaceholder},
+	BRFCCurrentAddress:         {aliasPlaceholder, domainPlaceholder},
+	BRFCP2PPaymentDestination:  {aliasPlaceholder, domainPlaceholder},
+	BRFCP2PTransactions:        {aliasPlaceholder, domainPlaceholder},
+	BRFCPaymentDestination:     {aliasPlaceholder, domainPlaceholder},
+	BRFCPki:                    {aliasPlaceholder, domainPlaceholder},
+	BRFCPkiAlternate:           {aliasPlaceholder, domainPlaceholder},
+	BRFCPublicProfile:          {aliasPlaceholder, domainPlaceholder},
+	BRFCVerifyPublicKeyOwner:   {aliasPlaceholder, domainPlaceholder, pubKeyPlaceholder},
+}
+
+// nestedURLTemplatePlaceholders are the placeholders required in the URL templates of the known nested capabilities
+var nestedURLTemplatePlaceholders = map[string][]string{
+	BRFCPike:              {aliasPlaceholder, domainPlaceholder},
+	BRFCReceiverApprovals: {aliasPlaceholder, domainPlaceholder},
+}
+
+// Validate will check the capabilities document, returning a (combined) error listing every problem
+//
+// The bsvalias version must be present and the URL template of each known capability must use https
+// and contain its required placeholders. Unknown capabilities are not checked
+func (c *CapabilitiesPayload) Validate() error {
+	return c.validate(false)
+}
+
+// ValidateInsecure will perform Validate() but also allow http (no TLS) URL templates (local testing only)
+func (c *CapabilitiesPayload) ValidateInsecure() error {
+	return c.validate(true)
+}
+
+// validate will check the capabilities document (see Validate())
+func (c *CapabilitiesPayload) validate(allowInsecureHTTP bool) error {
+	var problems []error
+	if len(strings.TrimSpace(c.BsvAlias)) == 0 {
+		problems = append(problems, fmt.Errorf("missing %s version", DefaultServiceName))
+	}
+
+	// Sorted, so the problems are always listed in the same order
+	keys := make([]string, 0, len(c.Capabilities))
+	for key := range c.Capabilities {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := c.Capabilities[key]
+		if key == BRFCSenderValidation {
+			if _, ok := value.(bool); !ok {
+				problems = append(problems, fmt.Errorf("capability %s must be a bool", key))
+			}
+		} else if placeholders, ok := urlTemplatePlaceholders[key]; ok {
+			problems = append(problems, validateURLTemplate(key, value, placeholders, allowInsecureHTTP)...)
+		} else if placeholders, ok = nestedURLTemplatePlaceholders[key]; ok {
+			nested, isMap := value.(map[string]interface{})
+			if !isMap {
+				problems = append(problems, fmt.Errorf("capability %s must be an object", key))
+				continue
+			}
+			nestedKeys := make([]string, 0, len(nested))
+			for nestedKey := range nested {
+				nestedKeys = append(nestedKeys, nestedKey)
+			}
+			sort.Strings(nestedKeys)
+			for _, nestedKey := range nestedKeys {
+				problems = append(problems, validateURLTemplate(
+					key+"."+nestedKey, nested[nestedKey], placeholders, allowInsecureHTTP,
+				)...)
+			}
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// validateURLTemplate will return the problems of the URL template of a capability
+func validateURLTemplate(key string, value interface{}, placeholders []string, allowInsecureHTTP bool) []error {
+	template, ok := value.(string)
+	if !ok || len(template) == 0 {
+		return []error{fmt.Errorf("capability %s must be a url template", key)}
+	}
+
+	var problems []error
+	if parsedURL, err := url.Parse(template); err != nil || len(parsedURL.Host) == 0 {
+		problems = append(problems, fmt.Errorf("capability %s has an invalid url template: %s", key, template))
+	} else if parsedURL.Scheme != "https" && !(allowInsecureHTTP && parsedURL.Scheme == "http") {
+		problems = append(problems, fmt.Errorf("capability %s must use https: %s", key, template))
+	}
+	for _, placeholder := range placeholders {
+		if !strings.Contains(template, placeholder) {
+			problems = append(problems, fmt.Errorf(
+				"capability %s url template is missing the %s placeholder: %s", key, placeholder, template,
+			))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,57 @@
+package paymail
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCertPinMismatch is returned when the certificate of a pinned domain does not match any of its pins
+var ErrCertPinMismatch = errors.New("certificate does not match any pinned public key")
+
+// pinPrefix is the optional prefix of a pin (sha256/<base64>)
+const pinPrefix = "sha256/"
+
+// normalizePins will standardize the domains and strip the optional prefix from the pins
+func normalizePins(pins map[string][]string) map[string]map[string]struct{} {
+	normalized := make(map[string]map[string]struct{}, len(pins))
+	for domain, domainPins := range pins {
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if normalized[domain] == nil {
+			normalized[domain] = make(map[string]struct{}, len(domainPins))
+		}
+		for _, pin := range domainPins {
+			normalized[domain][strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix)] = struct{}{}
+		}
+	}
+	return normalized
+}
+
+// spkiPin will return the base64 encoded SHA-256 hash of the certificate's SubjectPublicKeyInfo
+func spkiPin(rawSubjectPublicKeyInfo []byte) string {
+	hash := sha256.Sum256(rawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// verifyPinnedConnection will return a tls VerifyConnection callback enforcing the pins
+//
+// A connection to a pinned domain must have at least one certificate in the chain matching a pin,
+// domains without pins are not checked
+func verifyPinnedConnection(pins map[string][]string) func(cs tls.ConnectionState) error {
+	normalized := normalizePins(pins)
+	return func(cs tls.ConnectionState) error {
+		domainPins, ok := normalized[strings.TrimSuffix(strings.ToLower(cs.ServerName), ".")]
+		if !ok {
+			return nil
+		}
+		for _, cert := range cs.PeerCertificates {
+			if _, found := domainPins[spkiPin(cert.RawSubjectPublicKeyInfo)]; found {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w for %s", ErrCertPinMismatch, cs.ServerName)
+	}
+}
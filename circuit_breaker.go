@@ -0,0 +1,77 @@
+package paymail
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned (without a request) while the circuit breaker of the host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker stops the requests to a host after consecutive failures (see WithCircuitBreaker())
+type circuitBreaker struct {
+	cooldown  time.Duration
+	hosts     map[string]*hostCircuit
+	mu        sync.Mutex
+	threshold int
+}
+
+// hostCircuit is the state of the circuit of a single host
+type hostCircuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// newCircuitBreaker will create a circuit breaker (nil if the threshold is not set)
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 || cooldown <= 0 {
+		return nil
+	}
+	return &circuitBreaker{
+		cooldown:  cooldown,
+		hosts:     make(map[string]*hostCircuit),
+		threshold: threshold,
+	}
+}
+
+// allow will return false while the circuit of the host is open
+//
+// After the cooldown a request is allowed again (a failure opens the circuit for another cooldown)
+func (b *circuitBreaker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	circuit, ok := b.hosts[host]
+	return !ok || !time.Now().Before(circuit.openUntil)
+}
+
+// record will reset the circuit of the host on success, or count the failure (opening the circuit at the threshold)
+func (b *circuitBreaker) record(host string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		delete(b.hosts, host)
+		return
+	}
+
+	circuit, ok := b.hosts[host]
+	if !ok {
+		circuit = &hostCircuit{}
+		b.hosts[host] = circuit
+	}
+	circuit.failures++
+	if circuit.failures >= b.threshold {
+		circuit.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// circuitHost will return the (lowercase) host of the request URL used as the circuit key
+func circuitHost(requestURL string) string {
+	parsedURL, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsedURL.Hostname())
+}
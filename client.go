@@ -1,34 +1,66 @@
 package paymail
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/AmanTrance/go-paymail/interfaces"
+	"github.com/AmanTrance/go-paymail/logging"
 	"github.com/go-resty/resty/v2"
+	"golang.org/x/sync/singleflight"
 )
 
 type (
 	// Client is the Paymail client configuration and options
 	Client struct {
-		httpClient *resty.Client          // HTTP client for GET/POST requests
-		options    *ClientOptions         // Options are all the default settings / configuration
-		resolver   interfaces.DNSResolver // Resolver for DNS look ups
+		breaker      *circuitBreaker        // Circuit breaker by host (nil if disabled)
+		capabilities *capabilitiesCache     // Cache of capabilities by host
+		discovery    singleflight.Group     // Shares the in-flight capabilities requests by host
+		httpClient   *resty.Client          // HTTP client for GET/POST requests
+		options      *ClientOptions         // Options are all the default settings / configuration
+		resolver     interfaces.DNSResolver // Resolver for DNS look ups
 	}
 
 	// ClientOptions holds all the configuration for client requests and default resources
 	ClientOptions struct {
-		brfcSpecs         []*BRFCSpec   // List of BRFC specifications
-		dnsPort           string        // Default DNS port for SRV checks
-		dnsTimeout        time.Duration // Default timeout in seconds for DNS fetching
-		httpTimeout       time.Duration // Default timeout in seconds for GET requests
-		nameServer        string        // Default name server for DNS checks
-		nameServerNetwork string        // Default name server network
-		requestTracing    bool          // If enabled, it will trace the request timing
-		retryCount        int           // Default retry count for HTTP requests
-		sslDeadline       time.Duration // Default timeout in seconds for SSL deadline
-		sslTimeout        time.Duration // Default timeout in seconds for SSL timeout
-		userAgent         string        // User agent for all outgoing requests
-		network           Network       // The bitcoin network to operate on
+		brfcSpecs          []*BRFCSpec            // List of BRFC specifications
+		capabilitiesTTL    time.Duration          // Default time to keep capabilities in the cache (0 disables the cache)
+		circuitCooldown    time.Duration          // Time the circuit of a failing host stays open
+		circuitThreshold   int                    // Consecutive failures of a host before the circuit opens (0 disables the breaker)
+		dialNetwork        string                 // Network used to dial the hosts (tcp, tcp4 or tcp6)
+		dnssec             bool                   // If enabled, SRV records must pass DNSSEC validation
+		dnsPort            string                 // Default DNS port for SRV checks
+		dnsTimeout         time.Duration          // Default timeout in seconds for DNS fetching
+		headers            map[string]string      // Custom headers for all outgoing requests
+		httpTimeout        time.Duration          // Default timeout in seconds of the default HTTP client
+		interceptor        ResponseInterceptor    // Observes the raw request and response of every HTTP request
+		insecureHTTP       bool                   // If enabled, http (no TLS) urls are allowed (local testing only)
+		maxRedirects       int                    // Maximum redirects followed by a request (more returns ErrResolutionLoop)
+		nameServer         string                 // Default name server for DNS checks
+		nameServerNetwork  string                 // Default name server network
+		paymentFallback    bool                   // If enabled, GetPaymentDestination() falls back to basic address resolution
+		pinnedCerts        map[string][]string    // SPKI SHA-256 pins (base64) by domain
+		proxyURL           string                 // Proxy for all HTTP requests (http, https or socks5)
+		requestTimeout     time.Duration          // Deadline of every outgoing paymail request (0 uses the HTTP timeout)
+		requestTracing     bool                   // If enabled, it will trace the request timing
+		resolveConcurrency int                    // Default number of concurrent requests for ResolveAddresses()
+		resolver           interfaces.DNSResolver // Custom resolver for DNS look ups (default is net.Resolver)
+		retryCount         int                    // Default retry count for HTTP requests
+		retryDelay         time.Duration          // Base delay of the exponential backoff (0 disables the GET retry policy)
+		sslDeadline        time.Duration          // Default timeout in seconds for SSL deadline
+		sslTimeout         time.Duration          // Default timeout in seconds for SSL timeout
+		strictBsvAlias     bool                   // If enabled, capabilities with an incompatible bsvalias (major) version are rejected
+		userAgent          string                 // User agent for all outgoing requests
+		wellKnownFallback  bool                   // If enabled, a domain without an SRV record is discovered at <domain>:443
+		network            Network                // The bitcoin network to operate on
 	}
 )
 
@@ -61,7 +93,31 @@ func NewClient(opts ...ClientOps) (ClientInterface, error) {
 		}
 	}
 
+	// Check the proxy (if set)
+	if len(client.options.proxyURL) > 0 {
+		if err = validateProxyURL(client.options.proxyURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the dial network (if set)
+	if !isValidDialNetwork(client.options.dialNetwork) {
+		return nil, fmt.Errorf("unsupported dial network: %s", client.options.dialNetwork)
+	}
+
+	// Plain http is only meant for local testing
+	if client.options.insecureHTTP {
+		logging.GetDefaultLogger().Warn().Msg("paymail client is using insecure http (no TLS), this is for local testing only")
+	}
+
+	// Set the circuit breaker (if enabled)
+	client.breaker = newCircuitBreaker(client.options.circuitThreshold, client.options.circuitCooldown)
+
+	// Set the capabilities cache
+	client.capabilities = newCapabilitiesCache(client.options.capabilitiesTTL)
+
 	// Set the resolver
+	client.resolver = client.options.resolver
 	if client.resolver == nil {
 		r := client.defaultResolver()
 		client.resolver = &r
@@ -74,6 +130,40 @@ func NewClient(opts ...ClientOps) (ClientInterface, error) {
 		// Set defaults (for GET requests)
 		client.httpClient.SetTimeout(client.options.httpTimeout)
 		client.httpClient.SetRetryCount(client.options.retryCount)
+
+		// Stop redirect loops (and long redirect chains)
+		client.httpClient.SetRedirectPolicy(redirectPolicy(client.options.maxRedirects))
+
+		// Only retry GET requests on network errors, 429 and 5xx (with exponential backoff)
+		if client.options.retryDelay > 0 {
+			client.httpClient.SetRetryWaitTime(client.options.retryDelay).
+				SetRetryMaxWaitTime(defaultRetryMaxDelay).
+				SetRetryAfter(retryAfter).
+				AddRetryCondition(retryCondition)
+		} else {
+			client.httpClient.AddRetryCondition(retryOnError)
+		}
+
+		// Enforce the pinned certificates (domains without pins are not checked)
+		if len(client.options.pinnedCerts) > 0 {
+			client.httpClient.SetTLSClientConfig(&tls.Config{
+				VerifyConnection: verifyPinnedConnection(client.options.pinnedCerts),
+			})
+		}
+
+		// Only dial the hosts in the family of the dial network
+		if client.options.dialNetwork == DialNetworkIPv4 || client.options.dialNetwork == DialNetworkIPv6 {
+			var transport *http.Transport
+			if transport, err = client.httpClient.Transport(); err != nil {
+				return nil, err
+			}
+			transport.DialContext = client.dialContext
+		}
+
+		// Route all requests through the proxy
+		if len(client.options.proxyURL) > 0 {
+			client.httpClient.SetProxy(client.options.proxyURL)
+		}
 	}
 	return client, nil
 }
@@ -99,19 +189,76 @@ func (c *Client) GetResolver() interfaces.DNSResolver {
 }
 
 // getRequest is a standard GET request for all outgoing HTTP requests
-func (c *Client) getRequest(requestURL string) (response StandardResponse, err error) {
+func (c *Client) getRequest(ctx context.Context, operation, requestURL string) (response StandardResponse, err error) {
 
-	// Set the user agent
-	req := c.httpClient.R().SetHeader("User-Agent", c.options.userAgent)
+	return c.fireRequest(ctx, c.newRequest(), http.MethodGet, operation, requestURL)
+}
+
+// postRequest is a standard POST request for all outgoing HTTP requests
+func (c *Client) postRequest(ctx context.Context, operation, requestURL string, data interface{}) (response StandardResponse, err error) {
+
+	// Set the content type
+	req := c.newRequest().SetBody(data).
+		SetHeader("Content-Type", "application/json")
+
+	return c.fireRequest(ctx, req, http.MethodPost, operation, requestURL)
+}
+
+// newRequest will start a new request with the custom headers and the user agent
+func (c *Client) newRequest() *resty.Request {
+	return c.httpClient.R().
+		SetHeaders(c.options.headers).
+		SetHeader("User-Agent", c.options.userAgent)
+}
+
+// fireRequest will execute the request using the client timeout and tracing options
+func (c *Client) fireRequest(ctx context.Context, req *resty.Request, method, operation,
+	requestURL string) (response StandardResponse, err error) {
+
+	// Apply the timeout to every request (also when a custom HTTP client is used)
+	reqCtx := ctx
+	if timeout := c.options.timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req.SetContext(c.withRequestStart(reqCtx))
 
 	// Enable tracing
 	if c.options.requestTracing {
 		req.EnableTrace()
 	}
 
+	// Fail fast while the circuit of the host is open
+	host := circuitHost(requestURL)
+	if c.breaker != nil && !c.breaker.allow(host) {
+		err = fmt.Errorf("paymail %s request to %s: %w", operation, host, ErrCircuitOpen)
+		return
+	}
+
 	// Fire the request
 	var resp *resty.Response
-	if resp, err = req.Get(requestURL); err != nil {
+	resp, err = req.Execute(method, requestURL)
+	c.intercept(req, resp, err)
+	if c.breaker != nil && ctx.Err() == nil {
+		c.breaker.record(host, err != nil || isRetryableStatus(resp.StatusCode()))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("paymail %s request: %w", operation, ctx.Err())
+		} else {
+			err = wrapRequestError(operation, requestURL, err)
+		}
+		if req.Attempt > 1 {
+			err = fmt.Errorf("paymail %s request failed after %d attempts: %w", operation, req.Attempt, err)
+		}
+		return
+	}
+
+	// Retries were exhausted on a retryable status
+	if c.options.retryDelay > 0 && req.Attempt > 1 && isRetryableStatus(resp.StatusCode()) {
+		err = fmt.Errorf("paymail %s request failed after %d attempts, last status: %d",
+			operation, req.Attempt, resp.StatusCode())
 		return
 	}
 
@@ -128,32 +275,65 @@ func (c *Client) getRequest(requestURL string) (response StandardResponse, err e
 	return
 }
 
-// postRequest is a standard POST request for all outgoing HTTP requests
-func (c *Client) postRequest(requestURL string, data interface{}) (response StandardResponse, err error) {
+// prepareServerErrorResponse will return the error for a bad response from the paymail provider
+//
+// A standard error body is returned as a *PaymailError, otherwise a generic error including the raw body
+func (c *Client) prepareServerErrorResponse(response *StandardResponse) error {
+	serverError := &ServerError{}
+	if err := json.Unmarshal(response.Body, serverError); err != nil || serverError.Message == "" {
+		return fmt.Errorf("bad response from paymail provider: code %d, body: %s", response.StatusCode, string(response.Body))
+	}
 
-	// Set the user agent
-	req := c.httpClient.R().SetBody(data).SetHeader("User-Agent", c.options.userAgent)
+	return &PaymailError{
+		Code:       serverError.Code,
+		Message:    serverError.Message,
+		StatusCode: response.StatusCode,
+	}
+}
 
-	// Enable tracing
-	if c.options.requestTracing {
-		req.EnableTrace()
+// scheme will return the url scheme used for the requests (http only if WithInsecureHTTP() is enabled)
+func (c *Client) scheme() string {
+	if c.options.insecureHTTP {
+		return "http"
 	}
+	return "https"
+}
 
-	// Fire the request
-	var resp *resty.Response
-	if resp, err = req.Post(requestURL); err != nil {
-		return
+// isAllowedURL will return true if the url uses https (or http if WithInsecureHTTP() is enabled)
+func (c *Client) isAllowedURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "https://") ||
+		(c.options.insecureHTTP && strings.HasPrefix(rawURL, "http://"))
+}
+
+// validateProxyURL will check that the proxy URL is valid and uses a supported scheme
+func validateProxyURL(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url: %w", err)
 	}
 
-	// Tracing enabled?
-	if c.options.requestTracing {
-		response.Tracing = resp.Request.TraceInfo()
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
 	}
 
-	// Set the status code
-	response.StatusCode = resp.StatusCode()
+	if len(u.Host) == 0 {
+		return fmt.Errorf("invalid proxy url, missing host: %s", proxyURL)
+	}
+	return nil
+}
 
-	// Set the body
-	response.Body = resp.Body()
-	return
+// wrapRequestError will add the paymail operation and host to a timed out request error
+func wrapRequestError(operation, requestURL string, err error) error {
+	var netErr net.Error
+	if !errors.Is(err, context.DeadlineExceeded) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		return err
+	}
+
+	host := requestURL
+	if u, parseErr := url.Parse(requestURL); parseErr == nil {
+		host = u.Host
+	}
+	return fmt.Errorf("paymail %s request to %s timed out: %w", operation, host, err)
 }
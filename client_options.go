@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/AmanTrance/go-paymail/interfaces"
+	"github.com/AmanTrance/go-paymail/logging"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -17,17 +18,22 @@ type ClientOps func(c *ClientOptions)
 func defaultClientOptions() (opts *ClientOptions, err error) {
 	// Set the default options
 	opts = &ClientOptions{
-		dnsPort:           defaultDNSPort,
-		dnsTimeout:        defaultDNSTimeout,
-		httpTimeout:       defaultHTTPTimeout,
-		nameServer:        defaultNameServer,
-		nameServerNetwork: defaultNameServerNetwork,
-		requestTracing:    false,
-		retryCount:        defaultRetryCount,
-		sslDeadline:       defaultSSLDeadline,
-		sslTimeout:        defaultSSLTimeout,
-		userAgent:         defaultUserAgent,
-		network:           Network(defaultNetwork),
+		capabilitiesTTL:    defaultCapabilitiesTTL,
+		dnsPort:            defaultDNSPort,
+		dnsTimeout:         defaultDNSTimeout,
+		httpTimeout:        defaultHTTPTimeout,
+		maxRedirects:       defaultMaxRedirects,
+		nameServer:         defaultNameServer,
+		nameServerNetwork:  defaultNameServerNetwork,
+		paymentFallback:    true,
+		requestTracing:     false,
+		resolveConcurrency: defaultResolveConcurrency,
+		retryCount:         defaultRetryCount,
+		sslDeadline:        defaultSSLDeadline,
+		sslTimeout:         defaultSSLTimeout,
+		userAgent:          defaultUserAgent,
+		wellKnownFallback:  true,
+		network:            Network(defaultNetwork),
 	}
 
 	// Load the default BRFC specs
@@ -35,6 +41,16 @@ func defaultClientOptions() (opts *ClientOptions, err error) {
 	return
 }
 
+// WithDialNetwork will set the network used to dial the hosts after the SRV resolution:
+// DialNetworkIPv4 ("tcp4") or DialNetworkIPv6 ("tcp6") only dial the addresses of that family.
+// Default is dual-stack ("tcp").
+// Not applied to a custom HTTP client (see WithCustomHTTPClient).
+func WithDialNetwork(network string) ClientOps {
+	return func(c *ClientOptions) {
+		c.dialNetwork = network
+	}
+}
+
 // WithDNSPort can be supplied with a custom dns port to perform SRV checks on.
 // Default is 53.
 func WithDNSPort(port string) ClientOps {
@@ -43,6 +59,15 @@ func WithDNSPort(port string) ClientOps {
 	}
 }
 
+// WithDNSSEC will require the SRV records to be validated using DNSSEC.
+// The name server must be a DNSSEC-validating resolver.
+// Default is disabled.
+func WithDNSSEC(enabled bool) ClientOps {
+	return func(c *ClientOptions) {
+		c.dnssec = enabled
+	}
+}
+
 // WithDNSTimeout can be supplied to overwrite the default dns srv check timeout.
 // The default is 5 seconds.
 func WithDNSTimeout(timeout time.Duration) ClientOps {
@@ -58,8 +83,28 @@ func WithBRFCSpecs(specs []*BRFCSpec) ClientOps {
 	}
 }
 
+// WithCapabilitiesTTL will overwrite the default time capabilities are cached per host.
+// A TTL of 0 disables the cache.
+// Default is 5 minutes.
+func WithCapabilitiesTTL(ttl time.Duration) ClientOps {
+	return func(c *ClientOptions) {
+		c.capabilitiesTTL = ttl
+	}
+}
+
+// WithCircuitBreaker will stop the requests to a host after the threshold of consecutive failures
+// (network errors, 429 or 5xx). While open, the requests fail fast with ErrCircuitOpen for the cooldown,
+// a successful request resets the failures of the host.
+// Default is disabled.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOps {
+	return func(c *ClientOptions) {
+		c.circuitThreshold = threshold
+		c.circuitCooldown = cooldown
+	}
+}
+
 // WithHTTPTimeout can be supplied to adjust the default http client timeouts.
-// The http client is used when querying paymail services for capabilities
+// It is also the request timeout if WithRequestTimeout() is not set.
 // Default timeout is 20 seconds.
 func WithHTTPTimeout(timeout time.Duration) ClientOps {
 	return func(c *ClientOptions) {
@@ -83,6 +128,54 @@ func WithNameServerNetwork(network string) ClientOps {
 	}
 }
 
+// WithInsecureHTTP will allow http (no TLS) for the capability discovery and all subsequent requests.
+// This is for local testing only (a warning is logged when enabled), never use it in production.
+// Default is disabled (https is required).
+func WithInsecureHTTP(enabled bool) ClientOps {
+	return func(c *ClientOptions) {
+		c.insecureHTTP = enabled
+	}
+}
+
+// WithMaxRedirects will set the maximum number of redirects followed by a request (0 rejects any redirect).
+// A redirect loop, or more redirects, returns ErrResolutionLoop.
+// The redirect policy of a custom HTTP client is not changed (see WithCustomHTTPClient).
+// Default is 3.
+func WithMaxRedirects(redirects int) ClientOps {
+	return func(c *ClientOptions) {
+		c.maxRedirects = redirects
+	}
+}
+
+// WithPaymentDestinationFallback will enable/disable the fallback to basic address resolution
+// in GetPaymentDestination() when P2P payment destination is not supported.
+// Default is enabled.
+func WithPaymentDestinationFallback(enabled bool) ClientOps {
+	return func(c *ClientOptions) {
+		c.paymentFallback = enabled
+	}
+}
+
+// WithPinnedCerts will pin the TLS certificates of the domains (domain => base64 SPKI SHA-256 pins).
+// Requests to a pinned domain fail with ErrCertPinMismatch if no certificate in the chain matches.
+// The pins are not enforced by a custom HTTP client (see WithCustomHTTPClient).
+// Default is no pins.
+func WithPinnedCerts(pins map[string][]string) ClientOps {
+	return func(c *ClientOptions) {
+		c.pinnedCerts = pins
+	}
+}
+
+// WithProxy will route all HTTP requests through the proxy (http, https or socks5 scheme).
+// The URL is validated by NewClient(). SRV and SSL checks are not proxied and still use the name server.
+// A custom HTTP client does not use the proxy (see WithCustomHTTPClient).
+// Default is no proxy.
+func WithProxy(proxyURL string) ClientOps {
+	return func(c *ClientOptions) {
+		c.proxyURL = proxyURL
+	}
+}
+
 // WithRequestTracing will enable tracing.
 // Tracing is disabled by default.
 func WithRequestTracing() ClientOps {
@@ -91,6 +184,53 @@ func WithRequestTracing() ClientOps {
 	}
 }
 
+// WithResolveConcurrency will overwrite the number of concurrent requests used by ResolveAddresses().
+// Default is 10.
+func WithResolveConcurrency(workers int) ClientOps {
+	return func(c *ClientOptions) {
+		c.resolveConcurrency = workers
+	}
+}
+
+// WithResolver will use the custom resolver for all DNS look ups (SRV, host and IP).
+// Useful for testing or DNS-over-HTTPS deployments.
+// Default is a net.Resolver using the name server.
+func WithResolver(resolver interfaces.DNSResolver) ClientOps {
+	return func(c *ClientOptions) {
+		c.resolver = resolver
+	}
+}
+
+// WithResponseInterceptor will call the interceptor after every outgoing HTTP request (e.g. to log the
+// status, latency and url). The response body is a copy, see RequestLatency() for the latency.
+// Default is no interceptor.
+func WithResponseInterceptor(interceptor ResponseInterceptor) ClientOps {
+	return func(c *ClientOptions) {
+		c.interceptor = interceptor
+	}
+}
+
+// WithRequestTimeout will set the deadline of every outgoing paymail request (capabilities, pki, resolution, etc.),
+// also when a custom HTTP client is used. A timed out request returns an error with the operation and host.
+// Default is the HTTP timeout (20 seconds).
+func WithRequestTimeout(timeout time.Duration) ClientOps {
+	return func(c *ClientOptions) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithRetry will retry GET requests (capabilities, PKI, etc.) with exponential backoff and jitter.
+// Only network errors, 429 and 5xx responses are retried (the Retry-After header is honored), never 4xx.
+// Default is disabled.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOps {
+	return func(c *ClientOptions) {
+		if maxAttempts > 0 {
+			c.retryCount = maxAttempts - 1
+		}
+		c.retryDelay = baseDelay
+	}
+}
+
 // WithRetryCount will overwrite the default retry count for http requests.
 // Default retries is 2.
 func WithRetryCount(retries int) ClientOps {
@@ -115,14 +255,40 @@ func WithSSLDeadline(timeout time.Duration) ClientOps {
 	}
 }
 
+// WithHeaders will set the custom headers on all outgoing requests.
+// The User-Agent is set using WithUserAgent().
+// Default is no custom headers.
+func WithHeaders(headers map[string]string) ClientOps {
+	return func(c *ClientOptions) {
+		c.headers = headers
+	}
+}
+
+// WithStrictBsvAlias will reject capabilities with an incompatible bsvalias major version.
+// Default is disabled.
+func WithStrictBsvAlias(enabled bool) ClientOps {
+	return func(c *ClientOptions) {
+		c.strictBsvAlias = enabled
+	}
+}
+
 // WithUserAgent will overwrite the default useragent.
-// Default is go-paymail + version.
+// Default is go-paymail/<version>.
 func WithUserAgent(userAgent string) ClientOps {
 	return func(c *ClientOptions) {
 		c.userAgent = userAgent
 	}
 }
 
+// WithWellKnownFallback will enable/disable the discovery at https://<domain>/.well-known/bsvalias (port 443)
+// when the domain has no SRV record (NXDOMAIN or no records).
+// Default is enabled.
+func WithWellKnownFallback(enabled bool) ClientOps {
+	return func(c *ClientOptions) {
+		c.wellKnownFallback = enabled
+	}
+}
+
 // WithNetwork will set the client's operational network to one provided.
 // Default is mainnet.
 func WithNetwork(n Network) ClientOps {
@@ -139,7 +305,39 @@ func (c *Client) WithCustomResolver(resolver interfaces.DNSResolver) ClientInter
 }
 
 // WithCustomHTTPClient will overwrite the default client with a custom client.
+//
+// The custom client is used as is: the transport options (WithProxy, WithPinnedCerts, WithDialNetwork)
+// and the redirect policy (WithMaxRedirects) are not applied to it, a warning is logged if they are set.
 func (c *Client) WithCustomHTTPClient(client *resty.Client) ClientInterface {
+	if ignored := c.options.transportOptions(); len(ignored) > 0 {
+		logging.GetDefaultLogger().Warn().Strs("options", ignored).
+			Msg("paymail client options are not applied to the custom http client")
+	}
 	c.httpClient = client
 	return c
 }
+
+// timeout will return the deadline of the outgoing requests (the request timeout or the HTTP timeout)
+func (c *ClientOptions) timeout() time.Duration {
+	if c.requestTimeout > 0 {
+		return c.requestTimeout
+	}
+	return c.httpTimeout
+}
+
+// transportOptions will return the (set) options of the default HTTP client transport and redirect policy
+func (c *ClientOptions) transportOptions() (options []string) {
+	if len(c.proxyURL) > 0 {
+		options = append(options, "WithProxy")
+	}
+	if len(c.pinnedCerts) > 0 {
+		options = append(options, "WithPinnedCerts")
+	}
+	if c.dialNetwork == DialNetworkIPv4 || c.dialNetwork == DialNetworkIPv6 {
+		options = append(options, "WithDialNetwork")
+	}
+	if c.maxRedirects != defaultMaxRedirects {
+		options = append(options, "WithMaxRedirects")
+	}
+	return
+}
@@ -0,0 +1,29 @@
+package paymail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(WithInsecureHTTP(true), WithRequestTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetPKI(context.Background(), srv.URL+"/{alias}@{domain.tld}", "alice", "test.com")
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), srv.Listener.Addr().String()) {
+		t.Fatalf("expected the pki request to time out, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package paymail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+/*
+Default:
+{
+  "address": "1Cat862cjhp8SgLLMvin5gyk5UScasg1P9",
+  "output": "76a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac"
+}
+*/
+
+// CurrentAddressResponse is the result returned from GetCurrentAddress()
+type CurrentAddressResponse struct {
+	StandardResponse
+	CurrentAddressPayload
+}
+
+// CurrentAddressPayload is the payload from the response
+type CurrentAddressPayload struct {
+	Address string `json:"address,omitempty"` // Legacy BSV address derived from the output script
+	Output  string `json:"output"`            // hex-encoded Bitcoin script of the (fresh) deposit address
+}
+
+// GetCurrentAddress will return the current deposit address of the paymail address (no amount is negotiated)
+//
+// The capability URL is discovered from the capabilities of the domain, unlike the P2P payment destination
+// a single output is returned. Returns ErrCapabilityNotSupported if the provider does not support it
+func (c *Client) GetCurrentAddress(ctx context.Context, alias, domain string) (string, error) {
+
+	// Basic requirements for request
+	if len(alias) == 0 {
+		return "", fmt.Errorf("missing alias")
+	} else if len(domain) == 0 {
+		return "", fmt.Errorf("missing domain")
+	}
+
+	currentAddressURL, err := c.discoverCapabilityURL(ctx, domain, BRFCCurrentAddress, "")
+	if err != nil {
+		return "", err
+	} else if !c.isAllowedURL(currentAddressURL) {
+		return "", fmt.Errorf("invalid url: %s", currentAddressURL)
+	}
+
+	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
+	// https://<host-discovery-target>/current-address/{alias}@{domain.tld}
+	var reqURL string
+	if reqURL, err = ResolveURL(currentAddressURL, alias, domain); err != nil {
+		return "", err
+	}
+
+	// Fire the GET request
+	var resp StandardResponse
+	if resp, err = c.getRequest(ctx, "current address", reqURL); err != nil {
+		return "", err
+	}
+
+	// Test the status code (200 or 304 is valid)
+	response := &CurrentAddressResponse{StandardResponse: resp}
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
+		return "", c.prepareServerErrorResponse(&resp)
+	}
+
+	// Decode the body of the response
+	if err = json.Unmarshal(resp.Body, &response); err != nil {
+		return "", err
+	}
+
+	// Check for an output (the address is derived from the output script)
+	if len(response.Output) == 0 {
+		return "", fmt.Errorf("missing an output value")
+	}
+	var lockingScript *script.Script
+	if lockingScript, err = script.NewFromHex(response.Output); err != nil {
+		return "", err
+	}
+	addresses, err := lockingScript.Addresses()
+	if err != nil || len(addresses) == 0 {
+		return "", fmt.Errorf("invalid output script, missing an address")
+	}
+	return addresses[0], nil
+}
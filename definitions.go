@@ -1,6 +1,8 @@
 package paymail
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -8,17 +10,20 @@ import (
 
 // Defaults for paymail functions
 const (
-	defaultDNSPort           = "53"                     // Default port for DNS / NameServer checks
-	defaultDNSTimeout        = 5 * time.Second          // In seconds
-	defaultHTTPTimeout       = 20 * time.Second         // Default timeout for all GET requests in seconds
-	defaultNameServer        = "8.8.8.8"                // Default DNS NameServer
-	defaultNameServerNetwork = "udp"                    // Default for NS dialer
-	defaultRetryCount        = 2                        // Default retry count for HTTP requests
-	defaultSSLDeadline       = 10 * time.Second         // Default deadline in seconds
-	defaultSSLTimeout        = 10 * time.Second         // Default timeout in seconds
-	defaultUserAgent         = "go-paymail: " + version // Default user agent
-	defaultNetwork           = byte(Mainnet)            // Default network
-	version                  = "v0.9.3"                 // Go-Paymail version
+	defaultCapabilitiesTTL    = 5 * time.Minute         // Default time to cache capabilities
+	defaultDNSPort            = "53"                    // Default port for DNS / NameServer checks
+	defaultDNSTimeout         = 5 * time.Second         // In seconds
+	defaultHTTPTimeout        = 20 * time.Second        // Default timeout for all HTTP requests in seconds
+	defaultMaxRedirects       = 3                       // Default maximum redirects followed by a request
+	defaultNameServer         = "8.8.8.8"               // Default DNS NameServer
+	defaultNameServerNetwork  = "udp"                   // Default for NS dialer
+	defaultResolveConcurrency = 10                      // Default number of concurrent address resolutions
+	defaultRetryCount         = 2                       // Default retry count for HTTP requests
+	defaultSSLDeadline        = 10 * time.Second        // Default deadline in seconds
+	defaultSSLTimeout         = 10 * time.Second        // Default timeout in seconds
+	defaultUserAgent          = "go-paymail/" + version // Default user agent
+	defaultNetwork            = byte(Mainnet)           // Default network
+	version                   = "v0.9.3"                // Go-Paymail version
 )
 
 // Public defaults for paymail specs
@@ -38,15 +43,22 @@ const (
 	Max SRV Records:  1
 */
 const (
-	DefaultBsvAliasVersion = "1.0"      // Default version number for bsvalias
-	DefaultPort            = 443        // Default port (from specs)
-	DefaultPriority        = 10         // Default priority (from specs)
-	DefaultProtocol        = "tcp"      // Default protocol (from specs)
-	DefaultServiceName     = "bsvalias" // Default service name (from specs)
-	DefaultWeight          = 10         // Default weight (from specs)
-	PubKeyLength           = 66         // Required length for a valid PubKey (pki)
+	DefaultBsvAliasVersion = "1.0"           // Default version number for bsvalias
+	DefaultPort            = 443             // Default port (from specs)
+	DefaultPriority        = 10              // Default priority (from specs)
+	DefaultProtocol        = "tcp"           // Default protocol (from specs)
+	DefaultServiceName     = "bsvalias"      // Default service name (from specs)
+	DefaultTimestampSkew   = 2 * time.Minute // Default allowed skew for the sender "dt" (from specs)
+	DefaultWeight          = 10              // Default weight (from specs)
+	PubKeyLength           = 66              // Required length for a valid PubKey (pki)
 )
 
+// MaxSatoshis is the max amount of satoshis (the 21M BSV supply)
+const MaxSatoshis uint64 = 21_000_000 * 100_000_000
+
+// DustLimit is the min amount of satoshis paying an issued output without satoshis (any amount)
+const DustLimit uint64 = 1
+
 // StandardResponse is the standard fields returned on all responses
 type StandardResponse struct {
 	Body       []byte          `json:"-"` // Body of the response request
@@ -68,14 +80,45 @@ type ServerError struct {
 	Message string `json:"message"` // Shows the error message returned by the server
 }
 
+// PaymailError is the (structured) error response from a paymail server, returned by the client
+//
+// Use errors.As(err, &paymailErr) to branch on the Code
+type PaymailError struct {
+	Code       string // Machine-readable error code (e.g. "error-paymail-not-found")
+	Message    string // Human-readable error message
+	StatusCode int    // HTTP status code of the response
+}
+
+// Error returns the error message string for PaymailError, satisfying the error interface
+func (e *PaymailError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("bad response from paymail provider: code %d, message: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("bad response from paymail provider: code %d, error: %s, message: %s", e.StatusCode, e.Code, e.Message)
+}
+
 // AddressInformation is an internal struct for paymail addresses and their corresponding information
 type AddressInformation struct {
-	Alias       string `json:"alias"`        // Alias or handle of the paymail
-	Avatar      string `json:"avatar"`       // This is the url of the user (public profile)
-	Domain      string `json:"domain"`       // Domain of the paymail
-	ID          string `json:"id"`           // Global unique identifier
-	LastAddress string `json:"last_address"` // This is used as a temp address for now (should be via xPub)
-	Name        string `json:"name"`         // This is the name of the user (public profile)
-	PrivateKey  string `json:"-"`            // PrivateKey hex encoded
-	PubKey      string `json:"pubkey"`       // PublicKey hex encoded
+	Alias       string   `json:"alias"`             // Alias or handle of the paymail
+	Avatar      string   `json:"avatar"`            // This is the url of the user (public profile)
+	Domain      string   `json:"domain"`            // Domain of the paymail
+	ID          string   `json:"id"`                // Global unique identifier
+	LastAddress string   `json:"last_address"`      // This is used as a temp address for now (should be via xPub)
+	Name        string   `json:"name"`              // This is the name of the user (public profile)
+	PrivateKey  string   `json:"-"`                 // PrivateKey hex encoded
+	PubKey      string   `json:"pubkey"`            // PublicKey hex encoded (primary key, returned by PKI)
+	PubKeys     []string `json:"pubkeys,omitempty"` // Other active PublicKeys hex encoded (key rotation, optional)
+}
+
+// HasPubKey will return true if the pubkey is the primary or one of the other active keys
+func (a *AddressInformation) HasPubKey(pubKey string) bool {
+	if strings.EqualFold(a.PubKey, pubKey) {
+		return true
+	}
+	for _, key := range a.PubKeys {
+		if strings.EqualFold(key, pubKey) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,75 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Dial networks (see WithDialNetwork())
+const (
+	DialNetworkDualStack = "tcp"  // IPv4 and IPv6 (default)
+	DialNetworkIPv4      = "tcp4" // IPv4 only
+	DialNetworkIPv6      = "tcp6" // IPv6 only
+)
+
+// The dialer settings of the default (resty) transport
+const (
+	defaultDialKeepAlive = 30 * time.Second // Interval of the TCP keep-alive probes
+	defaultDialTimeout   = 30 * time.Second // Timeout of a single connection attempt
+)
+
+// ErrNoAddressForNetwork is returned when the host has no address in the family of the dial network
+var ErrNoAddressForNetwork = errors.New("host has no address for the dial network")
+
+// isValidDialNetwork will return true if the network is a known dial network (empty is dual-stack)
+func isValidDialNetwork(network string) bool {
+	switch network {
+	case "", DialNetworkDualStack, DialNetworkIPv4, DialNetworkIPv6:
+		return true
+	}
+	return false
+}
+
+// dialContext will only dial the addresses of the host in the family of the dial network
+//
+// The host is resolved using the client resolver, ErrNoAddressForNetwork is returned if none of its
+// addresses are in the family
+func (c *Client) dialContext(ctx context.Context, _, address string) (net.Conn, error) {
+	network := c.options.dialNetwork
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		var addrs []net.IPAddr
+		if addrs, err = c.resolver.LookupIPAddr(ctx, host); err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultDialKeepAlive}
+	var lastErr error
+	for _, ip := range ips {
+		if isIPv4 := ip.To4() != nil; isIPv4 != (network == DialNetworkIPv4) {
+			continue
+		}
+		var conn net.Conn
+		if conn, lastErr = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); lastErr == nil {
+			return conn, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("%w %s: %s", ErrNoAddressForNetwork, network, host)
+}
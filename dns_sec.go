@@ -2,6 +2,7 @@ package paymail
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -243,6 +244,53 @@ func newDNSMessage(domain, nameServer, dnsPort string, dnsType uint16) (*dns.Msg
 	return in, nil
 }
 
+// resolveSecureSRV will resolve the SRV records using a DNSSEC-validating name server
+//
+// The response must be authenticated (AD flag), otherwise the chain of trust could not be validated
+func resolveSecureSRV(name, nameServer, dnsPort string) ([]*net.SRV, error) {
+
+	// Fire the request
+	msg, err := newDNSMessage(name, nameServer, dnsPort, dns.TypeSRV)
+	if err != nil {
+		return nil, fmt.Errorf("dnssec srv lookup failed for %s: %w", name, err)
+	}
+
+	// A missing record is reported like the (non DNSSEC) resolver does, so the well-known fallback applies
+	if msg.Rcode == dns.RcodeNameError || (msg.Rcode == dns.RcodeSuccess && !hasSRVAnswer(msg)) {
+		return nil, &net.DNSError{Err: "no such host", Name: name, Server: nameServer, IsNotFound: true}
+	}
+
+	// Validating resolvers return SERVFAIL for bogus signatures
+	if msg.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("dnssec validation failed for %s: %s", name, dns.RcodeToString[msg.Rcode])
+	} else if !msg.AuthenticatedData {
+		return nil, fmt.Errorf("dnssec validation failed for %s: response is not authenticated", name)
+	}
+
+	var records []*net.SRV
+	for _, ain := range msg.Answer {
+		if a, ok := ain.(*dns.SRV); ok {
+			records = append(records, &net.SRV{
+				Port:     a.Port,
+				Priority: a.Priority,
+				Target:   a.Target,
+				Weight:   a.Weight,
+			})
+		}
+	}
+	return records, nil
+}
+
+// hasSRVAnswer will return true if the message answers (at least) one SRV record
+func hasSRVAnswer(msg *dns.Msg) bool {
+	for _, ain := range msg.Answer {
+		if _, ok := ain.(*dns.SRV); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // resolveOneNS will resolve one name server
 func resolveOneNS(domain, nameServer, dnsPort string) (string, error) {
 
@@ -0,0 +1,188 @@
+package errors
+
+import "sync"
+
+// ErrorCode is the stable, machine-readable code of an error (the JSON "code" of the error responses)
+type ErrorCode string
+
+// CodeUnknown is the code of any other (internal) error
+const CodeUnknown ErrorCode = UnknownErrorCode
+
+// Error codes of the SPVError definitions (the stable "code" of the error responses)
+const (
+	CodeDomainMissing               ErrorCode = "error-configuration-domain-missing"
+	CodeDomainPatternInvalid        ErrorCode = "error-configuration-domain-pattern-invalid"
+	CodePortMissing                 ErrorCode = "error-configuration-port-missing"
+	CodeServiceNameMissing          ErrorCode = "error-configuration-service-name-missing"
+	CodeCapabilitiesMissing         ErrorCode = "error-configuration-capabilities-missing"
+	CodeBsvAliasMissing             ErrorCode = "error-configuration-bsv-alias-missing"
+	CodeCapabilityNameUnknown       ErrorCode = "error-configuration-capability-name-unknown"
+	CodeErrorStatusCodeInvalid      ErrorCode = "error-configuration-error-status-code-invalid"
+	CodeTrustedProxyInvalid         ErrorCode = "error-configuration-trusted-proxy-invalid"
+	CodeOutputSplitInvalid          ErrorCode = "error-configuration-output-split-invalid"
+	CodeServiceURLInvalid           ErrorCode = "error-configuration-service-url-invalid"
+	CodeMetricsRegistration         ErrorCode = "error-configuration-metrics-registration"
+	CodeApprovalAuthorizerMissing   ErrorCode = "error-configuration-approval-authorizer-missing"
+	CodeServiceProviderNil          ErrorCode = "error-configuration-service-provider-nil"
+	CodePrefixOrDomainMissing       ErrorCode = "error-capabilities-prefix-or-domain-missing"
+	CodeDomainUnknown               ErrorCode = "error-capabilities-domain-unknown"
+	CodeCapabilityNotAllowed        ErrorCode = "error-capabilities-capability-not-allowed"
+	CodeCastingNestedCapabilities   ErrorCode = "error-capabilities-nested-capabilities-failed-to-cast"
+	CodeCannotBindRequest           ErrorCode = "error-bind-body-invalid"
+	CodeProcessingHex               ErrorCode = "error-processing-hex"
+	CodeProcessingBEEF              ErrorCode = "error-processing-beef"
+	CodeCouldNotFindPaymail         ErrorCode = "error-paymail-not-found"
+	CodeUnknownReference            ErrorCode = "error-p2p-reference-unknown"
+	CodeInvalidReference            ErrorCode = "error-p2p-reference-invalid"
+	CodeReferenceExpired            ErrorCode = "error-p2p-reference-expired"
+	CodeTransactionAlreadyRecorded  ErrorCode = "error-p2p-transaction-already-recorded"
+	CodeDestinationSigningFailed    ErrorCode = "error-p2p-destination-signing-failed"
+	CodeBroadcastFailed             ErrorCode = "error-p2p-broadcast-failed"
+	CodeTxTooLarge                  ErrorCode = "error-p2p-transaction-too-large"
+	CodeTransactionZeroAmount       ErrorCode = "error-p2p-transaction-zero-amount"
+	CodeApprovalUnauthorized        ErrorCode = "error-p2p-approval-unauthorized"
+	CodeTransactionMismatch         ErrorCode = "error-p2p-transaction-mismatch"
+	CodeInvalidPaymail              ErrorCode = "error-paymail-invalid"
+	CodeInvalidPubKey               ErrorCode = "error-pubkey-invalid"
+	CodeInvalidSignature            ErrorCode = "error-signature-invalid"
+	CodeUnsupportedSignatureScheme  ErrorCode = "error-signature-scheme-unsupported"
+	CodeNoteTooLong                 ErrorCode = "error-note-too-long"
+	CodeInvalidCallbackURL          ErrorCode = "error-callback-url-invalid"
+	CodeInvalidSatoshis             ErrorCode = "error-satoshis-invalid"
+	CodeAmountExceedsLimit          ErrorCode = "error-amount-exceeds-limit"
+	CodeUnsupportedScriptType       ErrorCode = "error-script-type-unsupported"
+	CodeInvalidScript               ErrorCode = "error-script-invalid"
+	CodeInvalidTimestamp            ErrorCode = "error-timestamp-invalid"
+	CodeInvalidSenderHandle         ErrorCode = "error-sender-handle-invalid"
+	CodePubKeyMismatch              ErrorCode = "error-pubkey-mismatch"
+	CodeInvalidParameter            ErrorCode = "error-parameter-invalid"
+	CodeMissingFieldReference       ErrorCode = "error-missing-field-reference"
+	CodeMissingFieldHex             ErrorCode = "error-missing-field-hex"
+	CodeMissingFieldBEEF            ErrorCode = "error-missing-field-beef"
+	CodeMissingFieldSignature       ErrorCode = "error-missing-field-signature"
+	CodeMissingFieldPubKey          ErrorCode = "error-missing-field-pubkey"
+	CodeMissingFieldSatoshis        ErrorCode = "error-missing-field-satoshis"
+	CodeSenderHandleEmpty           ErrorCode = "error-sender-handle-empty"
+	CodeDtEmpty                     ErrorCode = "error-dt-empty"
+	CodeRateLimited                 ErrorCode = "error-rate-limited"
+	CodeServerBusy                  ErrorCode = "error-server-busy"
+	CodeNoOutputs                   ErrorCode = "error-spv-no-outputs"
+	CodeNoInputs                    ErrorCode = "error-spv-no-inputs"
+	CodeInvalidParentTransactions   ErrorCode = "error-spv-parent-tx-invalid"
+	CodeLockTimeAndSequence         ErrorCode = "error-spv-locktime-sequence-invalid"
+	CodeOutputValueTooHigh          ErrorCode = "error-spv-output-value-too-high"
+	CodeBUMPAncestorNotPresent      ErrorCode = "error-spv-bump-ancestor-not-present"
+	CodeBUMPCouldNotFindMinedParent ErrorCode = "error-spv-bump-mined-parent-not-found"
+	CodeNoMatchingInputTx           ErrorCode = "error-spv-input-tx-not-found"
+	CodeInvalidMerkleProof          ErrorCode = "error-spv-merkle-proof-invalid"
+	CodeNonStandardTransaction      ErrorCode = "error-spv-non-standard-transaction"
+	CodeSPVFailed                   ErrorCode = "error-spv-failed"
+)
+
+// registry is the list of all the SPVError definitions (see Registered())
+var registry = []SPVError{
+	ErrDomainMissing,
+	ErrDomainPatternInvalid,
+	ErrPortMissing,
+	ErrServiceNameMissing,
+	ErrCapabilitiesMissing,
+	ErrBsvAliasMissing,
+	ErrCapabilityNameUnknown,
+	ErrErrorStatusCodeInvalid,
+	ErrTrustedProxyInvalid,
+	ErrOutputSplitInvalid,
+	ErrServiceURLInvalid,
+	ErrMetricsRegistration,
+	ErrApprovalAuthorizerMissing,
+	ErrServiceProviderNil,
+	ErrPrefixOrDomainMissing,
+	ErrDomainUnknown,
+	ErrCapabilityNotAllowed,
+	ErrCastingNestedCapabilities,
+	ErrCannotBindRequest,
+	ErrProcessingHex,
+	ErrProcessingBEEF,
+	ErrCouldNotFindPaymail,
+	ErrUnknownReference,
+	ErrInvalidReference,
+	ErrReferenceExpired,
+	ErrTransactionAlreadyRecorded,
+	ErrDestinationSigningFailed,
+	ErrBroadcastFailed,
+	ErrTxTooLarge,
+	ErrTransactionZeroAmount,
+	ErrApprovalUnauthorized,
+	ErrTransactionMismatch,
+	ErrInvalidPaymail,
+	ErrInvalidPubKey,
+	ErrInvalidSignature,
+	ErrUnsupportedSignatureScheme,
+	ErrNoteTooLong,
+	ErrInvalidCallbackURL,
+	ErrInvalidSatoshis,
+	ErrAmountExceedsLimit,
+	ErrUnsupportedScriptType,
+	ErrInvalidScript,
+	ErrInvalidTimestamp,
+	ErrInvalidSenderHandle,
+	ErrPubKeyMismatch,
+	ErrInvalidParameter,
+	ErrMissingFieldReference,
+	ErrMissingFieldHex,
+	ErrMissingFieldBEEF,
+	ErrMissingFieldSignature,
+	ErrMissingFieldPubKey,
+	ErrMissingFieldSatoshis,
+	ErrSenderHandleEmpty,
+	ErrDtEmpty,
+	ErrRateLimited,
+	ErrServerBusy,
+	ErrNoOutputs,
+	ErrNoInputs,
+	ErrInvalidParentTransactions,
+	ErrLockTimeAndSequence,
+	ErrOutputValueTooHigh,
+	ErrBUMPAncestorNotPresent,
+	ErrBUMPCouldNotFindMinedParent,
+	ErrNoMatchingTransactionsForInput,
+	ErrInvalidMerkleProof,
+	ErrNonStandardTransaction,
+	ErrSPVFailed,
+}
+
+var (
+	codesOnce sync.Once
+	codes     map[ErrorCode]SPVError
+)
+
+// String will return the (stable) string code
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+// StatusCode will return the default HTTP status of the code (500 for an unknown code)
+func (c ErrorCode) StatusCode() int {
+	if e, ok := LookupCode(c); ok {
+		return e.StatusCode
+	}
+	return 500
+}
+
+// Registered will return all the SPVError definitions (e.g. to enumerate the error codes)
+func Registered() []SPVError {
+	return append([]SPVError{}, registry...)
+}
+
+// LookupCode will return the SPVError definition of the code (the codes are unique, see codes_test.go)
+func LookupCode(code ErrorCode) (SPVError, bool) {
+	codesOnce.Do(func() {
+		codes = make(map[ErrorCode]SPVError, len(registry))
+		for _, e := range registry {
+			if _, ok := codes[e.Code]; !ok {
+				codes[e.Code] = e
+			}
+		}
+	})
+	e, ok := codes[code]
+	return e, ok
+}
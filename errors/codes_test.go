@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parseFile will parse the Go file of the package
+func parseFile(t *testing.T, name string) *ast.File {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), name, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// definedErrors will return the names of the SPVError definitions (in definitions order)
+func definedErrors(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	ast.Inspect(parseFile(t, "definitions.go"), func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 {
+			return true
+		}
+		if lit, isLit := spec.Values[0].(*ast.CompositeLit); isLit {
+			if ident, isIdent := lit.Type.(*ast.Ident); isIdent && ident.Name == "SPVError" {
+				names = append(names, spec.Names[0].Name)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// registeredErrors will return the names of the SPVError definitions in the registry
+func registeredErrors(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	ast.Inspect(parseFile(t, "codes.go"), func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || spec.Names[0].Name != "registry" {
+			return true
+		}
+		for _, element := range spec.Values[0].(*ast.CompositeLit).Elts {
+			names = append(names, element.(*ast.Ident).Name)
+		}
+		return false
+	})
+	return names
+}
+
+func TestRegistered_AllDefinitions(t *testing.T) {
+	defined := definedErrors(t)
+	if len(defined) == 0 {
+		t.Fatal("expected SPVError definitions")
+	}
+
+	// Every definition is registered once (in definitions order)
+	if registered := registeredErrors(t); !slices.Equal(defined, registered) {
+		for _, name := range defined {
+			if !slices.Contains(registered, name) {
+				t.Errorf("%s is not registered", name)
+			}
+		}
+		for _, name := range registered {
+			if !slices.Contains(defined, name) {
+				t.Errorf("%s is registered but not defined", name)
+			}
+		}
+		t.Fatalf("expected the registry to list the definitions in order:\n%v\ngot:\n%v", defined, registered)
+	}
+	if len(Registered()) != len(defined) {
+		t.Errorf("expected %d registered errors, got %d", len(defined), len(Registered()))
+	}
+}
+
+func TestRegistered_UniqueCodes(t *testing.T) {
+	seen := make(map[ErrorCode]string)
+	for _, e := range Registered() {
+		if !strings.HasPrefix(e.Code.String(), "error-") {
+			t.Errorf("%q: expected the error- prefix", e.Code)
+		} else if e.Code == CodeUnknown {
+			t.Errorf("%q: the unknown code is reserved for the other errors", e.Message)
+		}
+		if message, duplicate := seen[e.Code]; duplicate {
+			t.Errorf("%q is the code of %q and %q", e.Code, message, e.Message)
+		}
+		seen[e.Code] = e.Message
+
+		if found, ok := LookupCode(e.Code); !ok || found.Message != e.Message {
+			t.Errorf("%q: expected LookupCode to return %q", e.Code, e.Message)
+		} else if e.Code.StatusCode() != e.StatusCode {
+			t.Errorf("%q: expected the status %d, got %d", e.Code, e.StatusCode, e.Code.StatusCode())
+		}
+	}
+
+	if _, ok := LookupCode("error-not-defined"); ok || ErrorCode("error-not-defined").StatusCode() != 500 {
+		t.Error("expected an unknown code to be a 500")
+	}
+}
@@ -9,132 +9,231 @@ package errors
 // CONFIG ERRORS
 var (
 	// ErrDomainMissing is the error for missing domain
-	ErrDomainMissing = SPVError{Message: "domain is missing", StatusCode: 500, Code: "error-configuration-domain-missing"}
+	ErrDomainMissing = SPVError{Message: "domain is missing", StatusCode: 500, Code: CodeDomainMissing}
+
+	// ErrDomainPatternInvalid is when a domain wildcard is not a single leading label (*.example.com)
+	ErrDomainPatternInvalid = SPVError{Message: "domain pattern is invalid", StatusCode: 500, Code: CodeDomainPatternInvalid}
 
 	// ErrPortMissing is when the port is not found
-	ErrPortMissing = SPVError{Message: "missing a port", StatusCode: 500, Code: "error-configuration-port-missing"}
+	ErrPortMissing = SPVError{Message: "missing a port", StatusCode: 500, Code: CodePortMissing}
 
 	// ErrServiceNameMissing is when the service name is not found
-	ErrServiceNameMissing = SPVError{Message: "missing service name", StatusCode: 500, Code: "error-configuration-service-name-missing"}
+	ErrServiceNameMissing = SPVError{Message: "missing service name", StatusCode: 500, Code: CodeServiceNameMissing}
 
 	// ErrCapabilitiesMissing is when the capabilities struct is nil or not set
-	ErrCapabilitiesMissing = SPVError{Message: "missing capabilities struct", StatusCode: 500, Code: "error-configuration-capabilities-missing"}
+	ErrCapabilitiesMissing = SPVError{Message: "missing capabilities struct", StatusCode: 500, Code: CodeCapabilitiesMissing}
 
 	// ErrBsvAliasMissing is when the bsv alias version is missing
-	ErrBsvAliasMissing = SPVError{Message: "missing bsv alias version", StatusCode: 500, Code: "error-configuration-bsv-alias-missing"}
+	ErrBsvAliasMissing = SPVError{Message: "missing bsv alias version", StatusCode: 500, Code: CodeBsvAliasMissing}
+
+	// ErrCapabilityNameUnknown is when a capability name of the CapabilitiesBuilder is not known
+	ErrCapabilityNameUnknown = SPVError{Message: "unknown capability name", StatusCode: 500, Code: CodeCapabilityNameUnknown}
+
+	// ErrErrorStatusCodeInvalid is when an error status override is not a valid HTTP status code
+	ErrErrorStatusCodeInvalid = SPVError{Message: "error status code is invalid", StatusCode: 500, Code: CodeErrorStatusCodeInvalid}
+
+	// ErrTrustedProxyInvalid is when a trusted proxy is not a valid CIDR or IP address
+	ErrTrustedProxyInvalid = SPVError{Message: "trusted proxy is invalid", StatusCode: 500, Code: CodeTrustedProxyInvalid}
+
+	// ErrOutputSplitInvalid is when the output split strategy of the payment destination is not valid
+	ErrOutputSplitInvalid = SPVError{Message: "output split is invalid", StatusCode: 500, Code: CodeOutputSplitInvalid}
+
+	// ErrServiceURLInvalid is when the external service url is not an absolute http(s) url
+	ErrServiceURLInvalid = SPVError{Message: "service url is invalid", StatusCode: 500, Code: CodeServiceURLInvalid}
+
+	// ErrMetricsRegistration is when the metrics collectors cannot be registered (see WithMetrics)
+	ErrMetricsRegistration = SPVError{Message: "metrics collectors cannot be registered", StatusCode: 500, Code: CodeMetricsRegistration}
+
+	// ErrApprovalAuthorizerMissing is when the receiver approvals are enabled without an ApprovalAuthorizer
+	ErrApprovalAuthorizerMissing = SPVError{Message: "approval authorizer is missing", StatusCode: 500, Code: CodeApprovalAuthorizerMissing}
 
 	// ErrServiceProviderNil is the error for having a nil service provider
-	ErrServiceProviderNil = SPVError{Message: "service provider is nil", StatusCode: 500, Code: "error-configuration-service-provider-nil"}
+	ErrServiceProviderNil = SPVError{Message: "service provider is nil", StatusCode: 500, Code: CodeServiceProviderNil}
 )
 
 // CAPABILITY ERRORS
 var (
 	//ErrPrefixOrDomainMissing is when the prefix or domain is missing
-	ErrPrefixOrDomainMissing = SPVError{Message: "prefix or domain is missing", StatusCode: 400, Code: "error-capabilities-prefix-or-domain-missing"}
+	ErrPrefixOrDomainMissing = SPVError{Message: "prefix or domain is missing", StatusCode: 400, Code: CodePrefixOrDomainMissing}
 
 	//ErrDomainUnknown is when the domain is not in the list of allowed domains
-	ErrDomainUnknown = SPVError{Message: "paymail domain is unknown", StatusCode: 400, Code: "error-capabilities-domain-unknown"}
+	ErrDomainUnknown = SPVError{Message: "paymail domain is unknown", StatusCode: 400, Code: CodeDomainUnknown}
+
+	// ErrCapabilityNotAllowed is when the capability is not allowed for the paymail domain (see DomainConfig)
+	ErrCapabilityNotAllowed = SPVError{Message: "capability is not allowed for the paymail domain", StatusCode: 404, Code: CodeCapabilityNotAllowed}
 
 	//ErrCastingNestedCapabilities is when the nested capabilities cannot be cast
-	ErrCastingNestedCapabilities = SPVError{Message: "failed to cast nested capabilities", StatusCode: 500, Code: "error-capabilities-nested-capabilities-failed-to-cast"}
+	ErrCastingNestedCapabilities = SPVError{Message: "failed to cast nested capabilities", StatusCode: 500, Code: CodeCastingNestedCapabilities}
 )
 
 // PARSING ERRORS
 var (
 	// ErrCannotBindRequest is when request body cannot be bind into struct
-	ErrCannotBindRequest = SPVError{Message: "cannot bind request body", StatusCode: 400, Code: "error-bind-body-invalid"}
+	ErrCannotBindRequest = SPVError{Message: "cannot bind request body", StatusCode: 400, Code: CodeCannotBindRequest}
 
 	// ErrProcessingHex is when error occurred during processing hex
-	ErrProcessingHex = SPVError{Message: "cannot process hex", StatusCode: 400, Code: "error-processing-hex"}
+	ErrProcessingHex = SPVError{Message: "cannot process hex", StatusCode: 400, Code: CodeProcessingHex}
 
 	// ErrProcessingBEEF is when error occurred during processing beef
-	ErrProcessingBEEF = SPVError{Message: "cannot process beef", StatusCode: 400, Code: "error-processing-beef"}
+	ErrProcessingBEEF = SPVError{Message: "cannot process beef", StatusCode: 400, Code: CodeProcessingBEEF}
 )
 
 // PAYMAIL ERRORS
 var (
 	// ErrCouldNotFindPaymail is when could not find paymail
-	ErrCouldNotFindPaymail = SPVError{Message: "invalid paymail", StatusCode: 400, Code: "error-paymail-not-found"}
+	ErrCouldNotFindPaymail = SPVError{Message: "invalid paymail", StatusCode: 400, Code: CodeCouldNotFindPaymail}
+)
+
+// P2P TRANSACTION ERRORS
+var (
+	// ErrUnknownReference is when the reference was not issued by a previous P2P Payment Destination request
+	ErrUnknownReference = SPVError{Message: "unknown reference", StatusCode: 400, Code: CodeUnknownReference}
+
+	// ErrInvalidReference is when the reference is not a valid reference (length or charset)
+	ErrInvalidReference = SPVError{Message: "invalid reference", StatusCode: 400, Code: CodeInvalidReference}
+
+	// ErrReferenceExpired is when the transaction is submitted after the expiry of the reference
+	ErrReferenceExpired = SPVError{Message: "reference has expired", StatusCode: 400, Code: CodeReferenceExpired}
+
+	// ErrTransactionAlreadyRecorded is returned by RecordTransaction (with the original payload) for a retried transaction
+	ErrTransactionAlreadyRecorded = SPVError{Message: "transaction was already recorded", StatusCode: 409, Code: CodeTransactionAlreadyRecorded}
+
+	// ErrDestinationSigningFailed is when the issued P2P payment destination could not be signed by the receiver
+	ErrDestinationSigningFailed = SPVError{Message: "payment destination signing failed", StatusCode: 500, Code: CodeDestinationSigningFailed}
+
+	// ErrBroadcastFailed is when the P2P transaction could not be broadcast
+	ErrBroadcastFailed = SPVError{Message: "transaction broadcast failed", StatusCode: 502, Code: CodeBroadcastFailed}
+
+	// ErrTxTooLarge is when the transaction (hex or BEEF) is over the max size
+	ErrTxTooLarge = SPVError{Message: "transaction is too large", StatusCode: 413, Code: CodeTxTooLarge}
+
+	// ErrTransactionZeroAmount is when the transaction does not pay any satoshis to the receiver
+	ErrTransactionZeroAmount = SPVError{Message: "transaction does not pay any satoshis to the receiver", StatusCode: 400, Code: CodeTransactionZeroAmount}
+
+	// ErrApprovalUnauthorized is when the approve request is not authorized by the ApprovalAuthorizer
+	ErrApprovalUnauthorized = SPVError{Message: "not authorized to approve the transaction", StatusCode: 401, Code: CodeApprovalUnauthorized}
+
+	// ErrTransactionMismatch is when the transaction outputs do not match the outputs issued for the reference
+	ErrTransactionMismatch = SPVError{Message: "transaction does not match the issued payment destination", StatusCode: 400, Code: CodeTransactionMismatch}
 )
 
 // INVALID FIELD ERRORS
 var (
 	// ErrInvalidPaymail is when the paymail is invalid
-	ErrInvalidPaymail = SPVError{Message: "invalid paymail", StatusCode: 400, Code: "error-paymail-invalid"}
+	ErrInvalidPaymail = SPVError{Message: "invalid paymail", StatusCode: 400, Code: CodeInvalidPaymail}
 
 	// ErrInvalidPubKey is when the pubkey is invalid
-	ErrInvalidPubKey = SPVError{Message: "invalid pubkey", StatusCode: 400, Code: "error-pubkey-invalid"}
+	ErrInvalidPubKey = SPVError{Message: "invalid pubkey", StatusCode: 400, Code: CodeInvalidPubKey}
 
 	// ErrInvalidSignature is when the signature is invalid
-	ErrInvalidSignature = SPVError{Message: "invalid signature", StatusCode: 400, Code: "error-signature-invalid"}
+	ErrInvalidSignature = SPVError{Message: "invalid signature", StatusCode: 400, Code: CodeInvalidSignature}
+
+	// ErrUnsupportedSignatureScheme is when the signature scheme is not supported
+	ErrUnsupportedSignatureScheme = SPVError{Message: "unsupported signature scheme", StatusCode: 400, Code: CodeUnsupportedSignatureScheme}
+
+	// ErrNoteTooLong is when the note of the P2P metadata exceeds the max note length (WithMaxNoteLength)
+	ErrNoteTooLong = SPVError{Message: "note is too long", StatusCode: 400, Code: CodeNoteTooLong}
+
+	// ErrInvalidCallbackURL is when the callback url is not a valid https url
+	ErrInvalidCallbackURL = SPVError{Message: "invalid callback url, must be https", StatusCode: 400, Code: CodeInvalidCallbackURL}
+
+	// ErrInvalidSatoshis is when the amount is zero or exceeds the max supply
+	ErrInvalidSatoshis = SPVError{Message: "invalid satoshis, must be above zero and within the max supply", StatusCode: 400, Code: CodeInvalidSatoshis}
+
+	// ErrAmountExceedsLimit is when the amount exceeds the max satoshis of the server (WithMaxSatoshis)
+	ErrAmountExceedsLimit = SPVError{Message: "amount exceeds the limit", StatusCode: 400, Code: CodeAmountExceedsLimit}
+
+	// ErrUnsupportedScriptType is when the requested script type of the payment destination is not supported
+	ErrUnsupportedScriptType = SPVError{Message: "unsupported script type", StatusCode: 400, Code: CodeUnsupportedScriptType}
 
 	// ErrInvalidScript is when the script is invalid
-	ErrInvalidScript = SPVError{Message: "invalid script", StatusCode: 400, Code: "error-script-invalid"}
+	ErrInvalidScript = SPVError{Message: "invalid script", StatusCode: 400, Code: CodeInvalidScript}
 
 	// ErrInvalidTimestamp is when the timestamp is invalid
-	ErrInvalidTimestamp = SPVError{Message: "invalid timestamp", StatusCode: 400, Code: "error-timestamp-invalid"}
+	ErrInvalidTimestamp = SPVError{Message: "invalid timestamp", StatusCode: 400, Code: CodeInvalidTimestamp}
 
 	// ErrInvalidSenderHandle is when the sender handle is invalid
-	ErrInvalidSenderHandle = SPVError{Message: "invalid sender handle", StatusCode: 400, Code: "error-sender-handle-invalid"}
+	ErrInvalidSenderHandle = SPVError{Message: "invalid sender handle", StatusCode: 400, Code: CodeInvalidSenderHandle}
+
+	// ErrPubKeyMismatch is when the pubkey does not belong to the sender handle
+	ErrPubKeyMismatch = SPVError{Message: "pubkey does not match the sender handle", StatusCode: 400, Code: CodePubKeyMismatch}
+
+	// ErrInvalidParameter is when both the hex and beef fields are provided
+	ErrInvalidParameter = SPVError{Message: "invalid parameter: only one of hex or beef can be provided", StatusCode: 400, Code: CodeInvalidParameter}
 )
 
 // MISSING FIELD ERRORS
 
 var (
 	// ErrMissingFieldReference is when the reference field is required but missing
-	ErrMissingFieldReference = SPVError{Message: "missing required field: reference", StatusCode: 400, Code: "error-missing-field-reference"}
+	ErrMissingFieldReference = SPVError{Message: "missing required field: reference", StatusCode: 400, Code: CodeMissingFieldReference}
 
 	// ErrMissingFieldHex is when the hex field is required but missing
-	ErrMissingFieldHex = SPVError{Message: "missing required field: hex", StatusCode: 400, Code: "error-missing-field-hex"}
+	ErrMissingFieldHex = SPVError{Message: "missing required field: hex", StatusCode: 400, Code: CodeMissingFieldHex}
 
 	// ErrMissingFieldBEEF is when the beef field is required but missing
-	ErrMissingFieldBEEF = SPVError{Message: "missing required field: beef", StatusCode: 400, Code: "error-missing-field-beef"}
+	ErrMissingFieldBEEF = SPVError{Message: "missing required field: beef", StatusCode: 400, Code: CodeMissingFieldBEEF}
 
 	// ErrMissingFieldSignature is when the signature field is required but missing
-	ErrMissingFieldSignature = SPVError{Message: "missing required field: signature", StatusCode: 400, Code: "error-missing-field-signature"}
+	ErrMissingFieldSignature = SPVError{Message: "missing required field: signature", StatusCode: 400, Code: CodeMissingFieldSignature}
 
 	// ErrMissingFieldPubKey is when the pubkey field is required but missing
-	ErrMissingFieldPubKey = SPVError{Message: "missing required field: pubkey", StatusCode: 400, Code: "error-missing-field-pubkey"}
+	ErrMissingFieldPubKey = SPVError{Message: "missing required field: pubkey", StatusCode: 400, Code: CodeMissingFieldPubKey}
 
 	// ErrMissingFieldSatoshis is when the satoshis field is required but missing
-	ErrMissingFieldSatoshis = SPVError{Message: "missing required field: satoshis", StatusCode: 400, Code: "error-missing-field-satoshis"}
+	ErrMissingFieldSatoshis = SPVError{Message: "missing required field: satoshis", StatusCode: 400, Code: CodeMissingFieldSatoshis}
 )
 
 // EMPTY FIELDS ERRORS
 var (
 	// ErrSenderHandleEmpty is when the server handle is empty
-	ErrSenderHandleEmpty = SPVError{Message: "empty sender handle", StatusCode: 400, Code: "error-sender-handle-empty"}
+	ErrSenderHandleEmpty = SPVError{Message: "empty sender handle", StatusCode: 400, Code: CodeSenderHandleEmpty}
 
 	// ErrDtEmpty is when the dt field is empty
-	ErrDtEmpty = SPVError{Message: "empty dt", StatusCode: 400, Code: "error-dt-empty"}
+	ErrDtEmpty = SPVError{Message: "empty dt", StatusCode: 400, Code: CodeDtEmpty}
+)
+
+// REQUEST ERRORS
+var (
+	// ErrRateLimited is when the requests exceed the configured rate limit
+	ErrRateLimited = SPVError{Message: "too many requests", StatusCode: 429, Code: CodeRateLimited}
+
+	// ErrServerBusy is when the request could not be processed in time because of the concurrency limit
+	ErrServerBusy = SPVError{Message: "server is busy, try again later", StatusCode: 503, Code: CodeServerBusy}
 )
 
 // SPV ERRORS
 var (
 	// ErrNoOutputs is when there are no outputs
-	ErrNoOutputs = SPVError{Message: "invalid output, no outputs", StatusCode: 417, Code: "error-spv-no-outputs"}
+	ErrNoOutputs = SPVError{Message: "invalid output, no outputs", StatusCode: 417, Code: CodeNoOutputs}
 
 	// ErrNoInputs is when there are no inputs
-	ErrNoInputs = SPVError{Message: "invalid input, no inputs", StatusCode: 417, Code: "error-spv-no-inputs"}
+	ErrNoInputs = SPVError{Message: "invalid input, no inputs", StatusCode: 417, Code: CodeNoInputs}
 
 	// ErrInvalidParentTransactions is when the parent transactions are invalid
-	ErrInvalidParentTransactions = SPVError{Message: "invalid parent transactions, no matching transactions for input", StatusCode: 417, Code: "error-spv-parent-tx-invalid"}
+	ErrInvalidParentTransactions = SPVError{Message: "invalid parent transactions, no matching transactions for input", StatusCode: 417, Code: CodeInvalidParentTransactions}
 
 	// ErrLockTimeAndSequence is when the locktime and sequence are invalid
-	ErrLockTimeAndSequence = SPVError{Message: "nLocktime is set and nSequence is not max, therefore this could be a non-final tx which is not currently supported", StatusCode: 417, Code: "error-spv-locktime-sequence-invalid"}
+	ErrLockTimeAndSequence = SPVError{Message: "nLocktime is set and nSequence is not max, therefore this could be a non-final tx which is not currently supported", StatusCode: 417, Code: CodeLockTimeAndSequence}
 
 	// ErrOutputValueTooHigh is when the satoshis output is too high on a transaction
-	ErrOutputValueTooHigh = SPVError{Message: "invalid input and output sum, outputs can not be larger than inputs", StatusCode: 417, Code: "error-spv-output-value-too-high"}
+	ErrOutputValueTooHigh = SPVError{Message: "invalid input and output sum, outputs can not be larger than inputs", StatusCode: 417, Code: CodeOutputValueTooHigh}
 
 	// ErrBUMPAncestorNotPresent is when the input mined ancestor is not present in BUMPs
-	ErrBUMPAncestorNotPresent = SPVError{Message: "invalid BUMP - input mined ancestor is not present in BUMPs", StatusCode: 417, Code: "error-spv-bump-ancestor-not-present"}
+	ErrBUMPAncestorNotPresent = SPVError{Message: "invalid BUMP - input mined ancestor is not present in BUMPs", StatusCode: 417, Code: CodeBUMPAncestorNotPresent}
 
 	// ErrBUMPCouldNotFindMinedParent is when the mined parent for input could not be found
-	ErrBUMPCouldNotFindMinedParent = SPVError{Message: "invalid BUMP - cannot find mined parent for input", StatusCode: 417, Code: "error-spv-bump-mined-parent-not-found"}
+	ErrBUMPCouldNotFindMinedParent = SPVError{Message: "invalid BUMP - cannot find mined parent for input", StatusCode: 417, Code: CodeBUMPCouldNotFindMinedParent}
 
 	// ErrNoMatchingTransactionsForInput is when no matching transaction for input can be found
-	ErrNoMatchingTransactionsForInput = SPVError{Message: "invalid parent transactions, no matching transactions for input", StatusCode: 417, Code: "error-spv-bump-ancestor-not-present"}
+	ErrNoMatchingTransactionsForInput = SPVError{Message: "invalid parent transactions, no matching transactions for input", StatusCode: 417, Code: CodeNoMatchingInputTx}
+
+	// ErrInvalidMerkleProof is when a merkle proof (BUMP) of the BEEF is not valid for its block height
+	ErrInvalidMerkleProof = SPVError{Message: "invalid merkle proof, the merkle root is not valid for the block height", StatusCode: 417, Code: CodeInvalidMerkleProof}
+
+	// ErrNonStandardTransaction is when the transaction is malformed or not standard (see spv.VerifyTransaction)
+	ErrNonStandardTransaction = SPVError{Message: "transaction is not standard", StatusCode: 417, Code: CodeNonStandardTransaction}
 
 	// ErrSPVFailed is when the SPV returns an error
-	ErrSPVFailed = SPVError{Message: "simplified payment verification has failed", StatusCode: 417, Code: "error-spv-failed"}
+	ErrSPVFailed = SPVError{Message: "simplified payment verification has failed", StatusCode: 417, Code: CodeSPVFailed}
 )
@@ -9,15 +9,17 @@ type ExtendedError interface {
 
 // SPVError is extended error which holds information about http status and code that should be returned
 type SPVError struct {
-	Code       string
+	Code       ErrorCode
 	Message    string
 	StatusCode int
 }
 
 // ResponseError is an error which will be returned in HTTP response
+//
+// The Code is stable and machine-readable (see the SPVError definitions), the Message is human-readable
 type ResponseError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
 }
 
 const UnknownErrorCode = "error-unknown"
@@ -27,9 +29,15 @@ func (e SPVError) Error() string {
 	return e.Message
 }
 
+// Is will return true if the target is an SPVError with the same code (ignoring the details, see WithDetails)
+func (e SPVError) Is(target error) bool {
+	t, ok := target.(SPVError)
+	return ok && len(e.Code) > 0 && t.Code == e.Code
+}
+
 // GetCode returns the error code string for SPVError
 func (e SPVError) GetCode() string {
-	return e.Code
+	return e.Code.String()
 }
 
 // GetMessage returns the error message string for SPVError
@@ -37,6 +45,12 @@ func (e SPVError) GetMessage() string {
 	return e.Message
 }
 
+// WithDetails returns a copy of the SPVError with the details appended to the message
+func (e SPVError) WithDetails(details string) SPVError {
+	e.Message = e.Message + ": " + details
+	return e
+}
+
 // GetStatusCode returns the error status code for SPVError
 func (e SPVError) GetStatusCode() int {
 	return e.StatusCode
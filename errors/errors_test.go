@@ -0,0 +1,23 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestSPVError_Is(t *testing.T) {
+	detailed := ErrUnknownReference.WithDetails("reference-1")
+	if !stderrors.Is(detailed, ErrUnknownReference) {
+		t.Error("expected the error with details to match the definition")
+	}
+	if !stderrors.Is(fmt.Errorf("wrapped: %w", detailed), ErrUnknownReference) {
+		t.Error("expected the wrapped error to match the definition")
+	}
+	if stderrors.Is(detailed, ErrReferenceExpired) {
+		t.Error("expected a different code not to match")
+	}
+	if stderrors.Is(SPVError{Message: "no code"}, SPVError{Message: "other"}) {
+		t.Error("expected errors without a code not to match")
+	}
+}
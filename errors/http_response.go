@@ -9,21 +9,28 @@ import (
 )
 
 // ErrorResponse is a standard way to return errors to the client
+//
+// The error is returned as JSON (default), or as plain text ("code: message") if the Accept header prefers text/plain.
+// The HTTP status of the error can be overridden for the request using SetStatusCodes()
 func ErrorResponse(c *gin.Context, err error, log *zerolog.Logger) {
-	response, statusCode := mapAndLog(err, log)
+	response, statusCode := mapAndLog(err, log, getStatusCodes(c))
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain {
+		c.String(statusCode, "%s: %s", response.Code, response.Message)
+		return
+	}
 	c.JSON(statusCode, response)
 }
 
-func mapAndLog(err error, log *zerolog.Logger) (ResponseError, int) {
+func mapAndLog(err error, log *zerolog.Logger, statusCodes *StatusCodes) (ResponseError, int) {
 	var res ResponseError
-	res.Code = UnknownErrorCode
+	res.Code = CodeUnknown
 	statusCode := 500
 
 	logLevel := zerolog.WarnLevel
 	exposedInternalError := false
 	var extendedErr ExtendedError
 	if errors.As(err, &extendedErr) {
-		res.Code = extendedErr.GetCode()
+		res.Code = ErrorCode(extendedErr.GetCode())
 		res.Message = extendedErr.GetMessage()
 		statusCode = extendedErr.GetStatusCode()
 		if statusCode >= http.StatusInternalServerError {
@@ -34,6 +41,7 @@ func mapAndLog(err error, log *zerolog.Logger) (ResponseError, int) {
 		// if you find out that some endpoint produces this warning, feel free to fix it
 		exposedInternalError = true
 	}
+	statusCode = statusCodes.statusCode(res.Code.String(), statusCode)
 
 	if log != nil {
 		logInstance := log.WithLevel(logLevel).Str("module", "errors")
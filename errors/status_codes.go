@@ -0,0 +1,44 @@
+package errors
+
+import "github.com/gin-gonic/gin"
+
+// statusCodesContextKey is the gin context key of the StatusCodes used by ErrorResponse
+const statusCodesContextKey = "paymail-error-status-codes"
+
+// StatusCodes overrides the HTTP status returned by ErrorResponse
+//
+// The status of an error code in Codes takes precedence, then Default (0 keeps the status of the error)
+type StatusCodes struct {
+	Codes   map[string]int // HTTP status per error code (e.g. "error-paymail-not-found": 200)
+	Default int            // HTTP status for all other errors (0 keeps the status of the error)
+}
+
+// SetStatusCodes will set the status overrides used by ErrorResponse for the request
+func SetStatusCodes(c *gin.Context, statusCodes *StatusCodes) {
+	c.Set(statusCodesContextKey, statusCodes)
+}
+
+// getStatusCodes will return the status overrides of the request (if set)
+func getStatusCodes(c *gin.Context) *StatusCodes {
+	if c == nil {
+		return nil
+	}
+	value, ok := c.Get(statusCodesContextKey)
+	if !ok {
+		return nil
+	}
+	statusCodes, _ := value.(*StatusCodes)
+	return statusCodes
+}
+
+// statusCode will return the HTTP status for the error code
+func (s *StatusCodes) statusCode(code string, statusCode int) int {
+	if s == nil {
+		return statusCode
+	} else if override, ok := s.Codes[code]; ok {
+		return override
+	} else if s.Default > 0 {
+		return s.Default
+	}
+	return statusCode
+}
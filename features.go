@@ -0,0 +1,67 @@
+package paymail
+
+import (
+	"context"
+	"fmt"
+)
+
+// FeatureSet is the list of (known) capabilities supported by a paymail provider
+type FeatureSet struct {
+	BasicDestination bool `json:"basic_destination"` // Basic address resolution (paymentDestination)
+	BEEF             bool `json:"beef"`              // P2P transactions in BEEF format
+	CurrentAddress   bool `json:"current_address"`   // Current deposit address (no amount)
+	P2PDestination   bool `json:"p2p_destination"`   // P2P payment destination
+	P2PTransaction   bool `json:"p2p_transaction"`   // P2P transactions (receive)
+	PayToProtocol    bool `json:"pay_to_protocol"`   // PayTo protocol prefix
+	PIKE             bool `json:"pike"`              // PIKE (invite and outputs)
+	PKI              bool `json:"pki"`               // Public key infrastructure
+	PublicProfile    bool `json:"public_profile"`    // Public profile (name and avatar)
+	ReceiverApproval bool `json:"receiver_approval"` // Receiver approvals
+	SenderValidation bool `json:"sender_validation"` // Sender validation is required by the provider
+	VerifyPubKey     bool `json:"verify_pub_key"`    // Verify public key owner
+}
+
+// Features will return the known capabilities as a FeatureSet
+//
+// A capability is supported if its endpoint (URL) is advertised, sender validation is a flag
+func (c *CapabilitiesPayload) Features() *FeatureSet {
+	supports := func(brfcID, alternateID string) bool {
+		return len(c.GetString(brfcID, alternateID)) > 0
+	}
+	return &FeatureSet{
+		BasicDestination: supports(BRFCPaymentDestination, BRFCBasicAddressResolution),
+		BEEF:             supports(BRFCBeefTransaction, ""),
+		CurrentAddress:   supports(BRFCCurrentAddress, ""),
+		P2PDestination:   supports(BRFCP2PPaymentDestination, ""),
+		P2PTransaction:   supports(BRFCP2PTransactions, ""),
+		PayToProtocol:    supports(BRFCPayToProtocolPrefix, ""),
+		PIKE:             c.Pike != nil || c.Has(BRFCPike, ""),
+		PKI:              supports(BRFCPki, BRFCPkiAlternate),
+		PublicProfile:    supports(BRFCPublicProfile, ""),
+		ReceiverApproval: c.Has(BRFCReceiverApprovals, ""),
+		SenderValidation: c.GetBool(BRFCSenderValidation, ""),
+		VerifyPubKey:     supports(BRFCVerifyPublicKeyOwner, ""),
+	}
+}
+
+// GetSupportedFeatures will return the capabilities of the paymail provider of the address (alias@domain) as a FeatureSet
+//
+// The capabilities are discovered using the SRV record of the domain (cached capabilities are reused).
+// The capabilities document is shared by the aliases of the domain, so the features are the same for every alias
+func (c *Client) GetSupportedFeatures(ctx context.Context, alias, domain string) (*FeatureSet, error) {
+	_, sanitizedDomain, address := SanitizePaymail(alias + "@" + domain)
+	if len(address) == 0 {
+		return nil, fmt.Errorf("invalid paymail address: %s@%s", alias, domain)
+	}
+
+	srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, sanitizedDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	var capabilities *CapabilitiesResponse
+	if capabilities, err = c.GetCapabilities(ctx, srv.Target, int(srv.Port)); err != nil {
+		return nil, err
+	}
+	return capabilities.Features(), nil
+}
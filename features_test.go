@@ -0,0 +1,35 @@
+package paymail
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestGetSupportedFeatures(t *testing.T) {
+	client, err := NewClient(WithResolver(&srvResolver{target: &net.SRV{Target: "api.test.com", Port: DefaultPort}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.(*Client).capabilities.set("api.test.com", DefaultPort, &CapabilitiesResponse{
+		CapabilitiesPayload: CapabilitiesPayload{
+			BsvAlias: DefaultBsvAliasVersion,
+			Capabilities: map[string]interface{}{
+				BRFCPki:              "https://api.test.com/id/{alias}@{domain.tld}",
+				BRFCSenderValidation: true,
+			},
+		},
+	})
+
+	features, err := client.GetSupportedFeatures(context.Background(), "alice", "test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !features.PKI || !features.SenderValidation || features.P2PDestination {
+		t.Errorf("expected the pki and sender validation, got %+v", features)
+	}
+
+	if _, err = client.GetSupportedFeatures(context.Background(), "not valid", "test.com"); err == nil {
+		t.Error("expected an invalid alias to be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package paymail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ResponseInterceptor observes the raw request and response of every outgoing HTTP request (see WithResponseInterceptor())
+//
+// The response body is a copy (reading it does not affect the parsing), the response is nil if the request failed
+type ResponseInterceptor func(req *http.Request, resp *http.Response, err error)
+
+// requestStartKey is the context key of the start time of the request
+type requestStartKey struct{}
+
+// RequestLatency will return the time since the request was started (including the retries)
+//
+// Used in a ResponseInterceptor to log the latency, returns 0 for a request that was not made by the Client
+func RequestLatency(req *http.Request) time.Duration {
+	if req == nil {
+		return 0
+	}
+	if start, ok := req.Context().Value(requestStartKey{}).(time.Time); ok {
+		return time.Since(start)
+	}
+	return 0
+}
+
+// withRequestStart will set the start time of the request on the context (if an interceptor is set)
+func (c *Client) withRequestStart(ctx context.Context) context.Context {
+	if c.options.interceptor == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, requestStartKey{}, time.Now())
+}
+
+// intercept will call the response interceptor (if set) with the raw request and a copy of the response
+func (c *Client) intercept(req *resty.Request, resp *resty.Response, err error) {
+	if c.options.interceptor == nil {
+		return
+	}
+
+	var rawResponse *http.Response
+	if resp != nil && resp.RawResponse != nil {
+		clone := *resp.RawResponse
+		clone.Header = resp.RawResponse.Header.Clone()
+		clone.Body = io.NopCloser(bytes.NewReader(bytes.Clone(resp.Body())))
+		rawResponse = &clone
+	}
+	c.options.interceptor(req.RawRequest, rawResponse, err)
+}
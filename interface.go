@@ -13,22 +13,35 @@ import (
 type ClientInterface interface {
 	CheckDNSSEC(domain string) (result *DNSCheckResult)
 	CheckSSL(host string) (valid bool, err error)
+	ClearCapabilitiesCache(domain string)
+	FetchP2PPaymentDestination(ctx context.Context, alias, domain string, amount uint64) (*PaymentDestinationPayload, error)
+	FetchPublicProfile(ctx context.Context, alias, domain string) (*PublicProfilePayload, error)
+	GetAssetCapabilities(ctx context.Context, alias, domain string) (*AssetCapabilities, error)
 	GetBRFCs() []*BRFCSpec
-	GetCapabilities(target string, port int) (response *CapabilitiesResponse, err error)
+	GetCapabilities(ctx context.Context, target string, port int) (response *CapabilitiesResponse, err error)
+	GetCurrentAddress(ctx context.Context, alias, domain string) (string, error)
 	GetOptions() *ClientOptions
-	GetP2PPaymentDestination(p2pURL, alias, domain string, paymentRequest *PaymentRequest) (response *PaymentDestinationResponse, err error)
-	GetPKI(pkiURL, alias, domain string) (response *PKIResponse, err error)
-	GetPublicProfile(publicProfileURL, alias, domain string) (response *PublicProfileResponse, err error)
+	GetPaymentDestination(ctx context.Context, alias, domain string, amount uint64, sender *SenderRequest) (*PaymentDestination, error)
+	GetP2PPaymentDestination(ctx context.Context, p2pURL, alias, domain string, paymentRequest *PaymentRequest) (response *PaymentDestinationResponse, err error)
+	GetP2PTransactionStatus(ctx context.Context, statusURL, alias, domain, reference string) (response *P2PTransactionStatusResponse, err error)
+	GetPKI(ctx context.Context, pkiURL, alias, domain string) (response *PKIResponse, err error)
+	GetPublicProfile(ctx context.Context, publicProfileURL, alias, domain string) (response *PublicProfileResponse, err error)
 	GetResolver() interfaces.DNSResolver
 	GetSRVRecord(service, protocol, domainName string) (srv *net.SRV, err error)
+	GetSupportedFeatures(ctx context.Context, alias, domain string) (*FeatureSet, error)
 	GetUserAgent() string
-	ResolveAddress(resolutionURL, alias, domain string, senderRequest *SenderRequest) (response *ResolutionResponse, err error)
-	SendP2PTransaction(p2pURL, alias, domain string, transaction *P2PTransaction) (response *P2PTransactionResponse, err error)
+	ResolveAddress(ctx context.Context, resolutionURL, alias, domain string, senderRequest *SenderRequest) (response *ResolutionResponse, err error)
+	ResolveAddresses(ctx context.Context, requests []ResolveRequest) ([]ResolveResult, error)
+	ResolveStream(ctx context.Context, handles <-chan string) <-chan ResolveResult
+	SendP2PBeefTransaction(ctx context.Context, alias, domain, beefHex string, metadata *P2PMetaData, reference string) (response *P2PTransactionPayload, err error)
+	SendP2PTransaction(ctx context.Context, p2pURL, alias, domain string, transaction *P2PTransaction) (response *P2PTransactionResponse, err error)
 	ValidateSRVRecord(ctx context.Context, srv *net.SRV, port, priority, weight uint16) error
-	VerifyPubKey(verifyURL, alias, domain, pubKey string) (response *VerificationResponse, err error)
+	VerifyPubKey(ctx context.Context, verifyURL, alias, domain, pubKey string) (response *VerificationResponse, err error)
+	VerifyPubKeyOwner(ctx context.Context, alias, domain, pubKey string) (*VerificationPayload, error)
+	WarmCapabilities(ctx context.Context, domains []string) error
 	WithCustomHTTPClient(client *resty.Client) ClientInterface
 	WithCustomResolver(resolver interfaces.DNSResolver) ClientInterface
-	AddContactRequest(url, alias, domain string, request *PikeContactRequestPayload) (response *PikeContactRequestResponse, err error)
-	AddInviteRequest(inviteURL, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error)
-	GetOutputsTemplate(pikeURL, alias, domain string, payload *PikePaymentOutputsPayload) (response *PikePaymentOutputsResponse, err error)
+	AddContactRequest(ctx context.Context, url, alias, domain string, request *PikeContactRequestPayload) (response *PikeContactRequestResponse, err error)
+	AddInviteRequest(ctx context.Context, inviteURL, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error)
+	GetOutputsTemplate(ctx context.Context, pikeURL, alias, domain string, payload *PikePaymentOutputsPayload) (response *PikePaymentOutputsResponse, err error)
 }
@@ -1,11 +1,15 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bsv-blockchain/go-sdk/script"
 )
@@ -13,13 +17,40 @@ import (
 /*
 Example:
 {
-  "satoshis": 1000100
+  "satoshis": 1000100,
+  "scriptType": "p2pkh"
 }
 */
 
+// Output script types of a payment destination (the script type hint, see ScriptTypeOf())
+const (
+	ScriptTypeMultisig = "multisig" // Bare multisig (m of n public keys)
+	ScriptTypeP2PK     = "p2pk"     // Pay to public key
+	ScriptTypeP2PKH    = "p2pkh"    // Pay to public key hash (the default)
+)
+
+// SupportedScriptTypes are the script types that can be requested (and checked) by the client
+var SupportedScriptTypes = []string{ScriptTypeMultisig, ScriptTypeP2PK, ScriptTypeP2PKH}
+
+// ScriptTypeOf will return the script type of the locking script (empty if not a SupportedScriptTypes type)
+func ScriptTypeOf(lockingScript *script.Script) string {
+	switch {
+	case lockingScript == nil:
+		return ""
+	case lockingScript.IsP2PKH():
+		return ScriptTypeP2PKH
+	case lockingScript.IsP2PK():
+		return ScriptTypeP2PK
+	case lockingScript.IsMultiSigOut():
+		return ScriptTypeMultisig
+	}
+	return ""
+}
+
 // PaymentRequest is the request body for the P2P payment request
 type PaymentRequest struct {
-	Satoshis uint64 `json:"satoshis"` // The amount, in Satoshis, that the sender intends to transfer to the receiver
+	Satoshis   uint64 `json:"satoshis"`             // The amount, in Satoshis, that the sender intends to transfer to the receiver
+	ScriptType string `json:"scriptType,omitempty"` // Hint for the type of output script (default is ScriptTypeP2PKH)
 }
 
 // PaymentDestinationResponse is the response from the GetP2PPaymentDestination() request
@@ -34,8 +65,66 @@ type PaymentDestinationResponse struct {
 //
 // The reference is unique for the payment destination request
 type PaymentDestinationPayload struct {
-	Outputs   []*PaymentOutput `json:"outputs"`   // A list of outputs
-	Reference string           `json:"reference"` // A reference for the payment, created by the receiver of the transaction
+	ExpiresAt *time.Time       `json:"expiresAt,omitempty"` // Deadline to submit the transaction for the reference (optional)
+	FeeRate   uint64           `json:"feeRate,omitempty"`   // Suggested fee rate, in satoshis per kilobyte (optional hint)
+	MinFee    uint64           `json:"minFee,omitempty"`    // Minimum fee, in satoshis, accepted by the receiver (optional hint)
+	Outputs   []*PaymentOutput `json:"outputs"`             // A list of outputs
+	PubKey    string           `json:"pubkey,omitempty"`    // Receiver's (hex encoded) pubkey of the signature (optional)
+	Reference string           `json:"reference"`           // A reference for the payment, created by the receiver of the transaction
+	Signature string           `json:"signature,omitempty"` // Receiver's signature of the outputs and reference (optional)
+}
+
+// SuggestedFee will return the fee (in satoshis) suggested by the receiver for a transaction of the size (in bytes)
+//
+// The fee rate is rounded up and the min fee is applied, returns 0 if the receiver did not give a fee hint
+func (p *PaymentDestinationPayload) SuggestedFee(txSize uint64) uint64 {
+	fee := (txSize*p.FeeRate + 999) / 1000
+	return max(fee, p.MinFee)
+}
+
+// SignatureMessage will return the message signed by the receiver (every field except the pubkey and signature)
+//
+// Each field is length-prefixed (<length>:<value>) so different payloads never sign the same bytes: the reference,
+// the number of outputs, the script and satoshis of each output, the expiry (unix), the fee rate and the min fee.
+// A missing expiry is an empty field
+func (p *PaymentDestinationPayload) SignatureMessage() []byte {
+	var message []byte
+	appendField := func(value string) {
+		message = fmt.Appendf(message, "%d:%s", len(value), value)
+	}
+
+	appendField(p.Reference)
+	appendField(strconv.Itoa(len(p.Outputs)))
+	for _, output := range p.Outputs {
+		appendField(output.Script)
+		appendField(strconv.FormatUint(output.Satoshis, 10))
+	}
+	var expiresAt string
+	if p.ExpiresAt != nil {
+		expiresAt = strconv.FormatInt(p.ExpiresAt.Unix(), 10)
+	}
+	appendField(expiresAt)
+	appendField(strconv.FormatUint(p.FeeRate, 10))
+	appendField(strconv.FormatUint(p.MinFee, 10))
+	return message
+}
+
+// VerifySignature will verify the receiver's signature (attestation) of the outputs and reference
+//
+// The signature is a Bitcoin signed message of SignatureMessage() using the PubKey of the payload.
+// The sender should also confirm the PubKey belongs to the receiver (e.g. using VerifyPubKeyOwner())
+func (p *PaymentDestinationPayload) VerifySignature() error {
+	if len(p.Signature) == 0 {
+		return fmt.Errorf("missing a signature to verify")
+	} else if len(p.PubKey) == 0 {
+		return fmt.Errorf("missing pubkey")
+	}
+
+	address, err := script.NewAddressFromPublicKeyString(p.PubKey, true)
+	if err != nil {
+		return err
+	}
+	return VerifySignature(SignatureSchemeBitcoinMessage, address.AddressString, p.Signature, p.SignatureMessage())
 }
 
 // PaymentOutput is returned inside the payment destination response
@@ -43,19 +132,81 @@ type PaymentDestinationPayload struct {
 // There can be several outputs in one response based on the amount of satoshis being transferred and
 // the rules in place by the Paymail provider
 type PaymentOutput struct {
-	Address  string `json:"address,omitempty"`  // Hex encoded locking script
+	Address  string `json:"address,omitempty"`  // Legacy BSV address (P2PKH scripts only)
 	Satoshis uint64 `json:"satoshis,omitempty"` // Number of satoshis for that output
 	Script   string `json:"script"`             // Hex encoded locking script
 }
 
+// FetchP2PPaymentDestination will request the outputs (and reference) to pay the amount to the paymail address
+//
+// The capability URL is discovered from the capabilities of the domain.
+// Outputs with an amount must add up to the requested amount.
+//
+// Specs: https://docs.moneybutton.com/docs/paymail-07-p2p-payment-destination.html
+func (c *Client) FetchP2PPaymentDestination(ctx context.Context, alias, domain string,
+	amount uint64) (*PaymentDestinationPayload, error) {
+
+	// Check the amount before the capability discovery
+	if err := ValidateSatoshis(amount); err != nil {
+		return nil, err
+	}
+
+	p2pURL, err := c.discoverCapabilityURL(ctx, domain, BRFCP2PPaymentDestination, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var response *PaymentDestinationResponse
+	if response, err = c.GetP2PPaymentDestination(
+		ctx, p2pURL, alias, domain, &PaymentRequest{Satoshis: amount},
+	); err != nil {
+		return nil, err
+	}
+
+	// Check the amounts (outputs without an amount are left to the sender)
+	var total uint64
+	allSet := true
+	for _, out := range response.Outputs {
+		total += out.Satoshis
+		allSet = allSet && out.Satoshis > 0
+	}
+	if total > amount || (allSet && total != amount) {
+		return nil, fmt.Errorf("outputs total %d satoshis does not match the requested amount: %d", total, amount)
+	}
+
+	return &response.PaymentDestinationPayload, nil
+}
+
+// Validate will check that the output has a script and that the amount (if set) does not exceed MaxSatoshis
+func (o *PaymentOutput) Validate() error {
+	if len(o.Script) == 0 {
+		return errors.New("script is required")
+	} else if o.Satoshis > MaxSatoshis {
+		return fmt.Errorf("satoshis %d exceeds the max supply of %d", o.Satoshis, MaxSatoshis)
+	}
+	return nil
+}
+
+// DecodeAddress will decode the (P2PKH) output script and return its address
+//
+// Returns an error for non-standard (non P2PKH) scripts
+func (o *PaymentOutput) DecodeAddress() (string, error) {
+	return decodeP2PKHAddress(o.Script)
+}
+
+// IsP2PKH will return true if the output is a standard P2PKH script
+func (o *PaymentOutput) IsP2PKH() bool {
+	return isP2PKHScript(o.Script)
+}
+
 // GetP2PPaymentDestination will return list of outputs for the P2P transactions to use
 //
 // Specs: https://docs.moneybutton.com/docs/paymail-07-p2p-payment-destination.html
-func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
+func (c *Client) GetP2PPaymentDestination(ctx context.Context, p2pURL, alias, domain string,
 	paymentRequest *PaymentRequest) (response *PaymentDestinationResponse, err error) {
 
 	// Require a valid url
-	if len(p2pURL) == 0 || !strings.Contains(p2pURL, "https://") {
+	if len(p2pURL) == 0 || !c.isAllowedURL(p2pURL) {
 		err = fmt.Errorf("invalid url: %s", p2pURL)
 		return
 	}
@@ -64,8 +215,15 @@ func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
 	if paymentRequest == nil {
 		err = errors.New("paymentRequest cannot be nil")
 		return
-	} else if paymentRequest.Satoshis == 0 {
-		err = errors.New("satoshis is required")
+	} else if err = ValidateSatoshis(paymentRequest.Satoshis); err != nil {
+		return
+	}
+	scriptType := strings.ToLower(strings.TrimSpace(paymentRequest.ScriptType))
+	if len(scriptType) == 0 {
+		scriptType = ScriptTypeP2PKH
+	}
+	if !slices.Contains(SupportedScriptTypes, scriptType) {
+		err = fmt.Errorf("unsupported script type: %s", paymentRequest.ScriptType)
 		return
 	} else if len(alias) == 0 {
 		err = errors.New("missing alias")
@@ -75,14 +233,22 @@ func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(p2pURL, BRFCP2PPaymentDestination); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/api/rawtx/{alias}@{domain.tld}
 	// https://<host-discovery-target>/api/p2p-payment-destination/{alias}@{domain.tld}
-	reqURL := replaceAliasDomain(p2pURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(p2pURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the POST request
 	var resp StandardResponse
-	if resp, err = c.postRequest(reqURL, paymentRequest); err != nil {
+	if resp, err = c.postRequest(ctx, "p2p payment destination", reqURL, paymentRequest); err != nil {
 		return
 	}
 
@@ -97,14 +263,7 @@ func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
 		if response.StatusCode == http.StatusNotFound {
 			err = errors.New("paymail address not found")
 		} else {
-			serverError := &ServerError{}
-			if err = json.Unmarshal(resp.Body, serverError); err != nil {
-				return
-			}
-			err = fmt.Errorf(
-				"bad response from paymail provider: code %d, message: %s",
-				response.StatusCode, serverError.Message,
-			)
+			err = c.prepareServerErrorResponse(&resp)
 		}
 
 		return
@@ -129,9 +288,9 @@ func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
 
 	// Loop all outputs
 	for index, out := range response.Outputs {
-		// No script returned
-		if len(out.Script) == 0 {
-			err = fmt.Errorf("script was missing from output: %d", index)
+		// No script returned (or an invalid amount)
+		if err = out.Validate(); err != nil {
+			err = fmt.Errorf("invalid output %d: %w", index, err)
 			return
 		}
 
@@ -141,6 +300,15 @@ func (c *Client) GetP2PPaymentDestination(p2pURL, alias, domain string,
 			return
 		}
 
+		// The script must be of the requested type (only a P2PKH script has an address)
+		if outputType := ScriptTypeOf(sc); outputType != scriptType {
+			err = fmt.Errorf("invalid output %d: script type %q does not match the requested %s",
+				index, outputType, scriptType)
+			return
+		} else if outputType != ScriptTypeP2PKH {
+			continue
+		}
+
 		var addresses []string
 		addresses, err = sc.Addresses()
 		if err != nil || len(addresses) == 0 {
@@ -0,0 +1,154 @@
+package paymail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+const (
+	testP2PKHScript = "76a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac"
+	testP2PKScript  = "210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac"
+)
+
+// newDestinationServer will return a server answering the P2P payment destination with the output script
+func newDestinationServer(t *testing.T, outputScript string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"outputs":[{"script":"` + outputScript + `","satoshis":1000}],"reference":"reference-1"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T) ClientInterface {
+	t.Helper()
+	client, err := NewClient(WithInsecureHTTP(true))
+	if err != nil {
+		t.Fatalf("failed to create the client: %s", err)
+	}
+	return client
+}
+
+func TestScriptTypeOf(t *testing.T) {
+	for scriptHex, expected := range map[string]string{
+		testP2PKHScript:    ScriptTypeP2PKH,
+		testP2PKScript:     ScriptTypeP2PK,
+		"006a0568656c6c6f": "",
+	} {
+		lockingScript, err := script.NewFromHex(scriptHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scriptType := ScriptTypeOf(lockingScript); scriptType != expected {
+			t.Errorf("%s: expected %q, got %q", scriptHex, expected, scriptType)
+		}
+	}
+}
+
+func TestGetP2PPaymentDestination_ScriptTypes(t *testing.T) {
+	client := newTestClient(t)
+
+	t.Run("p2pkh has an address", func(t *testing.T) {
+		srv := newDestinationServer(t, testP2PKHScript)
+		response, err := client.GetP2PPaymentDestination(context.Background(), srv.URL+"/{alias}@{domain.tld}",
+			"alice", "test.com", &PaymentRequest{Satoshis: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Outputs[0].Address) == 0 {
+			t.Error("expected the address of the P2PKH output")
+		}
+	})
+
+	t.Run("p2pk has no address", func(t *testing.T) {
+		srv := newDestinationServer(t, testP2PKScript)
+		response, err := client.GetP2PPaymentDestination(context.Background(), srv.URL+"/{alias}@{domain.tld}",
+			"alice", "test.com", &PaymentRequest{Satoshis: 1000, ScriptType: ScriptTypeP2PK})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Outputs[0].Address) != 0 {
+			t.Errorf("expected no address, got %s", response.Outputs[0].Address)
+		}
+	})
+
+	t.Run("script type mismatch", func(t *testing.T) {
+		srv := newDestinationServer(t, testP2PKScript)
+		_, err := client.GetP2PPaymentDestination(context.Background(), srv.URL+"/{alias}@{domain.tld}",
+			"alice", "test.com", &PaymentRequest{Satoshis: 1000})
+		if err == nil || !strings.Contains(err.Error(), "does not match") {
+			t.Errorf("expected a script type mismatch, got %v", err)
+		}
+	})
+
+	t.Run("unsupported script type", func(t *testing.T) {
+		_, err := client.GetP2PPaymentDestination(context.Background(), "https://test.com/{alias}@{domain.tld}",
+			"alice", "test.com", &PaymentRequest{Satoshis: 1000, ScriptType: "p2sh"})
+		if err == nil || !strings.Contains(err.Error(), "unsupported script type") {
+			t.Errorf("expected an unsupported script type, got %v", err)
+		}
+	})
+}
+
+func TestSignatureMessage_Unambiguous(t *testing.T) {
+	scriptHex := testP2PKHScript
+	a := &PaymentDestinationPayload{Reference: "ref", Outputs: []*PaymentOutput{{Script: scriptHex + "10", Satoshis: 0}}}
+	b := &PaymentDestinationPayload{Reference: "ref", Outputs: []*PaymentOutput{{Script: scriptHex, Satoshis: 100}}}
+	if string(a.SignatureMessage()) == string(b.SignatureMessage()) {
+		t.Error("expected different outputs to sign different messages")
+	}
+
+	// The fee hints are signed
+	withFee := &PaymentDestinationPayload{Reference: "ref", Outputs: b.Outputs, FeeRate: 1}
+	withMinFee := &PaymentDestinationPayload{Reference: "ref", Outputs: b.Outputs, MinFee: 1}
+	if string(withFee.SignatureMessage()) == string(b.SignatureMessage()) ||
+		string(withFee.SignatureMessage()) == string(withMinFee.SignatureMessage()) {
+		t.Error("expected the fee rate and min fee to be signed")
+	}
+}
+
+func TestVerifySignature_RoundTrip(t *testing.T) {
+	privateKey, err := primitives.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &PaymentDestinationPayload{
+		FeeRate:   50,
+		Outputs:   []*PaymentOutput{{Script: testP2PKHScript, Satoshis: 1000}},
+		PubKey:    privateKey.PubKey().ToDERHex(),
+		Reference: "reference-1",
+	}
+	sigBytes, err := bsm.SignMessage(privateKey, payload.SignatureMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Signature = EncodeSignature(sigBytes)
+	if err = payload.VerifySignature(); err != nil {
+		t.Fatalf("expected a valid signature, got %s", err)
+	}
+
+	// Changing a signed field invalidates the signature
+	payload.MinFee = 1
+	if err = payload.VerifySignature(); err == nil {
+		t.Error("expected the signature to be invalid after changing the min fee")
+	}
+}
+
+func TestFetchP2PPaymentDestination_InvalidAmount(t *testing.T) {
+	// The amount is checked before the capability discovery (no SRV lookup)
+	client := newTestClient(t)
+	for _, amount := range []uint64{0, MaxSatoshis + 1} {
+		if _, err := client.FetchP2PPaymentDestination(context.Background(), "alice", "test.invalid", amount); err == nil ||
+			strings.Contains(err.Error(), "srv") {
+			t.Errorf("%d: expected an invalid amount, got %v", amount, err)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package paymail
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/AmanTrance/go-paymail/beef"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
 )
 
 /*
@@ -26,19 +30,23 @@ Example:
 
 // P2PTransaction is the request body for the P2P transaction request
 type P2PTransaction struct {
-	Hex         string            `json:"hex"`         // The raw transaction, encoded as a hexadecimal string
-	Beef        string            `json:"beef"`        // The transaction in hex BEEF format
-	DecodedBeef *beef.DecodedBEEF `json:"decodedBeef"` // Decoded BEEF transaction
-	MetaData    *P2PMetaData      `json:"metadata"`    // An object containing data associated with the transaction
-	Reference   string            `json:"reference"`   // Reference for the payment (from previous P2P Destination request)
+	Hex         string            `json:"hex,omitempty"`         // The raw transaction, encoded as a hexadecimal string (or the Beef)
+	Beef        string            `json:"beef,omitempty"`        // The transaction in hex BEEF format (or the Hex)
+	DecodedBeef *beef.DecodedBEEF `json:"decodedBeef,omitempty"` // Decoded BEEF transaction
+	MetaData    *P2PMetaData      `json:"metadata"`              // An object containing data associated with the transaction
+	Reference   string            `json:"reference"`             // Reference for the payment (from previous P2P Destination request)
 }
 
 // P2PMetaData is an object containing data associated with the P2P transaction
 type P2PMetaData struct {
-	Note      string `json:"note,omitempty"`      // A human-readable bit of information about the payment
-	PublicKey string `json:"pubkey,omitempty"`    // Public key to validate the signature (if signature is given)
-	Sender    string `json:"sender,omitempty"`    // The paymail of the person that originated the transaction
-	Signature string `json:"signature,omitempty"` // A signature of the tx id made by the sender
+	CallbackURL string `json:"callbackUrl,omitempty"` // Sender callback (https) for the merkle proof delivery (optional)
+	Note        string `json:"note,omitempty"`        // A human-readable bit of information about the payment
+	PublicKey   string `json:"pubkey,omitempty"`      // Public key to validate the signature (if signature is given)
+	Sender      string `json:"sender,omitempty"`      // The paymail of the person that originated the transaction
+	Signature   string `json:"signature,omitempty"`   // A signature of the tx id made by the sender
+
+	// SignatureScheme is the scheme of the signature (empty is the legacy Bitcoin signed message)
+	SignatureScheme string `json:"signatureScheme,omitempty"`
 }
 
 // P2PTransactionResponse is the response to the request
@@ -47,20 +55,92 @@ type P2PTransactionResponse struct {
 	P2PTransactionPayload
 }
 
+// P2P transaction statuses (receiver approvals)
+const (
+	P2PTransactionStatusAccepted = "accepted" // The transaction was accepted by the receiver
+	P2PTransactionStatusPending  = "pending"  // The transaction is waiting for the approval of the receiver
+	P2PTransactionStatusRejected = "rejected" // The transaction was rejected by the receiver
+)
+
 // P2PTransactionPayload is payload from the request
 type P2PTransactionPayload struct {
-	Note string `json:"note"` // Some human-readable note
-	TxID string `json:"txid"` // The txid of the broadcasted tx
+	BroadcastStatus string           `json:"broadcastStatus,omitempty"` // Status returned by the receiver's broadcaster (if any)
+	DryRun          bool             `json:"dryRun,omitempty"`          // The tx was validated but not recorded (dry-run)
+	Note            string           `json:"note,omitempty"`            // Some human-readable note
+	Outputs         []*PaymentOutput `json:"outputs,omitempty"`         // The issued outputs paid by the tx (script & satoshis)
+	Status          string           `json:"status,omitempty"`          // Status of the transaction (accepted, pending or rejected)
+	TxID            string           `json:"txid"`                      // The txid of the broadcasted tx
+}
+
+// SignatureMessage will return the message that is signed by the sender (the tx id, as a hex string)
+//
+// The tx id is taken from the Hex, or from the latest transaction of the BEEF. Returns an empty
+// string if the transaction cannot be decoded
+func (t *P2PTransaction) SignatureMessage() string {
+	if len(t.Hex) > 0 {
+		tx, err := sdk.NewTransactionFromHex(t.Hex)
+		if err != nil {
+			return ""
+		}
+		return tx.TxID().String()
+	}
+
+	decodedBeef := t.DecodedBeef
+	if decodedBeef == nil && len(t.Beef) > 0 {
+		var err error
+		if decodedBeef, err = beef.DecodeBEEF(t.Beef); err != nil {
+			return ""
+		}
+	}
+	if decodedBeef == nil || len(decodedBeef.Transactions) == 0 {
+		return ""
+	}
+	if tx := decodedBeef.GetLatestTx(); tx != nil {
+		return tx.TxID().String()
+	}
+	return ""
+}
+
+// Sign will sign the tx id using the (hex encoded) private key of the sender
+//
+// The MetaData PublicKey and (base64 encoded) Signature fields are set, which is what the
+// receiver uses to verify the transaction came from the sender
+func (t *P2PTransaction) Sign(privateKey string) error {
+	if len(privateKey) == 0 {
+		return errors.New("missing private key")
+	}
+
+	message := t.SignatureMessage()
+	if len(message) == 0 {
+		return errors.New("beef or hex is required to sign the transaction")
+	}
+
+	privKey, err := primitives.PrivateKeyFromHex(privateKey)
+	if err != nil {
+		return err
+	}
+
+	var sigBytes []byte
+	if sigBytes, err = bsm.SignMessage(privKey, []byte(message)); err != nil {
+		return err
+	}
+
+	if t.MetaData == nil {
+		t.MetaData = &P2PMetaData{}
+	}
+	t.MetaData.PublicKey = hex.EncodeToString(privKey.PubKey().Compressed())
+	t.MetaData.Signature = EncodeSignature(sigBytes)
+	return nil
 }
 
 // SendP2PTransaction will submit a transaction hex string (tx_hex) to a paymail provider
 //
 // Specs: https://docs.moneybutton.com/docs/paymail-06-p2p-transactions.html
-func (c *Client) SendP2PTransaction(p2pURL, alias, domain string,
+func (c *Client) SendP2PTransaction(ctx context.Context, p2pURL, alias, domain string,
 	transaction *P2PTransaction) (response *P2PTransactionResponse, err error) {
 
 	// Require a valid url
-	if len(p2pURL) == 0 || !strings.Contains(p2pURL, "https://") {
+	if len(p2pURL) == 0 || !c.isAllowedURL(p2pURL) {
 		err = fmt.Errorf("invalid url: %s", p2pURL)
 		return
 	} else if len(alias) == 0 {
@@ -83,14 +163,22 @@ func (c *Client) SendP2PTransaction(p2pURL, alias, domain string,
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(p2pURL, BRFCP2PTransactions, BRFCBeefTransaction); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/api/rawtx/{alias}@{domain.tld}
 	// https://<host-discovery-target>/api/receive-transaction/{alias}@{domain.tld}
-	reqURL := replaceAliasDomain(p2pURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(p2pURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the POST request
 	var resp StandardResponse
-	if resp, err = c.postRequest(reqURL, transaction); err != nil {
+	if resp, err = c.postRequest(ctx, "p2p send transaction", reqURL, transaction); err != nil {
 		return
 	}
 
@@ -104,15 +192,7 @@ func (c *Client) SendP2PTransaction(p2pURL, alias, domain string,
 		if response.StatusCode == http.StatusNotFound {
 			err = errors.New("paymail address not found")
 		} else {
-			serverError := &ServerError{}
-			if err = json.Unmarshal(resp.Body, serverError); err != nil {
-				return
-			}
-			if len(serverError.Message) == 0 {
-				err = fmt.Errorf("bad response from paymail provider: code %d, body: %s", response.StatusCode, string(resp.Body))
-			} else {
-				err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
-			}
+			err = c.prepareServerErrorResponse(&resp)
 		}
 
 		return
@@ -131,3 +211,43 @@ func (c *Client) SendP2PTransaction(p2pURL, alias, domain string,
 
 	return
 }
+
+// SendP2PBeefTransaction will submit a transaction in BEEF format to the paymail provider
+//
+// The BEEF endpoint is discovered from the capabilities of the receiver's domain.
+//
+// Specs: https://bsv.brc.dev/payments/0070
+func (c *Client) SendP2PBeefTransaction(ctx context.Context, alias, domain, beefHex string, metadata *P2PMetaData,
+	reference string) (response *P2PTransactionPayload, err error) {
+
+	// Basic requirements for request
+	if len(alias) == 0 {
+		err = errors.New("missing alias")
+		return
+	} else if len(domain) == 0 {
+		err = errors.New("missing domain")
+		return
+	} else if len(beefHex) == 0 {
+		err = errors.New("beef is required")
+		return
+	}
+
+	// Get the BEEF URL from the capabilities of the receiver's domain
+	var beefURL string
+	if beefURL, err = c.discoverCapabilityURL(ctx, domain, BRFCBeefTransaction, ""); err != nil {
+		return
+	}
+
+	// Fire the request
+	var resp *P2PTransactionResponse
+	if resp, err = c.SendP2PTransaction(ctx, beefURL, alias, domain, &P2PTransaction{
+		Beef:      beefHex,
+		MetaData:  metadata,
+		Reference: reference,
+	}); err != nil {
+		return
+	}
+
+	response = &resp.P2PTransactionPayload
+	return
+}
@@ -0,0 +1,53 @@
+package paymail
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// assertJSON will check that the payload marshals to the expected JSON
+func assertJSON(t *testing.T, payload any, expected string) {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestPayloads_JSON(t *testing.T) {
+	t.Run("empty optional fields are omitted", func(t *testing.T) {
+		assertJSON(t, &P2PMetaData{}, `{}`)
+		assertJSON(t, &P2PTransactionPayload{TxID: "txid"}, `{"txid":"txid"}`)
+		assertJSON(t, &ResolutionPayload{Output: "76a9"}, `{"output":"76a9"}`)
+		assertJSON(t, &CurrentAddressPayload{Output: "76a9"}, `{"output":"76a9"}`)
+		assertJSON(t, &P2PTransaction{Hex: "01"}, `{"hex":"01","metadata":null,"reference":""}`)
+	})
+
+	t.Run("field names", func(t *testing.T) {
+		assertJSON(t, &P2PMetaData{
+			CallbackURL:     "https://test.com/callback",
+			Note:            "note",
+			PublicKey:       "pubkey",
+			Sender:          "bob@test.com",
+			Signature:       "signature",
+			SignatureScheme: "bsm",
+		}, `{"callbackUrl":"https://test.com/callback","note":"note","pubkey":"pubkey","sender":"bob@test.com",`+
+			`"signature":"signature","signatureScheme":"bsm"}`)
+		assertJSON(t, &P2PTransactionPayload{
+			BroadcastStatus: "SEEN_ON_NETWORK",
+			DryRun:          true,
+			Note:            "note",
+			Outputs:         []*PaymentOutput{{Address: "address", Satoshis: 1000, Script: "76a9"}},
+			Status:          P2PTransactionStatusAccepted,
+			TxID:            "txid",
+		}, `{"broadcastStatus":"SEEN_ON_NETWORK","dryRun":true,"note":"note",`+
+			`"outputs":[{"address":"address","satoshis":1000,"script":"76a9"}],"status":"accepted","txid":"txid"}`)
+		assertJSON(t, &ResolutionPayload{Address: "address", Output: "76a9", Signature: "signature"},
+			`{"address":"address","output":"76a9","signature":"signature"}`)
+		assertJSON(t, &CurrentAddressPayload{Address: "address", Output: "76a9"},
+			`{"address":"address","output":"76a9"}`)
+	})
+}
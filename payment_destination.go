@@ -0,0 +1,79 @@
+package paymail
+
+import (
+	"context"
+	"fmt"
+)
+
+// Payment destination protocols
+const (
+	PaymentProtocolBasic = "basic" // Basic address resolution (paymentDestination)
+	PaymentProtocolP2P   = "p2p"   // P2P payment destination
+)
+
+// PaymentDestination is the normalized result of GetPaymentDestination()
+type PaymentDestination struct {
+	FeeRate   uint64           `json:"feeRate,omitempty"`   // Suggested fee rate, in satoshis per kilobyte (P2P only, optional)
+	MinFee    uint64           `json:"minFee,omitempty"`    // Minimum fee, in satoshis, accepted by the receiver (P2P only, optional)
+	Outputs   []*PaymentOutput `json:"outputs"`             // A list of outputs
+	Protocol  string           `json:"protocol"`            // The protocol used (PaymentProtocolP2P or PaymentProtocolBasic)
+	Reference string           `json:"reference,omitempty"` // A reference for the payment (P2P only)
+}
+
+// GetPaymentDestination will return the outputs to pay the amount to the paymail address
+//
+// The P2P payment destination is preferred, falling back to basic address resolution if the
+// provider does not support P2P (the fallback can be disabled using WithPaymentDestinationFallback())
+func (c *Client) GetPaymentDestination(ctx context.Context, alias, domain string, amount uint64,
+	sender *SenderRequest) (*PaymentDestination, error) {
+
+	srv, err := c.GetSRVRecord(DefaultServiceName, DefaultProtocol, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var capabilities *CapabilitiesResponse
+	if capabilities, err = c.GetCapabilities(ctx, srv.Target, int(srv.Port)); err != nil {
+		return nil, err
+	}
+
+	// Prefer P2P
+	if p2pURL := capabilities.GetString(BRFCP2PPaymentDestination, ""); len(p2pURL) > 0 {
+		var response *PaymentDestinationResponse
+		if response, err = c.GetP2PPaymentDestination(
+			ctx, p2pURL, alias, domain, &PaymentRequest{Satoshis: amount},
+		); err != nil {
+			return nil, err
+		}
+		return &PaymentDestination{
+			FeeRate:   response.FeeRate,
+			MinFee:    response.MinFee,
+			Outputs:   response.Outputs,
+			Protocol:  PaymentProtocolP2P,
+			Reference: response.Reference,
+		}, nil
+	} else if !c.options.paymentFallback {
+		return nil, fmt.Errorf("paymail provider %s does not support the %s capability: %w",
+			domain, BRFCP2PPaymentDestination, ErrCapabilityNotSupported)
+	}
+
+	// Fallback to basic address resolution
+	resolutionURL := capabilities.GetString(BRFCPaymentDestination, BRFCBasicAddressResolution)
+	if len(resolutionURL) == 0 {
+		return nil, fmt.Errorf("paymail provider %s does not support the %s capability: %w",
+			domain, BRFCPaymentDestination, ErrCapabilityNotSupported)
+	}
+
+	var response *ResolutionResponse
+	if response, err = c.ResolveAddress(ctx, resolutionURL, alias, domain, sender); err != nil {
+		return nil, err
+	}
+	return &PaymentDestination{
+		Outputs: []*PaymentOutput{{
+			Address:  response.Address,
+			Satoshis: amount,
+			Script:   response.Output,
+		}},
+		Protocol: PaymentProtocolBasic,
+	}, nil
+}
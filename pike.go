@@ -1,11 +1,11 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 // PikeContactRequestResponse is PIKE wrapper for StandardResponse
@@ -37,7 +37,7 @@ type OutputTemplate struct {
 	Satoshis uint64 `json:"satoshis"`
 }
 
-func (c *Client) AddContactRequest(url, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error) {
+func (c *Client) AddContactRequest(ctx context.Context, url, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error) {
 
 	if err := c.validateUrlWithPaymail(url, alias, domain); err != nil {
 		return nil, err
@@ -47,11 +47,19 @@ func (c *Client) AddContactRequest(url, alias, domain string, request *PikeConta
 		return nil, err
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err := c.checkCapability(url, BRFCPike); err != nil {
+		return nil, err
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/{alias}@{domain.tld}/id
-	reqURL := replaceAliasDomain(url, alias, domain)
+	reqURL, err := ResolveURL(url, alias, domain)
+	if err != nil {
+		return nil, err
+	}
 
-	response, err := c.postRequest(reqURL, request)
+	response, err := c.postRequest(ctx, "pike contact request", reqURL, request)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +76,7 @@ func (c *Client) AddContactRequest(url, alias, domain string, request *PikeConta
 }
 
 func (c *Client) validateUrlWithPaymail(url, alias, domain string) error {
-	if len(url) == 0 || !strings.HasPrefix(url, "https://") {
+	if len(url) == 0 || !c.isAllowedURL(url) {
 		return fmt.Errorf("invalid url: %s", url)
 	} else if alias == "" {
 		return errors.New("missing alias")
@@ -78,19 +86,6 @@ func (c *Client) validateUrlWithPaymail(url, alias, domain string) error {
 	return nil
 }
 
-func (c *Client) prepareServerErrorResponse(response *StandardResponse) error {
-	var details string
-
-	serverError := &ServerError{}
-	if err := json.Unmarshal(response.Body, serverError); err != nil || serverError.Message == "" {
-		details = fmt.Sprintf("body: %s", string(response.Body))
-	} else {
-		details = fmt.Sprintf("message: %s", serverError.Message)
-	}
-
-	return fmt.Errorf("bad response from paymail provider: code %d, %s", response.StatusCode, details)
-}
-
 func (r *PikeContactRequestPayload) validate() error {
 	if r.FullName == "" {
 		return errors.New("missing full name")
@@ -103,9 +98,9 @@ func (r *PikeContactRequestPayload) validate() error {
 }
 
 // GetOutputsTemplate calls the PIKE capability outputs subcapability
-func (c *Client) GetOutputsTemplate(pikeURL, alias, domain string, payload *PikePaymentOutputsPayload) (response *PikePaymentOutputsResponse, err error) {
+func (c *Client) GetOutputsTemplate(ctx context.Context, pikeURL, alias, domain string, payload *PikePaymentOutputsPayload) (response *PikePaymentOutputsResponse, err error) {
 	// Require a valid URL
-	if len(pikeURL) == 0 || !strings.Contains(pikeURL, "https://") {
+	if len(pikeURL) == 0 || !c.isAllowedURL(pikeURL) {
 		err = fmt.Errorf("invalid url: %s", pikeURL)
 		return
 	}
@@ -125,12 +120,20 @@ func (c *Client) GetOutputsTemplate(pikeURL, alias, domain string, payload *Pike
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(pikeURL, BRFCPike); err != nil {
+		return
+	}
+
 	// Set the base URL and path, assuming the URL is from the prior GetCapabilities() request
-	reqURL := replaceAliasDomain(pikeURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(pikeURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the POST request
 	var resp StandardResponse
-	if resp, err = c.postRequest(reqURL, payload); err != nil {
+	if resp, err = c.postRequest(ctx, "pike outputs", reqURL, payload); err != nil {
 		return
 	}
 
@@ -149,6 +152,6 @@ func (c *Client) GetOutputsTemplate(pikeURL, alias, domain string, payload *Pike
 }
 
 // AddInviteRequest sends a contact request using the invite URL from capabilities
-func (c *Client) AddInviteRequest(inviteURL, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error) {
-	return c.AddContactRequest(inviteURL, alias, domain, request)
+func (c *Client) AddInviteRequest(ctx context.Context, inviteURL, alias, domain string, request *PikeContactRequestPayload) (*PikeContactRequestResponse, error) {
+	return c.AddContactRequest(ctx, inviteURL, alias, domain, request)
 }
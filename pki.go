@@ -1,10 +1,14 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
 )
 
 /*
@@ -24,18 +28,49 @@ type PKIResponse struct {
 
 // PKIPayload is the payload from the response
 type PKIPayload struct {
-	BsvAlias string `json:"bsvalias"` // Version of Paymail
-	Handle   string `json:"handle"`   // The <alias>@<domain>.<tld>
-	PubKey   string `json:"pubkey"`   // The related PubKey
+	BsvAlias  string                `json:"bsvalias"` // Version of Paymail
+	Handle    string                `json:"handle"`   // The <alias>@<domain>.<tld>
+	PubKey    string                `json:"pubkey"`   // The related PubKey
+	PublicKey *primitives.PublicKey `json:"-"`        // The parsed PubKey (set by GetPKI)
+}
+
+// Address will return the P2PKH address of the PubKey
+func (p *PKIPayload) Address(mainnet bool) (string, error) {
+	publicKey := p.PublicKey
+	if publicKey == nil {
+		var err error
+		if publicKey, err = parseCompressedPubKey(p.PubKey); err != nil {
+			return "", err
+		}
+	}
+
+	address, err := script.NewAddressFromPublicKey(publicKey, mainnet)
+	if err != nil {
+		return "", err
+	}
+	return address.AddressString, nil
+}
+
+// parseCompressedPubKey will parse the hex encoded PubKey, which must be a valid compressed secp256k1 point
+func parseCompressedPubKey(pubKey string) (*primitives.PublicKey, error) {
+	if len(pubKey) != PubKeyLength || (!strings.HasPrefix(pubKey, "02") && !strings.HasPrefix(pubKey, "03")) {
+		return nil, fmt.Errorf("pubkey is not a compressed public key: %s", pubKey)
+	}
+
+	publicKey, err := primitives.PublicKeyFromString(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("pubkey is not a valid secp256k1 point: %w", err)
+	}
+	return publicKey, nil
 }
 
 // GetPKI will return a valid PKI response for a given alias@domain.tld
 //
 // Specs: http://bsvalias.org/03-public-key-infrastructure.html
-func (c *Client) GetPKI(pkiURL, alias, domain string) (response *PKIResponse, err error) {
+func (c *Client) GetPKI(ctx context.Context, pkiURL, alias, domain string) (response *PKIResponse, err error) {
 
 	// Require a valid url
-	if len(pkiURL) == 0 || !strings.Contains(pkiURL, "https://") {
+	if len(pkiURL) == 0 || !c.isAllowedURL(pkiURL) {
 		err = fmt.Errorf("invalid url: %s", pkiURL)
 		return
 	}
@@ -49,13 +84,21 @@ func (c *Client) GetPKI(pkiURL, alias, domain string) (response *PKIResponse, er
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(pkiURL, BRFCPki, BRFCPkiAlternate); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/{alias}@{domain.tld}/id
-	reqURL := replaceAliasDomain(pkiURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(pkiURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the GET request
 	var resp StandardResponse
-	if resp, err = c.getRequest(reqURL); err != nil {
+	if resp, err = c.getRequest(ctx, "pki", reqURL); err != nil {
 		return
 	}
 
@@ -64,11 +107,7 @@ func (c *Client) GetPKI(pkiURL, alias, domain string) (response *PKIResponse, er
 
 	// Test the status code (200 or 304 is valid)
 	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
-		serverError := &ServerError{}
-		if err = json.Unmarshal(resp.Body, serverError); err != nil {
-			return
-		}
-		err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
+		err = c.prepareServerErrorResponse(&resp)
 		return
 	}
 
@@ -92,9 +131,13 @@ func (c *Client) GetPKI(pkiURL, alias, domain string) (response *PKIResponse, er
 	// Check the PubKey length
 	if len(response.PubKey) == 0 {
 		err = fmt.Errorf("pki response is missing a PubKey value")
+		return
 	} else if len(response.PubKey) != PubKeyLength {
 		err = fmt.Errorf("returned pubkey is not the required length of %d, got: %d", PubKeyLength, len(response.PubKey))
+		return
 	}
 
+	// Parse the PubKey (must be a valid compressed point)
+	response.PublicKey, err = parseCompressedPubKey(response.PubKey)
 	return
 }
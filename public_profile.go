@@ -1,10 +1,11 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
 )
 
 /*
@@ -27,13 +28,32 @@ type PublicProfilePayload struct {
 	Name   string `json:"name"`   // A string up to 100 characters long. (name or nickname)
 }
 
+// FetchPublicProfile will return the public profile (name & avatar) of the paymail address
+//
+// The capability URL is discovered from the capabilities of the domain.
+// Returns ErrCapabilityNotSupported if the provider does not support public profiles.
+//
+// Specs: https://github.com/bitcoin-sv-specs/brfc-paymail/pull/7/files
+func (c *Client) FetchPublicProfile(ctx context.Context, alias, domain string) (*PublicProfilePayload, error) {
+	publicProfileURL, err := c.discoverCapabilityURL(ctx, domain, BRFCPublicProfile, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var response *PublicProfileResponse
+	if response, err = c.GetPublicProfile(ctx, publicProfileURL, alias, domain); err != nil {
+		return nil, err
+	}
+	return &response.PublicProfilePayload, nil
+}
+
 // GetPublicProfile will return a valid public profile
 //
 // Specs: https://github.com/bitcoin-sv-specs/brfc-paymail/pull/7/files
-func (c *Client) GetPublicProfile(publicProfileURL, alias, domain string) (response *PublicProfileResponse, err error) {
+func (c *Client) GetPublicProfile(ctx context.Context, publicProfileURL, alias, domain string) (response *PublicProfileResponse, err error) {
 
 	// Require a valid url
-	if len(publicProfileURL) == 0 || !strings.Contains(publicProfileURL, "https://") {
+	if len(publicProfileURL) == 0 || !c.isAllowedURL(publicProfileURL) {
 		err = fmt.Errorf("invalid url: %s", publicProfileURL)
 		return
 	}
@@ -47,13 +67,21 @@ func (c *Client) GetPublicProfile(publicProfileURL, alias, domain string) (respo
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(publicProfileURL, BRFCPublicProfile); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/public-profile/{alias}@{domain.tld}
-	reqURL := replaceAliasDomain(publicProfileURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(publicProfileURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the GET request
 	var resp StandardResponse
-	if resp, err = c.getRequest(reqURL); err != nil {
+	if resp, err = c.getRequest(ctx, "public profile", reqURL); err != nil {
 		return
 	}
 
@@ -62,16 +90,23 @@ func (c *Client) GetPublicProfile(publicProfileURL, alias, domain string) (respo
 
 	// Test the status code (200 or 304 is valid)
 	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
-		serverError := &ServerError{}
-		if err = json.Unmarshal(resp.Body, serverError); err != nil {
-			return
-		}
-		err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
+		err = c.prepareServerErrorResponse(&resp)
 		return
 	}
 
 	// Decode the body of the response
-	err = json.Unmarshal(resp.Body, &response)
+	if err = json.Unmarshal(resp.Body, &response); err != nil {
+		return
+	}
+
+	// The avatar (if set) must be an absolute http(s) URL
+	if len(response.Avatar) > 0 {
+		avatarURL, parseErr := url.Parse(response.Avatar)
+		if parseErr != nil || !avatarURL.IsAbs() ||
+			(avatarURL.Scheme != "http" && avatarURL.Scheme != "https") || len(avatarURL.Host) == 0 {
+			err = fmt.Errorf("invalid avatar url: %s", response.Avatar)
+		}
+	}
 
 	return
 }
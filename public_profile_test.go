@@ -0,0 +1,26 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestFetchPublicProfile_CapabilityNotFound(t *testing.T) {
+	client, err := NewClient(WithResolver(&srvResolver{target: &net.SRV{Target: "api.test.com", Port: DefaultPort}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.(*Client).capabilities.set("api.test.com", DefaultPort, &CapabilitiesResponse{
+		CapabilitiesPayload: CapabilitiesPayload{
+			BsvAlias:     DefaultBsvAliasVersion,
+			Capabilities: map[string]interface{}{BRFCPki: "https://api.test.com/id/{alias}@{domain.tld}"},
+		},
+	})
+
+	// The public profile is not advertised by the paymail provider
+	if _, err = client.FetchPublicProfile(context.Background(), "alice", "test.com"); !errors.Is(err, ErrCapabilityNotFound) {
+		t.Fatalf("expected ErrCapabilityNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package paymail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Keys of the nested receiver approvals capability (BRFCReceiverApprovals)
+const (
+	ReceiverApprovalsApprove = "approve" // Approve (or reject) a pending transaction (receiver only, see the server)
+	ReceiverApprovalsStatus  = "status"  // Status of a sent transaction
+)
+
+/*
+Example:
+{
+  "reference": "someRefId",
+  "approved": true
+}
+*/
+
+// TransactionApprovalRequest is the request body to approve (or reject) a pending P2P transaction
+type TransactionApprovalRequest struct {
+	Approved  bool   `json:"approved"`  // True to accept the transaction, false to reject it
+	Reference string `json:"reference"` // Reference of the payment (from the P2P Destination request)
+}
+
+// P2PTransactionStatusResponse is the response to the status request
+type P2PTransactionStatusResponse struct {
+	StandardResponse
+	P2PTransactionPayload
+}
+
+// GetP2PTransactionStatus will return the status (accepted, pending or rejected) of a sent P2P transaction
+//
+// The statusURL is the receiver approvals status endpoint (from the capabilities), the reference is sent as a query param.
+//
+// Specs: http://bsvalias.org/04-03-receiver-approvals.html
+func (c *Client) GetP2PTransactionStatus(ctx context.Context, statusURL, alias, domain,
+	reference string) (response *P2PTransactionStatusResponse, err error) {
+
+	// Require a valid url
+	if len(statusURL) == 0 || !c.isAllowedURL(statusURL) {
+		err = fmt.Errorf("invalid url: %s", statusURL)
+		return
+	} else if len(alias) == 0 {
+		err = errors.New("missing alias")
+		return
+	} else if len(domain) == 0 {
+		err = errors.New("missing domain")
+		return
+	} else if len(reference) == 0 {
+		err = errors.New("reference is required")
+		return
+	}
+
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(statusURL, BRFCReceiverApprovals); err != nil {
+		return
+	}
+
+	// https://<host-discovery-target>/receiver-approvals/status/{alias}@{domain.tld}?reference=<reference>
+	var reqURL string
+	if reqURL, err = ResolveURL(statusURL, alias, domain); err != nil {
+		return
+	}
+	separator := "?"
+	if strings.Contains(reqURL, "?") {
+		separator = "&"
+	}
+	reqURL += separator + url.Values{"reference": []string{reference}}.Encode()
+
+	// Fire the GET request
+	var resp StandardResponse
+	if resp, err = c.getRequest(ctx, "p2p transaction status", reqURL); err != nil {
+		return
+	}
+
+	// Start the response
+	response = &P2PTransactionStatusResponse{StandardResponse: resp}
+
+	// Test the status code (200 or 304 is valid)
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
+		err = c.prepareServerErrorResponse(&resp)
+		return
+	}
+
+	// Decode the body of the response
+	if err = json.Unmarshal(resp.Body, &response); err != nil {
+		return
+	}
+
+	// Check for a status
+	if len(response.Status) == 0 {
+		err = errors.New("missing a returned status")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,46 @@
+package paymail
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Reference limits (see ValidateReference())
+const (
+	MaxReferenceLength = 128 // Maximum length of a P2P payment reference
+	MinReferenceLength = 1   // Minimum length of a P2P payment reference (see the server WithMinReferenceLength())
+)
+
+// referenceBytes is the number of random bytes of a generated reference (128 bits)
+const referenceBytes = 16
+
+// referenceLength is the length of a generated reference (16 bytes in base62)
+const referenceLength = 22
+
+// referenceRegExp is the (URL-safe) charset of a reference: letters, digits and -._~
+var referenceRegExp = regexp.MustCompile(`^[a-zA-Z0-9._~-]+$`)
+
+// GenerateReference will return a new random (16 bytes, base62 encoded) reference for a P2P payment destination
+//
+// The reference is URL-safe and always 22 characters long
+func GenerateReference() string {
+	randomBytes := make([]byte, referenceBytes)
+	_, _ = rand.Read(randomBytes)
+	reference := new(big.Int).SetBytes(randomBytes).Text(62)
+	return strings.Repeat("0", referenceLength-len(reference)) + reference
+}
+
+// ValidateReference will check the length and the (URL-safe) charset of a P2P payment reference
+//
+// It does not check that the reference was issued, only that it is not obviously invalid
+func ValidateReference(reference string) error {
+	if len(reference) < MinReferenceLength || len(reference) > MaxReferenceLength {
+		return fmt.Errorf("reference must be %d to %d characters long", MinReferenceLength, MaxReferenceLength)
+	} else if !referenceRegExp.MatchString(reference) {
+		return fmt.Errorf("reference contains invalid characters: %q", reference)
+	}
+	return nil
+}
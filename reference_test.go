@@ -0,0 +1,39 @@
+package paymail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateReference_Unique(t *testing.T) {
+	const iterations = 100_000
+	references := make(map[string]struct{}, iterations)
+	for i := 0; i < iterations; i++ {
+		reference := GenerateReference()
+		if len(reference) != referenceLength {
+			t.Fatalf("expected %d characters, got %q", referenceLength, reference)
+		} else if err := ValidateReference(reference); err != nil {
+			t.Fatalf("expected a valid reference, got %s", err)
+		}
+		if _, found := references[reference]; found {
+			t.Fatalf("duplicate reference after %d iterations: %s", i, reference)
+		}
+		references[reference] = struct{}{}
+	}
+}
+
+func TestValidateReference(t *testing.T) {
+	for reference, valid := range map[string]bool{
+		"1":                      true, // Short references issued by existing providers are accepted
+		"ref-1.a_b~c":            true,
+		"":                       false,
+		"ref 1":                  false,
+		"ref/1":                  false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+	} {
+		if err := ValidateReference(reference); (err == nil) != valid {
+			t.Errorf("%q: expected valid=%t, got %v", reference, valid, err)
+		}
+	}
+}
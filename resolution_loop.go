@@ -0,0 +1,39 @@
+package paymail
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrResolutionLoop is returned when a request is redirected in a loop (or too many times),
+// or when a capability of the paymail provider points back at its capability discovery
+var ErrResolutionLoop = errors.New("paymail resolution loop detected")
+
+// redirectPolicy will follow up to maxRedirects redirects, a redirect back to a visited url is a loop
+func redirectPolicy(maxRedirects int) resty.RedirectPolicy {
+	return resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+		for _, visited := range via {
+			if visited.URL.String() == req.URL.String() {
+				return fmt.Errorf("%w: redirected back to %s", ErrResolutionLoop, req.URL.Redacted())
+			}
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrResolutionLoop, maxRedirects)
+		}
+		return nil
+	})
+}
+
+// isDiscoveryURL will return true if the url is the capability discovery (/.well-known/bsvalias) of a host
+func isDiscoveryURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(parsedURL.Path), "/.well-known/"+DefaultServiceName)
+}
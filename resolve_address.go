@@ -1,11 +1,11 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/bsv-blockchain/go-sdk/script"
 )
@@ -23,13 +23,45 @@ type ResolutionPayload struct {
 	Signature string `json:"signature,omitempty"` // This is used if SenderValidation is enforced (signature of "output" value)
 }
 
+// DecodeAddress will decode the (P2PKH) output script and return its address
+//
+// Returns an error for non-standard (non P2PKH) scripts
+func (r *ResolutionPayload) DecodeAddress() (string, error) {
+	return decodeP2PKHAddress(r.Output)
+}
+
+// IsP2PKH will return true if the output is a standard P2PKH script
+func (r *ResolutionPayload) IsP2PKH() bool {
+	return isP2PKHScript(r.Output)
+}
+
+// decodeP2PKHAddress will return the address of a hex encoded P2PKH script
+func decodeP2PKHAddress(scriptHex string) (string, error) {
+	s, err := script.NewFromHex(scriptHex)
+	if err != nil {
+		return "", err
+	}
+
+	var address *script.Address
+	if address, err = s.Address(); err != nil {
+		return "", fmt.Errorf("non-standard output script: %w", err)
+	}
+	return address.AddressString, nil
+}
+
+// isP2PKHScript will return true if the hex encoded script is a standard P2PKH script
+func isP2PKHScript(scriptHex string) bool {
+	s, err := script.NewFromHex(scriptHex)
+	return err == nil && s.IsP2PKH()
+}
+
 // ResolveAddress will return a hex-encoded Bitcoin script if successful
 //
 // Specs: http://bsvalias.org/04-01-basic-address-resolution.html
-func (c *Client) ResolveAddress(resolutionURL, alias, domain string, senderRequest *SenderRequest) (response *ResolutionResponse, err error) {
+func (c *Client) ResolveAddress(ctx context.Context, resolutionURL, alias, domain string, senderRequest *SenderRequest) (response *ResolutionResponse, err error) {
 
 	// Require a valid url
-	if len(resolutionURL) == 0 || !strings.Contains(resolutionURL, "https://") {
+	if len(resolutionURL) == 0 || !c.isAllowedURL(resolutionURL) {
 		err = fmt.Errorf("invalid url: %s", resolutionURL)
 		return
 	}
@@ -55,13 +87,21 @@ func (c *Client) ResolveAddress(resolutionURL, alias, domain string, senderReque
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(resolutionURL, BRFCPaymentDestination, BRFCBasicAddressResolution); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/{alias}@{domain.tld}/payment-destination
-	reqURL := replaceAliasDomain(resolutionURL, alias, domain)
+	var reqURL string
+	if reqURL, err = ResolveURL(resolutionURL, alias, domain); err != nil {
+		return
+	}
 
 	// Fire the POST request
 	var resp StandardResponse
-	if resp, err = c.postRequest(reqURL, senderRequest); err != nil {
+	if resp, err = c.postRequest(ctx, "resolve address", reqURL, senderRequest); err != nil {
 		return
 	}
 
@@ -75,11 +115,7 @@ func (c *Client) ResolveAddress(resolutionURL, alias, domain string, senderReque
 		if response.StatusCode == http.StatusNotFound {
 			err = errors.New("paymail address not found")
 		} else {
-			serverError := &ServerError{}
-			if err = json.Unmarshal(resp.Body, serverError); err != nil {
-				return
-			}
-			err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
+			err = c.prepareServerErrorResponse(&resp)
 		}
 
 		return
@@ -0,0 +1,169 @@
+package paymail
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ResolveRequest is a single paymail address to resolve using ResolveAddresses()
+type ResolveRequest struct {
+	Handle        string         // Paymail address to resolve (alias@domain.tld)
+	SenderRequest *SenderRequest // Sender information sent to the paymail provider
+}
+
+// ResolveResult is the result of a single ResolveRequest
+type ResolveResult struct {
+	Address string // Legacy BSV address derived from the output script
+	Error   error  // Error resolving this handle (nil on success)
+	Handle  string // Original paymail address from the request
+	Output  string // hex-encoded Bitcoin script
+}
+
+// domainDiscovery is the (shared) address resolution URL lookup for a single domain
+type domainDiscovery struct {
+	err  error
+	once sync.Once
+	url  string
+}
+
+// ResolveAddresses will resolve multiple paymail addresses concurrently
+//
+// The results are in the same order as the requests. A failure for a single handle
+// is set on its result and does not fail the batch. Capability discovery is done once per domain.
+// The number of concurrent requests is set using WithResolveConcurrency()
+//
+// If the context is done, the results resolved so far are returned with the context error
+// (the handles that were not resolved have the context error as their result error)
+func (c *Client) ResolveAddresses(ctx context.Context, requests []ResolveRequest) ([]ResolveResult, error) {
+	results := make([]ResolveResult, len(requests))
+	discover := c.newDomainDiscoverer(ctx)
+	workers := c.resolveWorkers()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(requests); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = c.resolveBatchRequest(ctx, requests[index], discover)
+			}
+		}()
+	}
+
+	// Queue the requests (stop when the context is done)
+	queued := 0
+	for ; queued < len(requests) && ctx.Err() == nil; queued++ {
+		jobs <- queued
+	}
+	close(jobs)
+	wg.Wait()
+
+	err := ctx.Err()
+	for index := queued; index < len(requests); index++ {
+		results[index] = ResolveResult{Error: err, Handle: requests[index].Handle}
+	}
+	return results, err
+}
+
+// ResolveStream will resolve the paymail addresses from the channel concurrently, emitting the results as they complete
+//
+// The results are not in the same order as the handles. Closing the handles channel ends the stream (the results
+// channel is closed once all handles are resolved), cancelling the context stops the workers. Capability discovery
+// is done once per domain and the number of concurrent requests is set using WithResolveConcurrency()
+func (c *Client) ResolveStream(ctx context.Context, handles <-chan string) <-chan ResolveResult {
+	results := make(chan ResolveResult)
+	discover := c.newDomainDiscoverer(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.resolveWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var handle string
+				var ok bool
+				select {
+				case <-ctx.Done():
+					return
+				case handle, ok = <-handles:
+					if !ok {
+						return
+					}
+				}
+
+				result := c.resolveBatchRequest(ctx, ResolveRequest{Handle: handle}, discover)
+				select {
+				case <-ctx.Done():
+					return
+				case results <- result:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// newDomainDiscoverer will return a lookup of the address resolution URL, discovered once per domain
+func (c *Client) newDomainDiscoverer(ctx context.Context) func(domain string) (string, error) {
+	var mu sync.Mutex
+	discoveries := make(map[string]*domainDiscovery)
+	return func(domain string) (string, error) {
+		mu.Lock()
+		d, ok := discoveries[domain]
+		if !ok {
+			d = &domainDiscovery{}
+			discoveries[domain] = d
+		}
+		mu.Unlock()
+
+		d.once.Do(func() {
+			d.url, d.err = c.discoverCapabilityURL(ctx, domain, BRFCPaymentDestination, BRFCBasicAddressResolution)
+		})
+		return d.url, d.err
+	}
+}
+
+// resolveWorkers will return the number of concurrent resolutions (at least 1)
+func (c *Client) resolveWorkers() int {
+	if c.options.resolveConcurrency <= 0 {
+		return 1
+	}
+	return c.options.resolveConcurrency
+}
+
+// resolveBatchRequest will resolve a single request of the batch
+func (c *Client) resolveBatchRequest(ctx context.Context, request ResolveRequest,
+	discover func(domain string) (string, error)) (result ResolveResult) {
+
+	result.Handle = request.Handle
+
+	alias, domain, address := SanitizePaymail(request.Handle)
+	if len(address) == 0 {
+		result.Error = fmt.Errorf("invalid paymail address: %s", request.Handle)
+		return
+	}
+
+	resolutionURL, err := discover(domain)
+	if err != nil {
+		result.Error = err
+		return
+	}
+
+	var response *ResolutionResponse
+	if response, err = c.ResolveAddress(ctx, resolutionURL, alias, domain, request.SenderRequest); err != nil {
+		result.Error = err
+		return
+	}
+
+	result.Address = response.Address
+	result.Output = response.Output
+	return
+}
@@ -0,0 +1,75 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// srvResolver answers the SRV lookups with the target
+type srvResolver struct {
+	net.Resolver
+	target *net.SRV
+}
+
+func (r *srvResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return "_" + service + "._" + proto + "." + name + ".", []*net.SRV{r.target}, nil
+}
+
+func TestResolveAddresses_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first address is resolved, the context is cancelled during the second resolution
+	var resolutions atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if resolutions.Add(1) > 1 {
+			cancel()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"output":"` + testP2PKHScript + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	host, srvPort := addr.IP.String(), uint16(addr.Port)
+	client, err := NewClient(WithInsecureHTTP(true), WithResolveConcurrency(1),
+		WithResolver(&srvResolver{target: &net.SRV{Target: host, Port: srvPort}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.(*Client).capabilities.set(host, int(srvPort), &CapabilitiesResponse{
+		CapabilitiesPayload: CapabilitiesPayload{
+			BsvAlias:     DefaultBsvAliasVersion,
+			Capabilities: map[string]interface{}{BRFCPaymentDestination: srv.URL + "/{alias}@{domain.tld}"},
+		},
+	})
+
+	senderRequest := &SenderRequest{Dt: time.Now().UTC().Format(time.RFC3339), SenderHandle: "bob@test.com"}
+	requests := []ResolveRequest{
+		{Handle: "alice@test.com", SenderRequest: senderRequest},
+		{Handle: "carol@test.com", SenderRequest: senderRequest},
+		{Handle: "dave@test.com", SenderRequest: senderRequest},
+	}
+	results, err := client.ResolveAddresses(ctx, requests)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("expected %d results, got %d", len(requests), len(results))
+	}
+	if results[0].Error != nil || results[0].Output != testP2PKHScript {
+		t.Errorf("expected the resolved address, got %+v", results[0])
+	}
+	for _, result := range results[1:] {
+		if result.Error == nil || len(result.Handle) == 0 {
+			t.Errorf("expected an error with the handle, got %+v", result)
+		}
+	}
+}
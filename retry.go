@@ -0,0 +1,51 @@
+package paymail
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// defaultRetryMaxDelay is the maximum wait between retries (also caps Retry-After)
+const defaultRetryMaxDelay = 30 * time.Second
+
+// isRetryableStatus will return true for the status codes that are retried (429 and 5xx)
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// retryCondition will only retry idempotent (GET) requests on network errors, 429 or 5xx (a resolution loop is not retried)
+func retryCondition(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil || resp.Request.Method != http.MethodGet {
+		return false
+	} else if err != nil {
+		return !errors.Is(err, ErrResolutionLoop)
+	}
+	return isRetryableStatus(resp.StatusCode())
+}
+
+// retryOnError will retry the requests on errors (the default policy), a resolution loop is not retried
+func retryOnError(_ *resty.Response, err error) bool {
+	return err != nil && !errors.Is(err, ErrResolutionLoop)
+}
+
+// retryAfter will return the wait time from the Retry-After header (0 uses the exponential backoff)
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	value := resp.Header().Get("Retry-After")
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, nil
+		}
+	}
+	return 0, nil
+}
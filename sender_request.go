@@ -2,9 +2,11 @@ package paymail
 
 import (
 	"fmt"
+	"time"
 
 	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
 	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
 )
 
 /*
@@ -29,6 +31,9 @@ type SenderRequest struct {
 	SenderHandle string `json:"senderHandle"`         // (required) Sender's paymail handle
 	SenderName   string `json:"senderName,omitempty"` // Human-readable sender display name
 	Signature    string `json:"signature,omitempty"`  // Compact Bitcoin message signature; http://bsvalias.org/04-01-basic-address-resolution.html#signature-field
+
+	// SignatureScheme is the scheme of the signature (empty is the legacy Bitcoin signed message)
+	SignatureScheme string `json:"signatureScheme,omitempty"`
 }
 
 // Verify will verify the given components in the ResolveAddress() request
@@ -43,13 +48,8 @@ func (s *SenderRequest) Verify(keyAddress string, signature string) error {
 		return fmt.Errorf("missing a signature to verify")
 	}
 
-	decodedSig, err := DecodeSignature(signature)
-	if err != nil {
-		return err
-	}
-
-	// Concatenate & verify the message
-	return bsm.VerifyMessage(keyAddress, decodedSig, prepareMessage(s))
+	// Concatenate & verify the message (using the scheme of the request)
+	return VerifySignature(s.SignatureScheme, keyAddress, signature, prepareMessage(s))
 }
 
 // Sign will sign the given components in the ResolveAddress() request
@@ -79,6 +79,45 @@ func (s *SenderRequest) Sign(privateKey string) ([]byte, error) {
 	)
 }
 
+// SetSignature will sign the request and set the (base64 encoded) Signature field
+//
+// If Dt is empty it is set to the current time, otherwise it must be an RFC3339 timestamp.
+// An empty amount is signed as 0.
+func (s *SenderRequest) SetSignature(privateKey string) error {
+	if len(s.Dt) == 0 {
+		s.Dt = time.Now().UTC().Format(time.RFC3339)
+	} else if _, err := time.Parse(time.RFC3339, s.Dt); err != nil {
+		return fmt.Errorf("dt is not a valid RFC3339 timestamp: %w", err)
+	}
+
+	sigBytes, err := s.Sign(privateKey)
+	if err != nil {
+		return err
+	}
+
+	s.Signature = EncodeSignature(sigBytes)
+	return nil
+}
+
+// VerifySignature will verify the Signature field using the sender's (hex encoded) PubKey
+func (s *SenderRequest) VerifySignature(pubKey string) error {
+	if len(pubKey) == 0 {
+		return fmt.Errorf("missing pubkey")
+	}
+
+	address, err := script.NewAddressFromPublicKeyString(pubKey, true)
+	if err != nil {
+		return err
+	}
+
+	return s.Verify(address.AddressString, s.Signature)
+}
+
+// Message will return the signed message of the request (senderHandle, amount, dt and purpose)
+func (s *SenderRequest) Message() []byte {
+	return prepareMessage(s)
+}
+
 func prepareMessage(senderRequest *SenderRequest) []byte {
 	return fmt.Appendf(nil, "%s%d%s%s", senderRequest.SenderHandle, senderRequest.Amount, senderRequest.Dt, senderRequest.Purpose)
 }
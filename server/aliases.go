@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// aliasSet will return the set of the sanitized (lowercase) aliases
+func aliasSet(aliases []string) map[string]struct{} {
+	if len(aliases) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(aliases))
+	for _, alias := range aliases {
+		if alias = paymail.SanitizeEmail(alias); len(alias) > 0 {
+			set[alias] = struct{}{}
+		}
+	}
+	return set
+}
+
+// isBlockedAlias will return true if the (sanitized) alias is blocked
+func (c *Configuration) isBlockedAlias(alias string) bool {
+	_, ok := c.blockedAliases[alias]
+	return ok
+}
+
+// isReservedAlias will return true if the (sanitized) alias is reserved
+func (c *Configuration) isReservedAlias(alias string) bool {
+	_, ok := c.reservedAliases[alias]
+	return ok
+}
+
+// aliasActions will return the service provider for the (sanitized) alias and domain
+//
+// Reserved aliases are routed to the reserved alias service (if registered), the other aliases
+// to the service provider of the domain (see DomainConfig)
+func (c *Configuration) aliasActions(alias, domain string) PaymailServiceProvider {
+	if c.reservedActions != nil && c.isReservedAlias(alias) {
+		return c.reservedActions
+	}
+	return c.domainActions(domain)
+}
+
+// aliasesMiddleware will reject the requests for blocked aliases (and reserved aliases without a service)
+// with ErrCouldNotFindPaymail, so they are never resolved from the data layer
+func (c *Configuration) aliasesMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		alias, _, _ := paymail.SanitizePaymail(ctx.Param(PaymailAddressParamName))
+		if c.isBlockedAlias(alias) || (c.reservedActions == nil && c.isReservedAlias(alias)) {
+			errors.ErrorResponse(ctx, errors.ErrCouldNotFindPaymail, c.Logger)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
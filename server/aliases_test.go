@@ -0,0 +1,64 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/AmanTrance/go-paymail/server/mockactions"
+)
+
+func getPKI(handler http.Handler, address string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/bsvalias/id/"+address, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBlockedAliases(t *testing.T) {
+	// The blocked alias is present in the data layer
+	handler := newTestHandler(t, newTestProvider(), server.WithBlockedAliases("ALICE"))
+
+	for _, address := range []string{"alice@" + testDomain, "Alice@" + testDomain, "ALICE@" + testDomain} {
+		rec := getPKI(handler, address)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-paymail-not-found") {
+			t.Errorf("%q: expected the blocked alias to be not found, got %d: %s", address, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestReservedAliases(t *testing.T) {
+	provider := mockactions.New(
+		&paymail.AddressInformation{Alias: "admin", Domain: testDomain, PubKey: testPubKey},
+		&paymail.AddressInformation{Alias: testAlias, Domain: testDomain, PubKey: testPubKey},
+	)
+
+	t.Run("without a reserved alias service", func(t *testing.T) {
+		handler := newTestHandler(t, provider, server.WithReservedAliases("Admin"))
+		if rec := getPKI(handler, "admin@"+testDomain); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected the reserved alias to be not found, got %d", rec.Code)
+		}
+		if rec := getPKI(handler, testAlias+"@"+testDomain); rec.Code != http.StatusOK {
+			t.Errorf("expected the other aliases to be resolved, got %d", rec.Code)
+		}
+	})
+
+	t.Run("with a reserved alias service", func(t *testing.T) {
+		reserved := mockactions.New(&paymail.AddressInformation{Alias: "admin", Domain: testDomain, PubKey: testPubKey})
+		locator := &server.PaymailServiceLocator{}
+		locator.RegisterPaymailService(mockactions.New())
+		locator.RegisterReservedAliasService(reserved)
+		config, err := server.NewConfig(locator, server.WithDomain(testDomain), server.WithReservedAliases("admin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The reserved alias is only resolved from the reserved alias service
+		if rec := getPKI(server.Handlers(config), "ADMIN@"+testDomain); rec.Code != http.StatusOK {
+			t.Errorf("expected the reserved alias to be resolved, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
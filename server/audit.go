@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Audit event types
+const (
+	AuditEventAddressResolved     = "address_resolved"
+	AuditEventDestinationIssued   = "destination_issued"
+	AuditEventTransactionRecorded = "transaction_recorded"
+)
+
+// AuditEvent is a single entry of the audit trail
+type AuditEvent struct {
+	Alias     string    `json:"alias"`               // Alias of the receiving paymail
+	Domain    string    `json:"domain"`              // Domain of the receiving paymail
+	IPAddress string    `json:"ip_address"`          // IP address of the requesting client
+	Reference string    `json:"reference,omitempty"` // Reference of the P2P payment destination
+	Satoshis  uint64    `json:"satoshis,omitempty"`  // Amount (requested or paid)
+	Timestamp time.Time `json:"timestamp"`           // Time of the event (from the configured Clock)
+	TxID      string    `json:"txid,omitempty"`      // The txid of the recorded transaction
+	Type      string    `json:"type"`                // Type of the event (AuditEventAddressResolved, ...)
+}
+
+// AuditSink receives the audit events of the server (resolutions, issued destinations and recorded transactions)
+//
+// Events are recorded synchronously (in the request), a slow sink should buffer the events
+type AuditSink interface {
+	RecordEvent(ctx context.Context, event AuditEvent)
+}
+
+// NopAuditSink discards all the events (default)
+type NopAuditSink struct{}
+
+// RecordEvent will discard the event
+func (NopAuditSink) RecordEvent(context.Context, AuditEvent) {}
+
+// JSONAuditSink writes every event as a line of JSON (safe for concurrent use)
+type JSONAuditSink struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewJSONAuditSink will create an AuditSink that writes the events as JSON lines to the writer
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{encoder: json.NewEncoder(w)}
+}
+
+// RecordEvent will write the event as a line of JSON (write errors are ignored)
+func (s *JSONAuditSink) RecordEvent(_ context.Context, event AuditEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.encoder.Encode(event)
+}
+
+// recordAuditEvent will set the client IP address and timestamp of the event and record it
+func (c *Configuration) recordAuditEvent(ctx context.Context, md *RequestMetadata, event AuditEvent) {
+	if c.auditSink == nil {
+		return
+	}
+	if md != nil {
+		event.IPAddress = md.IPAddress
+	}
+	event.Timestamp = c.now().UTC()
+	c.auditSink.RecordEvent(ctx, event)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +16,38 @@ func index(c *gin.Context) {
 	c.JSON(http.StatusOK, responseData)
 }
 
-// health is a basic request to return a health response
-func health(c *gin.Context) {
-	c.Status(http.StatusOK)
+// health will return the health response (used for load balancers)
+//
+// The readiness check (HealthReadyParamName) also checks the data layer if the actions implement HealthChecker
+func (c *Configuration) health(context *gin.Context) {
+	if context.Request.Method == http.MethodHead {
+		context.Status(http.StatusOK)
+		return
+	}
+
+	response := &HealthResponse{
+		Actions:     c.actions != nil,
+		Broadcaster: c.broadcaster != nil,
+		BsvAlias:    c.BSVAliasVersion,
+		Status:      HealthStatusOK,
+	}
+
+	if ready, _ := strconv.ParseBool(context.Query(HealthReadyParamName)); ready {
+		isReady := response.Actions
+		if checker, ok := c.actions.(HealthChecker); ok && isReady {
+			if err := checker.HealthCheck(context.Request.Context()); err != nil {
+				c.Logger.Warn().Err(err).Msg("readiness check failed")
+				response.Error = err.Error()
+				isReady = false
+			}
+		}
+		response.Ready = &isReady
+		if !isReady {
+			response.Status = HealthStatusUnavailable
+			context.JSON(http.StatusServiceUnavailable, response)
+			return
+		}
+	}
+
+	context.JSON(http.StatusOK, response)
 }
@@ -23,64 +23,23 @@ type CallableCapabilitiesMap map[string]CallableCapability
 type StaticCapabilitiesMap map[string]any
 
 func (c *Configuration) SetGenericCapabilities() {
-	_addCapabilities(c.callableCapabilities,
-		CallableCapabilitiesMap{
-			paymail.BRFCPaymentDestination: CallableCapability{
-				Path:    fmt.Sprintf("/address/%s", PaymailAddressTemplate),
-				Method:  http.MethodPost,
-				Handler: c.resolveAddress,
-			},
-			paymail.BRFCPki: CallableCapability{
-				Path:    fmt.Sprintf("/id/%s", PaymailAddressTemplate),
-				Method:  http.MethodGet,
-				Handler: c.showPKI,
-			},
-			paymail.BRFCPublicProfile: CallableCapability{
-				Path:    fmt.Sprintf("/public-profile/%s", PaymailAddressTemplate),
-				Method:  http.MethodGet,
-				Handler: c.publicProfile,
-			},
-			paymail.BRFCVerifyPublicKeyOwner: CallableCapability{
-				Path:    fmt.Sprintf("/verify-pubkey/%s/%s", PaymailAddressTemplate, PubKeyTemplate),
-				Method:  http.MethodGet,
-				Handler: c.verifyPubKey,
-			},
-		},
-	)
-	_addCapabilities(c.staticCapabilities,
-		StaticCapabilitiesMap{
-			paymail.BRFCSenderValidation: c.SenderValidationEnabled,
-		},
-	)
+	c.enableCapabilities(CapabilityPaymentDestination, CapabilityPKI, CapabilityVerifyPubKey)
 }
 
 func (c *Configuration) SetP2PCapabilities() {
-	_addCapabilities(c.callableCapabilities,
-		CallableCapabilitiesMap{
-			paymail.BRFCP2PTransactions: CallableCapability{
-				Path:    fmt.Sprintf("/receive-transaction/%s", PaymailAddressTemplate),
-				Method:  http.MethodPost,
-				Handler: c.p2pReceiveTx,
-			},
-			paymail.BRFCP2PPaymentDestination: CallableCapability{
-				Path:    fmt.Sprintf("/p2p-payment-destination/%s", PaymailAddressTemplate),
-				Method:  http.MethodPost,
-				Handler: c.p2pDestination,
-			},
-		},
-	)
+	c.enableCapabilities(CapabilityP2PTransaction, CapabilityP2PPaymentDestination)
 }
 
 func (c *Configuration) SetBeefCapabilities() {
-	_addCapabilities(c.callableCapabilities,
-		CallableCapabilitiesMap{
-			paymail.BRFCBeefTransaction: CallableCapability{
-				Path:    fmt.Sprintf("/beef/%s", PaymailAddressTemplate),
-				Method:  http.MethodPost,
-				Handler: c.p2pReceiveBeefTx,
-			},
-		},
-	)
+	c.enableCapabilities(CapabilityBeef)
+}
+
+func (c *Configuration) SetCurrentAddressCapabilities() {
+	c.enableCapabilities(CapabilityCurrentAddress)
+}
+
+func (c *Configuration) SetPublicProfileCapabilities() {
+	c.enableCapabilities(CapabilityPublicProfile)
 }
 
 func (c *Configuration) SetPikeContactCapabilities() {
@@ -111,6 +70,28 @@ func (c *Configuration) SetPikePaymentCapabilities() {
 	)
 }
 
+func (c *Configuration) SetReceiverApprovalsCapabilities() {
+	c.enableCapabilities(CapabilityReceiverApprovals)
+}
+
+// setCapability will set the callable (served & advertised) or static (advertised) capability
+//
+// The key is removed from the other capability maps, so a capability is never served but advertised differently
+func (c *Configuration) setCapability(key string, cap any) {
+	delete(c.callableCapabilities, key)
+	delete(c.staticCapabilities, key)
+	delete(c.nestedCapabilities, key)
+
+	switch typedCap := cap.(type) {
+	case CallableCapability:
+		c.callableCapabilities[key] = typedCap
+	case CallableCapabilitiesMap:
+		c.nestedCapabilities[key] = typedCap
+	default:
+		c.staticCapabilities[key] = typedCap
+	}
+}
+
 func _addCapabilities[T any](base map[string]T, newCaps map[string]T) {
 	for key, val := range newCaps {
 		base[key] = val
@@ -134,12 +115,13 @@ func (c *Configuration) showCapabilities(context *gin.Context) {
 	// Check the host (allowed, and used for capabilities response)
 	// todo: bake this into middleware? This is protecting the "req" host name (like CORs)
 
-	if !c.IsAllowedDomain(c.Domain) {
+	domain := c.capabilitiesDomain(context)
+	if !c.IsAllowedDomain(domain) {
 		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
 		return
 	}
 
-	capabilities, err := c.EnrichCapabilities(c.Domain)
+	capabilities, err := c.EnrichCapabilities(domain)
 	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
@@ -149,8 +131,15 @@ func (c *Configuration) showCapabilities(context *gin.Context) {
 }
 
 // EnrichCapabilities will update the capabilities with the appropriate service url
+//
+// If an external service url is set (WithServiceURL), it is used instead of the prefix and host
 func (c *Configuration) EnrichCapabilities(host string) (*paymail.CapabilitiesPayload, error) {
-	serviceUrl, err := generateServiceURL(c.Prefix, host, c.APIVersion, c.ServiceName)
+	prefix := c.Prefix
+	if c.serviceURL != nil {
+		prefix = c.serviceURL.Scheme + "://"
+		host = c.serviceURL.Host + c.serviceURL.Path
+	}
+	serviceUrl, err := generateServiceURL(prefix, host, c.APIVersion, c.ServiceName)
 	if err != nil {
 		return nil, err
 	}
@@ -162,9 +151,14 @@ func (c *Configuration) EnrichCapabilities(host string) (*paymail.CapabilitiesPa
 		payload.Capabilities[key] = cap
 	}
 	for key, cap := range c.callableCapabilities {
-		payload.Capabilities[key] = serviceUrl + string(cap.Path)
+		if c.isCapabilityAllowed(host, key) {
+			payload.Capabilities[key] = serviceUrl + string(cap.Path)
+		}
 	}
 	for key, cap := range c.nestedCapabilities {
+		if !c.isCapabilityAllowed(host, key) {
+			continue
+		}
 		payload.Capabilities[key] = make(map[string]interface{})
 		for nestedKey, nestedCap := range cap {
 			nestedObj, ok := payload.Capabilities[key].(map[string]interface{})
@@ -174,6 +168,9 @@ func (c *Configuration) EnrichCapabilities(host string) (*paymail.CapabilitiesPa
 			nestedObj[nestedKey] = serviceUrl + nestedCap.Path
 		}
 	}
+	if _, ok := payload.Capabilities[paymail.BRFCSenderValidation]; ok {
+		payload.Capabilities[paymail.BRFCSenderValidation] = c.senderValidationEnabled(host)
+	}
 	return payload, nil
 }
 
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Capability names of the CapabilitiesBuilder
+const (
+	CapabilityBeef                  = "beef"
+	CapabilityCurrentAddress        = "currentAddress"
+	CapabilityP2PPaymentDestination = "p2pPaymentDestination"
+	CapabilityP2PTransaction        = "p2pTransaction"
+	CapabilityPaymentDestination    = "paymentDestination"
+	CapabilityPKI                   = "pki"
+	CapabilityPublicProfile         = "publicProfile"
+	CapabilityReceiverApprovals     = "receiverApprovals"
+	CapabilityVerifyPubKey          = "verifypubkey"
+)
+
+// builderRoute is a route of a capability (the handler is bound to the configuration)
+type builderRoute struct {
+	handler func(c *Configuration) gin.HandlerFunc
+	key     string // Key in the nested capability (empty for a callable capability)
+	method  string
+	path    string
+}
+
+// builderCapability is the BRFC ID and the routes of a capability
+type builderCapability struct {
+	brfcID           string
+	routes           []builderRoute
+	senderValidation bool // The sender validation (static) capability is also advertised
+}
+
+// builderCapabilities are the capabilities by name, the routes and the advertised document are
+// both generated from this list (see Configuration.enableCapabilities)
+var builderCapabilities = map[string]builderCapability{
+	CapabilityBeef: {brfcID: paymail.BRFCBeefTransaction, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.p2pReceiveBeefTx },
+		method:  http.MethodPost,
+		path:    "/beef/" + PaymailAddressTemplate,
+	}}},
+	CapabilityCurrentAddress: {brfcID: paymail.BRFCCurrentAddress, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.currentAddress },
+		method:  http.MethodGet,
+		path:    "/current-address/" + PaymailAddressTemplate,
+	}}},
+	CapabilityP2PPaymentDestination: {brfcID: paymail.BRFCP2PPaymentDestination, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.p2pDestination },
+		method:  http.MethodPost,
+		path:    "/p2p-payment-destination/" + PaymailAddressTemplate,
+	}}},
+	CapabilityP2PTransaction: {brfcID: paymail.BRFCP2PTransactions, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.p2pReceiveTx },
+		method:  http.MethodPost,
+		path:    "/receive-transaction/" + PaymailAddressTemplate,
+	}}},
+	CapabilityPaymentDestination: {brfcID: paymail.BRFCPaymentDestination, senderValidation: true, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.resolveAddress },
+		method:  http.MethodPost,
+		path:    "/address/" + PaymailAddressTemplate,
+	}}},
+	CapabilityPKI: {brfcID: paymail.BRFCPki, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.showPKI },
+		method:  http.MethodGet,
+		path:    "/id/" + PaymailAddressTemplate,
+	}}},
+	CapabilityPublicProfile: {brfcID: paymail.BRFCPublicProfile, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.publicProfile },
+		method:  http.MethodGet,
+		path:    "/public-profile/" + PaymailAddressTemplate,
+	}}},
+	CapabilityReceiverApprovals: {brfcID: paymail.BRFCReceiverApprovals, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.approveTransaction },
+		key:     paymail.ReceiverApprovalsApprove,
+		method:  http.MethodPost,
+		path:    "/receiver-approvals/approve/" + PaymailAddressTemplate,
+	}, {
+		handler: func(c *Configuration) gin.HandlerFunc { return c.transactionStatus },
+		key:     paymail.ReceiverApprovalsStatus,
+		method:  http.MethodGet,
+		path:    "/receiver-approvals/status/" + PaymailAddressTemplate,
+	}}},
+	CapabilityVerifyPubKey: {brfcID: paymail.BRFCVerifyPublicKeyOwner, routes: []builderRoute{{
+		handler: func(c *Configuration) gin.HandlerFunc { return c.verifyPubKey },
+		method:  http.MethodGet,
+		path:    "/verify-pubkey/" + PaymailAddressTemplate + "/" + PubKeyTemplate,
+	}}},
+}
+
+// CapabilitiesBuilder registers the enabled capabilities (by name) of the server
+//
+// The same capabilities are served (routes) and advertised (capabilities document) when the
+// builder is used in the configuration (WithCapabilitiesBuilder)
+type CapabilitiesBuilder struct {
+	approvalAuthorizer ApprovalAuthorizer
+	bsvAliasVersion    string
+	err                error
+	names              []string
+	senderValidation   bool
+}
+
+// NewCapabilitiesBuilder will return a builder without capabilities
+func NewCapabilitiesBuilder() *CapabilitiesBuilder {
+	return &CapabilitiesBuilder{bsvAliasVersion: paymail.DefaultBsvAliasVersion}
+}
+
+// Enable will enable the capabilities (see the Capability names), an unknown name fails Build()
+func (b *CapabilitiesBuilder) Enable(names ...string) *CapabilitiesBuilder {
+	for _, name := range names {
+		if _, ok := builderCapabilities[name]; !ok {
+			b.err = errors.ErrCapabilityNameUnknown.WithDetails(name)
+			continue
+		}
+		if !slices.Contains(b.names, name) {
+			b.names = append(b.names, name)
+		}
+	}
+	return b
+}
+
+// BSVAliasVersion will overwrite the bsvalias version of the capabilities document
+func (b *CapabilitiesBuilder) BSVAliasVersion(version string) *CapabilitiesBuilder {
+	b.bsvAliasVersion = version
+	return b
+}
+
+// SenderValidation will advertise (and enforce) the sender validation (with the payment destination)
+func (b *CapabilitiesBuilder) SenderValidation(enabled bool) *CapabilitiesBuilder {
+	b.senderValidation = enabled
+	return b
+}
+
+// ApprovalAuthorizer will set the authorizer of the receiver approvals (required by CapabilityReceiverApprovals)
+func (b *CapabilitiesBuilder) ApprovalAuthorizer(authorizer ApprovalAuthorizer) *CapabilitiesBuilder {
+	b.approvalAuthorizer = authorizer
+	return b
+}
+
+// Build will return the capabilities document (BRFC ID => URL template) using the service url
+// (e.g. https://example.com/v1/bsvalias)
+func (b *CapabilitiesBuilder) Build(serviceURL string) (*paymail.CapabilitiesPayload, error) {
+	if b.err != nil {
+		return nil, b.err
+	} else if len(b.bsvAliasVersion) == 0 {
+		return nil, errors.ErrBsvAliasMissing
+	}
+
+	payload := &paymail.CapabilitiesPayload{
+		BsvAlias:     b.bsvAliasVersion,
+		Capabilities: make(map[string]interface{}),
+	}
+	for _, name := range b.names {
+		definition := builderCapabilities[name]
+		for _, route := range definition.routes {
+			if len(route.key) == 0 {
+				payload.Capabilities[definition.brfcID] = serviceURL + route.path
+				continue
+			}
+			nested, ok := payload.Capabilities[definition.brfcID].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				payload.Capabilities[definition.brfcID] = nested
+			}
+			nested[route.key] = serviceURL + route.path
+		}
+		if definition.senderValidation {
+			payload.Capabilities[paymail.BRFCSenderValidation] = b.senderValidation
+		}
+	}
+	return payload, nil
+}
+
+// apply will enable the capabilities in the configuration (replacing the Enabled flags of the builder capabilities)
+//
+// The capabilities that need a service (public profile, receiver approvals) set their Enabled flag,
+// so the service is loaded by NewConfig
+func (b *CapabilitiesBuilder) apply(c *Configuration) error {
+	if b.err != nil {
+		return b.err
+	}
+	c.GenericCapabilitiesEnabled = false
+	c.P2PCapabilitiesEnabled = false
+	c.BeefCapabilitiesEnabled = false
+	c.CurrentAddressCapabilitiesEnabled = false
+	c.PublicProfileCapabilitiesEnabled = false
+	c.ReceiverApprovalsCapabilitiesEnabled = false
+	c.BSVAliasVersion = b.bsvAliasVersion
+	c.SenderValidationEnabled = c.SenderValidationEnabled || b.senderValidation
+	for _, name := range b.names {
+		switch name {
+		case CapabilityPublicProfile:
+			c.PublicProfileCapabilitiesEnabled = true
+		case CapabilityReceiverApprovals:
+			c.ReceiverApprovalsCapabilitiesEnabled = true
+			if b.approvalAuthorizer != nil {
+				c.approvalAuthorizer = b.approvalAuthorizer
+			}
+		default:
+			c.enableCapabilities(name)
+		}
+	}
+	return nil
+}
+
+// enableCapabilities will add the callable (or nested) capabilities of the names
+func (c *Configuration) enableCapabilities(names ...string) {
+	for _, name := range names {
+		definition := builderCapabilities[name]
+		for _, route := range definition.routes {
+			capability := CallableCapability{Path: route.path, Method: route.method, Handler: route.handler(c)}
+			if len(route.key) == 0 {
+				c.callableCapabilities[definition.brfcID] = capability
+				continue
+			}
+			_addNestedCapabilities(c.nestedCapabilities, NestedCapabilitiesMap{
+				definition.brfcID: CallableCapabilitiesMap{route.key: capability},
+			})
+		}
+		if definition.senderValidation {
+			c.staticCapabilities[paymail.BRFCSenderValidation] = c.SenderValidationEnabled
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server_test
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/AmanTrance/go-paymail/server"
+)
+
+const testServiceURL = "https://" + testDomain + "/v1/bsvalias"
+
+func TestCapabilitiesBuilder_Build(t *testing.T) {
+	payload, err := server.NewCapabilitiesBuilder().
+		Enable(server.CapabilityPKI, server.CapabilityPaymentDestination, server.CapabilityReceiverApprovals).
+		SenderValidation(true).
+		Build(testServiceURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.BsvAlias != paymail.DefaultBsvAliasVersion {
+		t.Errorf("expected the default bsvalias version, got %s", payload.BsvAlias)
+	}
+	expected := map[string]interface{}{
+		paymail.BRFCPki:                testServiceURL + "/id/{alias}@{domain.tld}",
+		paymail.BRFCPaymentDestination: testServiceURL + "/address/{alias}@{domain.tld}",
+		paymail.BRFCSenderValidation:   true,
+		paymail.BRFCReceiverApprovals: map[string]interface{}{
+			paymail.ReceiverApprovalsApprove: testServiceURL + "/receiver-approvals/approve/{alias}@{domain.tld}",
+			paymail.ReceiverApprovalsStatus:  testServiceURL + "/receiver-approvals/status/{alias}@{domain.tld}",
+		},
+	}
+	if actual, _ := json.Marshal(payload.Capabilities); string(actual) != mustJSON(t, expected) {
+		t.Errorf("expected %s, got %s", mustJSON(t, expected), actual)
+	}
+}
+
+func TestCapabilitiesBuilder_UnknownName(t *testing.T) {
+	builder := server.NewCapabilitiesBuilder().Enable(server.CapabilityPKI, "unknown")
+	var spvErr errors.SPVError
+	if _, err := builder.Build(testServiceURL); !stderrors.As(err, &spvErr) || spvErr.Code != errors.CodeCapabilityNameUnknown {
+		t.Errorf("expected an unknown capability name, got %v", err)
+	}
+
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(newTestProvider())
+	if _, err := server.NewConfig(locator, server.WithDomain(testDomain), server.WithCapabilitiesBuilder(builder)); err == nil {
+		t.Error("expected the configuration to fail with an unknown capability name")
+	}
+}
+
+func TestCapabilitiesBuilder_ServedAndAdvertised(t *testing.T) {
+	builder := server.NewCapabilitiesBuilder().Enable(server.CapabilityPKI, server.CapabilityP2PPaymentDestination)
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(newTestProvider())
+	config, err := server.NewConfig(locator, server.WithDomain(testDomain), server.WithCapabilitiesBuilder(builder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := server.Handlers(config)
+	advertised, err := config.EnrichCapabilities(testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The advertised document is the document of the builder
+	built, err := builder.Build(strings.TrimSuffix(advertised.Capabilities[paymail.BRFCPki].(string), "/id/{alias}@{domain.tld}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mustJSON(t, advertised.Capabilities) != mustJSON(t, built.Capabilities) {
+		t.Errorf("expected %s, got %s", mustJSON(t, built.Capabilities), mustJSON(t, advertised.Capabilities))
+	}
+
+	// Only the enabled capabilities are served
+	if rec := getPKI(handler, testAlias+"@"+testDomain); rec.Code != http.StatusOK {
+		t.Errorf("expected the pki to be served, got %d", rec.Code)
+	}
+	if rec := requestDestination(handler, 1000); rec.Code != http.StatusOK {
+		t.Errorf("expected the p2p payment destination to be served, got %d", rec.Code)
+	}
+	if rec := receiveTransaction(handler, testSignedTx(t, testOutput{testOutputScript, 1000}), "ref"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the p2p transactions not to be served, got %d", rec.Code)
+	}
+}
+
+func mustJSON(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
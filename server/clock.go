@@ -0,0 +1,18 @@
+package server
+
+import "time"
+
+// Clock is the source of the current time used by the timestamp (dt) and expiry checks
+//
+// The real clock is used by default, a fake clock can be set using WithClock() for deterministic tests
+type Clock interface {
+	Now() time.Time
+}
+
+// now will return the current time of the configured clock
+func (c *Configuration) now() time.Time {
+	if c.clock != nil {
+		return c.clock.Now()
+	}
+	return time.Now()
+}
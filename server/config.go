@@ -1,50 +1,128 @@
 package server
 
 import (
+	"net"
+	"net/url"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/AmanTrance/go-paymail/spv"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/AmanTrance/go-paymail"
 )
 
 // Configuration paymail server configuration object
 type Configuration struct {
-	APIVersion                       string          `json:"api_version"`
-	BasicRoutes                      *basicRoutes    `json:"basic_routes"`
-	BSVAliasVersion                  string          `json:"bsv_alias_version"`
-	PaymailDomains                   []*Domain       `json:"paymail_domains"`
-	PaymailDomainsValidationDisabled bool            `json:"paymail_domains_validation_disabled"`
-	Port                             int             `json:"port"`
-	Prefix                           string          `json:"prefix"`
-	Domain                           string          `json:"domain"`
-	SenderValidationEnabled          bool            `json:"sender_validation_enabled"`
-	GenericCapabilitiesEnabled       bool            `json:"generic_capabilities_enabled"`
-	P2PCapabilitiesEnabled           bool            `json:"p2p_capabilities_enabled"`
-	BeefCapabilitiesEnabled          bool            `json:"beef_capabilities_enabled"`
-	PikeContactCapabilitiesEnabled   bool            `json:"pike_contact_capabilities_enabled"`
-	PikePaymentCapabilitiesEnabled   bool            `json:"pike_payment_capabilities_enabled"`
-	ServiceName                      string          `json:"service_name"`
-	Timeout                          time.Duration   `json:"timeout"`
-	Logger                           *zerolog.Logger `json:"logger"`
+	APIVersion                           string          `json:"api_version"`
+	BasicRoutes                          *basicRoutes    `json:"basic_routes"`
+	BSVAliasVersion                      string          `json:"bsv_alias_version"`
+	PaymailDomains                       []*Domain       `json:"paymail_domains"`
+	PaymailDomainsValidationDisabled     bool            `json:"paymail_domains_validation_disabled"`
+	Port                                 int             `json:"port"`
+	Prefix                               string          `json:"prefix"`
+	Domain                               string          `json:"domain"`
+	SenderValidationEnabled              bool            `json:"sender_validation_enabled"`
+	SenderPKIValidationEnabled           bool            `json:"sender_pki_validation_enabled"`
+	SignatureScheme                      string          `json:"signature_scheme"`
+	GenericCapabilitiesEnabled           bool            `json:"generic_capabilities_enabled"`
+	P2PCapabilitiesEnabled               bool            `json:"p2p_capabilities_enabled"`
+	BeefCapabilitiesEnabled              bool            `json:"beef_capabilities_enabled"`
+	BroadcastBeforeRecord                bool            `json:"broadcast_before_record"`
+	BroadcastCallbackURL                 string          `json:"broadcast_callback_url"`
+	CurrentAddressCapabilitiesEnabled    bool            `json:"current_address_capabilities_enabled"`
+	PikeContactCapabilitiesEnabled       bool            `json:"pike_contact_capabilities_enabled"`
+	PikePaymentCapabilitiesEnabled       bool            `json:"pike_payment_capabilities_enabled"`
+	PublicProfileCapabilitiesEnabled     bool            `json:"public_profile_capabilities_enabled"`
+	ReceiverApprovalsCapabilitiesEnabled bool            `json:"receiver_approvals_capabilities_enabled"`
+	ServiceName                          string          `json:"service_name"`
+	ServiceURL                           string          `json:"service_url"`
+	Timeout                              time.Duration   `json:"timeout"`
+	TimestampSkew                        time.Duration   `json:"timestamp_skew"`
+	ReferenceTTL                         time.Duration   `json:"reference_ttl"`
+	MaxTxSizeBytes                       int64           `json:"max_tx_size_bytes"`
+	MaxSatoshis                          uint64          `json:"max_satoshis"`
+	MaxNoteLength                        int             `json:"max_note_length"`
+	MinReferenceLength                   int             `json:"min_reference_length"`
+	ErrorStatusCodes                     map[string]int  `json:"error_status_codes"`
+	ErrorStatusCode                      int             `json:"error_status_code"`
+	TrustedProxies                       []string        `json:"trusted_proxies"`
+	CORSAllowedOrigins                   []string        `json:"cors_allowed_origins"`
+	BlockedAliases                       []string        `json:"blocked_aliases"`
+	ReservedAliases                      []string        `json:"reserved_aliases"`
+	SupportedScriptTypes                 []string        `json:"supported_script_types"`
+	OutputSplit                          *OutputSplit    `json:"output_split"`
+	SecurityHeaders                      SecurityHeaders `json:"security_headers"`
+	MerkleProofValidationDisabled        bool            `json:"merkle_proof_validation_disabled"`
+	Logger                               *zerolog.Logger `json:"logger"`
 
 	// private
 	actions              PaymailServiceProvider
+	auditSink            AuditSink
+	approvalActions      TransactionApprovalServiceProvider
+	approvalAuthorizer   ApprovalAuthorizer
+	reservedActions      PaymailServiceProvider
+	broadcaster          Broadcaster
+	clock                Clock
+	destinationSigner    Signer
 	pikeContactActions   PikeContactServiceProvider
 	pikePaymentActions   PikePaymentServiceProvider
+	profileActions       PublicProfileServiceProvider
 	nestedCapabilities   NestedCapabilitiesMap
 	callableCapabilities CallableCapabilitiesMap
 	staticCapabilities   StaticCapabilitiesMap
+	customCapabilities   map[string]any
+	capabilitiesBuilder  *CapabilitiesBuilder
+	customVerifier       MessageVerifier
+	domainConfigs        map[string]*DomainConfig
+	headerValidator      spv.HeaderValidator
+	keyStore             KeyStore
+	metadataEnricher     MetadataEnricher
+	trustedProxies       []*net.IPNet
+	blockedAliases       map[string]struct{}
+	reservedAliases      map[string]struct{}
+	metrics              *serverMetrics
+	metricsRegisterer    prometheus.Registerer
+	rateLimiter          *rateLimiter
+	recordSlots          *semaphore.Weighted
+	recordWait           time.Duration
+	serviceURL           *url.URL
 }
 
 // Domain is the Paymail Domain information
+//
+// The name can be a wildcard pattern (*.example.com) matching a single label
 type Domain struct {
 	Name string `json:"name"`
 }
 
+// wildcardPrefix is the prefix of a wildcard domain pattern
+const wildcardPrefix = "*."
+
+// isValid will return true if the domain is a plain domain or a single-label wildcard pattern
+func (d *Domain) isValid() bool {
+	return !strings.Contains(strings.TrimPrefix(d.Name, wildcardPrefix), "*")
+}
+
+// matches will return true if the (sanitized) domain matches the name or wildcard pattern
+func (d *Domain) matches(domain string) bool {
+	if strings.EqualFold(d.Name, domain) {
+		return true
+	}
+
+	base, isWildcard := strings.CutPrefix(d.Name, wildcardPrefix)
+	if !isWildcard || len(domain) <= len(base)+1 {
+		return false
+	}
+
+	label, suffix := domain[:len(domain)-len(base)-1], domain[len(domain)-len(base)-1:]
+	return strings.EqualFold(suffix, "."+base) && !strings.Contains(label, ".")
+}
+
 // Validate will check that the configuration meets a minimum requirement to run the server
 func (c *Configuration) Validate() error {
 
@@ -58,7 +136,12 @@ func (c *Configuration) Validate() error {
 		return errors.ErrPortMissing
 	}
 
-	// todo: validate the []domains
+	// Wildcards are only allowed as a single leading label
+	for _, d := range c.PaymailDomains {
+		if !d.isValid() {
+			return errors.ErrDomainPatternInvalid
+		}
+	}
 
 	// Sanitize and standardize the service name
 	c.ServiceName = paymail.SanitizePathName(c.ServiceName)
@@ -74,9 +157,91 @@ func (c *Configuration) Validate() error {
 		return errors.ErrCapabilitiesMissing
 	}
 
+	// The approve route is public, only the receiver (authorized) can approve a transaction
+	if c.ReceiverApprovalsCapabilitiesEnabled && c.approvalAuthorizer == nil {
+		return errors.ErrApprovalAuthorizerMissing
+	}
+
+	// Trusted proxies must be valid CIDRs (or IPs)
+	var err error
+	if c.trustedProxies, err = parseTrustedProxies(c.TrustedProxies); err != nil {
+		return errors.ErrTrustedProxyInvalid.WithDetails(err.Error())
+	}
+
+	// Standardize the blocked and reserved aliases (matched after sanitizing)
+	c.blockedAliases = aliasSet(c.BlockedAliases)
+	c.reservedAliases = aliasSet(c.ReservedAliases)
+
+	// The default signature scheme must be known
+	if !paymail.IsSupportedSignatureScheme(c.SignatureScheme) {
+		return errors.ErrUnsupportedSignatureScheme.WithDetails(c.SignatureScheme)
+	}
+
+	// The output split of the payment destinations must be a known strategy
+	if c.OutputSplit != nil {
+		if err = c.OutputSplit.validate(); err != nil {
+			return err
+		}
+	}
+
+	// The external service url must be an absolute http(s) url (without a query or fragment)
+	if len(c.ServiceURL) > 0 {
+		if c.serviceURL, err = parseServiceURL(c.ServiceURL); err != nil {
+			return err
+		}
+	}
+
+	// Error status overrides must be valid HTTP status codes
+	if c.ErrorStatusCode != 0 && !isValidStatusCode(c.ErrorStatusCode) {
+		return errors.ErrErrorStatusCodeInvalid
+	}
+	for _, statusCode := range c.ErrorStatusCodes {
+		if !isValidStatusCode(statusCode) {
+			return errors.ErrErrorStatusCodeInvalid
+		}
+	}
+
 	return nil
 }
 
+// signatureScheme will return the requested signature scheme (or the default scheme if not requested)
+func (c *Configuration) signatureScheme(requested string) (string, error) {
+	if len(requested) == 0 {
+		return c.SignatureScheme, nil
+	} else if !paymail.IsSupportedSignatureScheme(requested) {
+		return "", errors.ErrUnsupportedSignatureScheme.WithDetails(requested)
+	}
+	return requested, nil
+}
+
+// parseServiceURL will parse and standardize the external base url of the service
+func parseServiceURL(serviceURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(serviceURL))
+	if err != nil {
+		return nil, errors.ErrServiceURLInvalid.WithDetails(err.Error())
+	} else if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, errors.ErrServiceURLInvalid.WithDetails("url must be absolute (http or https): " + serviceURL)
+	} else if len(u.RawQuery) > 0 || len(u.Fragment) > 0 {
+		return nil, errors.ErrServiceURLInvalid.WithDetails("url cannot have a query or fragment: " + serviceURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// servicePath will return the path prefix of the external service url (empty if not set)
+func (c *Configuration) servicePath() string {
+	if c.serviceURL == nil {
+		return ""
+	}
+	return c.serviceURL.Path
+}
+
+// isValidStatusCode will return true if the status code is a valid HTTP status (100-599)
+func isValidStatusCode(statusCode int) bool {
+	return statusCode >= 100 && statusCode <= 599
+}
+
 // IsAllowedDomain will return true if it's an allowed paymail domain
 func (c *Configuration) IsAllowedDomain(domain string) bool {
 	if c.PaymailDomainsValidationDisabled {
@@ -90,7 +255,7 @@ func (c *Configuration) IsAllowedDomain(domain string) bool {
 	}
 
 	return slices.ContainsFunc(c.PaymailDomains, func(d *Domain) bool {
-		return strings.EqualFold(d.Name, domain)
+		return d.matches(domain)
 	})
 }
 
@@ -135,6 +300,13 @@ func NewConfig(serviceProvider *PaymailServiceLocator, opts ...ConfigOps) (*Conf
 		opt(config)
 	}
 
+	// The capabilities of the builder (if set) replace the Enabled flags of the builder capabilities
+	if config.capabilitiesBuilder != nil {
+		if err := config.capabilitiesBuilder.apply(config); err != nil {
+			return nil, err
+		}
+	}
+
 	if config.GenericCapabilitiesEnabled {
 		config.SetGenericCapabilities()
 	}
@@ -145,6 +317,10 @@ func NewConfig(serviceProvider *PaymailServiceLocator, opts ...ConfigOps) (*Conf
 		config.SetBeefCapabilities()
 	}
 
+	if config.CurrentAddressCapabilitiesEnabled {
+		config.SetCurrentAddressCapabilities()
+	}
+
 	if config.PikeContactCapabilitiesEnabled {
 		config.SetPikeContactCapabilities()
 		config.pikeContactActions = serviceProvider.GetPikeContactService()
@@ -155,13 +331,37 @@ func NewConfig(serviceProvider *PaymailServiceLocator, opts ...ConfigOps) (*Conf
 		config.pikePaymentActions = serviceProvider.GetPikePaymentService()
 	}
 
+	if config.PublicProfileCapabilitiesEnabled {
+		config.SetPublicProfileCapabilities()
+		config.profileActions = serviceProvider.GetPublicProfileService()
+	}
+
+	if config.ReceiverApprovalsCapabilitiesEnabled {
+		config.SetReceiverApprovalsCapabilities()
+		config.approvalActions = serviceProvider.GetTransactionApprovalService()
+	}
+
+	// Custom capabilities override the enabled capabilities
+	for key, cap := range config.customCapabilities {
+		config.setCapability(key, cap)
+	}
+
 	// Validate the configuration
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
+	// Register the metrics collectors (after validating, so a rejected configuration does not register them)
+	if config.metricsRegisterer != nil {
+		var err error
+		if config.metrics, err = newServerMetrics(config.metricsRegisterer); err != nil {
+			return nil, errors.ErrMetricsRegistration.WithDetails(err.Error())
+		}
+	}
+
 	// Set the service provider
 	config.actions = serviceProvider.GetPaymailService()
+	config.reservedActions = serviceProvider.reservedService
 
 	config.Logger.Debug().Msg("New config loaded")
 	return config, nil
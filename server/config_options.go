@@ -1,10 +1,14 @@
 package server
 
 import (
+	"strings"
 	"time"
 
 	"github.com/AmanTrance/go-paymail/logging"
+	"github.com/AmanTrance/go-paymail/spv"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/AmanTrance/go-paymail"
 )
@@ -18,24 +22,33 @@ type ConfigOps func(c *Configuration)
 // Useful for starting with the default and then modifying as needed
 func defaultConfigOptions() *Configuration {
 	return &Configuration{
-		APIVersion:                       DefaultAPIVersion,
-		BasicRoutes:                      &basicRoutes{},
-		BSVAliasVersion:                  paymail.DefaultBsvAliasVersion,
-		PaymailDomainsValidationDisabled: false,
-		Port:                             DefaultServerPort,
-		Prefix:                           DefaultPrefix,
-		SenderValidationEnabled:          DefaultSenderValidation,
-		GenericCapabilitiesEnabled:       true,
-		P2PCapabilitiesEnabled:           false,
-		BeefCapabilitiesEnabled:          false,
-		PikeContactCapabilitiesEnabled:   false,
-		PikePaymentCapabilitiesEnabled:   false,
-		ServiceName:                      paymail.DefaultServiceName,
-		Timeout:                          DefaultTimeout,
-		Logger:                           logging.GetDefaultLogger(),
-		nestedCapabilities:               make(NestedCapabilitiesMap),
-		callableCapabilities:             make(CallableCapabilitiesMap),
-		staticCapabilities:               make(StaticCapabilitiesMap),
+		APIVersion:                           DefaultAPIVersion,
+		BasicRoutes:                          &basicRoutes{},
+		BSVAliasVersion:                      paymail.DefaultBsvAliasVersion,
+		PaymailDomainsValidationDisabled:     false,
+		Port:                                 DefaultServerPort,
+		Prefix:                               DefaultPrefix,
+		SenderValidationEnabled:              DefaultSenderValidation,
+		GenericCapabilitiesEnabled:           true,
+		P2PCapabilitiesEnabled:               false,
+		BeefCapabilitiesEnabled:              false,
+		CurrentAddressCapabilitiesEnabled:    false,
+		PikeContactCapabilitiesEnabled:       false,
+		PikePaymentCapabilitiesEnabled:       false,
+		PublicProfileCapabilitiesEnabled:     false,
+		ReceiverApprovalsCapabilitiesEnabled: false,
+		ServiceName:                          paymail.DefaultServiceName,
+		Timeout:                              DefaultTimeout,
+		TimestampSkew:                        paymail.DefaultTimestampSkew,
+		ReferenceTTL:                         DefaultReferenceTTL,
+		MaxTxSizeBytes:                       DefaultMaxTxSizeBytes,
+		MaxNoteLength:                        DefaultMaxNoteLength,
+		Logger:                               logging.GetDefaultLogger(),
+		nestedCapabilities:                   make(NestedCapabilitiesMap),
+		callableCapabilities:                 make(CallableCapabilitiesMap),
+		staticCapabilities:                   make(StaticCapabilitiesMap),
+		customCapabilities:                   make(map[string]any),
+		auditSink:                            NopAuditSink{},
 	}
 }
 
@@ -61,6 +74,14 @@ func WithBeefCapabilities() ConfigOps {
 	}
 }
 
+// WithCurrentAddressCapabilities will load the current address capability (deposit address without an amount)
+// The PaymailServiceProvider can implement the CurrentAddressProvider
+func WithCurrentAddressCapabilities() ConfigOps {
+	return func(c *Configuration) {
+		c.CurrentAddressCapabilitiesEnabled = true
+	}
+}
+
 // WithPikeContactCapabilities will load the PIKE capabilities
 func WithPikeContactCapabilities() ConfigOps {
 	return func(c *Configuration) {
@@ -75,32 +96,153 @@ func WithPikePaymentCapabilities() ConfigOps {
 	}
 }
 
+// WithPublicProfileCapabilities will load the public profile capability
+// The PublicProfileServiceProvider has to be registered
+func WithPublicProfileCapabilities() ConfigOps {
+	return func(c *Configuration) {
+		c.PublicProfileCapabilitiesEnabled = true
+	}
+}
+
 // WithCapabilities will modify the capabilities
+//
+// Custom capabilities are applied after the enabled capabilities (overriding a capability with the same key)
 func WithCapabilities(customCapabilities map[string]any) ConfigOps {
 	return func(c *Configuration) {
 		for key, cap := range customCapabilities {
-			switch typedCap := cap.(type) {
-			case CallableCapability:
-				c.callableCapabilities[key] = typedCap
-			default:
-				c.staticCapabilities[key] = typedCap
-			}
+			c.customCapabilities[key] = cap
 		}
 	}
 }
 
+// WithCapabilitiesBuilder will enable the capabilities of the builder (served and advertised)
+//
+// The builder replaces the capabilities it knows (generic, p2p, beef, current address, public profile and
+// receiver approvals) enabled by the other options, the PIKE and custom capabilities are still applied
+func WithCapabilitiesBuilder(builder *CapabilitiesBuilder) ConfigOps {
+	return func(c *Configuration) {
+		c.capabilitiesBuilder = builder
+	}
+}
+
 // WithBasicRoutes will turn on all the basic routes
 func WithBasicRoutes() ConfigOps {
 	return func(c *Configuration) {
+		var healthPath string
+		if c.BasicRoutes != nil {
+			healthPath = c.BasicRoutes.HealthPath
+		}
 		c.BasicRoutes = &basicRoutes{
 			Add404Route:    true,
 			AddHealthRoute: true,
 			AddIndexRoute:  true,
 			AddNotAllowed:  true,
+			HealthPath:     healthPath,
+		}
+	}
+}
+
+// WithHealthPath will turn on the health route using a custom path
+// Default is DefaultHealthPath (/health)
+func WithHealthPath(path string) ConfigOps {
+	return func(c *Configuration) {
+		if c.BasicRoutes == nil {
+			c.BasicRoutes = &basicRoutes{}
+		}
+		c.BasicRoutes.AddHealthRoute = true
+		c.BasicRoutes.HealthPath = "/" + strings.TrimPrefix(path, "/")
+	}
+}
+
+// WithMetadataEnricher will set a hook to add information to the metadata of each request
+// e.g. a request ID, the auth subject or geo information (using RequestMetadata.Extra)
+func WithMetadataEnricher(enricher MetadataEnricher) ConfigOps {
+	return func(c *Configuration) {
+		c.metadataEnricher = enricher
+	}
+}
+
+// WithHeaderValidator will verify the merkle proofs of received BEEF transactions using the block header source
+// Default is the VerifyMerkleRoots() of the service provider
+func WithHeaderValidator(validator spv.HeaderValidator) ConfigOps {
+	return func(c *Configuration) {
+		c.headerValidator = validator
+	}
+}
+
+// WithoutMerkleProofValidation will accept BEEF transactions without verifying the merkle proofs
+// e.g. relying on the Broadcaster to reject invalid transactions
+func WithoutMerkleProofValidation() ConfigOps {
+	return func(c *Configuration) {
+		c.MerkleProofValidationDisabled = true
+	}
+}
+
+// WithScriptTypes will enable the (non P2PKH) script types for the P2P payment destination
+// This requires the actions to implement ScriptTypeDestinationProvider
+func WithScriptTypes(scriptTypes ...string) ConfigOps {
+	return func(c *Configuration) {
+		for _, scriptType := range scriptTypes {
+			c.SupportedScriptTypes = append(c.SupportedScriptTypes, strings.ToLower(strings.TrimSpace(scriptType)))
 		}
 	}
 }
 
+// WithTrustedProxies will trust the X-Forwarded-For and X-Real-IP headers from the proxies (CIDRs or IPs)
+// Default is no trusted proxies (the direct RemoteAddr is used)
+func WithTrustedProxies(cidrs ...string) ConfigOps {
+	return func(c *Configuration) {
+		c.TrustedProxies = append(c.TrustedProxies, cidrs...)
+	}
+}
+
+// WithSignatureScheme will set the default scheme of the sender signatures (used if the request does not set one)
+// Default is the legacy Bitcoin signed message (paymail.SignatureSchemeBitcoinMessage)
+func WithSignatureScheme(scheme string) ConfigOps {
+	return func(c *Configuration) {
+		c.SignatureScheme = scheme
+	}
+}
+
+// WithOutputSplit will split the amount of the P2P payment destinations into several outputs
+// The amounts are set on the metadata (RequestMetadata.OutputAmounts) for the service provider
+// Default is a single output
+func WithOutputSplit(split OutputSplit) ConfigOps {
+	return func(c *Configuration) {
+		c.OutputSplit = &split
+	}
+}
+
+// WithServiceURL will set the external base url of the service (e.g. https://example.com/paymail)
+// The capability urls are generated from it and the routes are registered under its path
+// (the service discovery route stays at /.well-known/bsvalias)
+func WithServiceURL(baseURL string) ConfigOps {
+	return func(c *Configuration) {
+		c.ServiceURL = baseURL
+	}
+}
+
+// WithReferenceTTL will set the time to submit the transaction of a P2P payment destination reference
+// A ttl of 0 disables the expiry, default is DefaultReferenceTTL
+//
+// The expiry is passed to the provider (RequestMetadata.ReferenceExpiresAt), it is only advertised and
+// enforced if the provider stores it (returns it with the destination and from ReferenceProvider.GetReference)
+func WithReferenceTTL(ttl time.Duration) ConfigOps {
+	return func(c *Configuration) {
+		if ttl >= 0 {
+			c.ReferenceTTL = ttl
+		}
+	}
+}
+
+// WithClock will set the clock used by the timestamp (dt) and expiry checks (used for testing)
+// Default is the real clock
+func WithClock(clock Clock) ConfigOps {
+	return func(c *Configuration) {
+		c.clock = clock
+	}
+}
+
 // WithTimeout will set a custom timeout
 func WithTimeout(timeout time.Duration) ConfigOps {
 	return func(c *Configuration) {
@@ -110,6 +252,96 @@ func WithTimeout(timeout time.Duration) ConfigOps {
 	}
 }
 
+// WithMaxTxSize will overwrite the max size (in bytes) of a received P2P transaction (hex or BEEF)
+// 0 disables the limit
+func WithMaxTxSize(maxBytes int64) ConfigOps {
+	return func(c *Configuration) {
+		c.MaxTxSizeBytes = maxBytes
+	}
+}
+
+// WithMaxSatoshis will set the max amount (in satoshis) of a payment destination request and of a received P2P transaction
+// 0 disables the limit (default)
+func WithMaxSatoshis(maxSatoshis uint64) ConfigOps {
+	return func(c *Configuration) {
+		c.MaxSatoshis = maxSatoshis
+	}
+}
+
+// WithMinReferenceLength will set the min length of a received P2P reference (longer than paymail.MinReferenceLength)
+// 0 only applies paymail.ValidateReference() (default, accepts the short references already issued by providers)
+func WithMinReferenceLength(minLength int) ConfigOps {
+	return func(c *Configuration) {
+		c.MinReferenceLength = minLength
+	}
+}
+
+// WithMaxNoteLength will overwrite the max length (in characters) of the note of a received P2P transaction
+// 0 disables the limit (the control characters are always removed)
+func WithMaxNoteLength(maxLength int) ConfigOps {
+	return func(c *Configuration) {
+		if maxLength >= 0 {
+			c.MaxNoteLength = maxLength
+		}
+	}
+}
+
+// WithErrorStatusCodes will overwrite the HTTP status returned for the given error codes
+// e.g. {"error-paymail-not-found": 200}, the other errors keep their status
+func WithErrorStatusCodes(statusCodes map[string]int) ConfigOps {
+	return func(c *Configuration) {
+		if c.ErrorStatusCodes == nil {
+			c.ErrorStatusCodes = make(map[string]int, len(statusCodes))
+		}
+		for code, statusCode := range statusCodes {
+			c.ErrorStatusCodes[code] = statusCode
+		}
+	}
+}
+
+// WithErrorStatusCode will overwrite the HTTP status returned for all errors (e.g. always 200)
+// Codes set using WithErrorStatusCodes() take precedence
+func WithErrorStatusCode(statusCode int) ConfigOps {
+	return func(c *Configuration) {
+		c.ErrorStatusCode = statusCode
+	}
+}
+
+// WithBSVAliasVersion will overwrite the bsvalias version of the capabilities document
+func WithBSVAliasVersion(version string) ConfigOps {
+	return func(c *Configuration) {
+		if len(version) > 0 {
+			c.BSVAliasVersion = version
+		}
+	}
+}
+
+// WithReceiverApprovalsCapabilities will enable the receiver approvals (pending P2P transactions and the approval routes)
+// This requires a TransactionApprovalServiceProvider, the authorizer (required) authenticates the approve requests
+func WithReceiverApprovalsCapabilities(authorizer ApprovalAuthorizer) ConfigOps {
+	return func(c *Configuration) {
+		c.ReceiverApprovalsCapabilitiesEnabled = true
+		c.approvalAuthorizer = authorizer
+	}
+}
+
+// WithSenderPKIValidation will check that the pubkey of a signed P2P transaction belongs to the sender
+// This requires a PKI request to the paymail provider of the sender
+func WithSenderPKIValidation() ConfigOps {
+	return func(c *Configuration) {
+		c.SenderPKIValidationEnabled = true
+	}
+}
+
+// WithTimestampSkew will set the allowed skew of the sender "dt" timestamp
+func WithTimestampSkew(skew time.Duration) ConfigOps {
+	return func(c *Configuration) {
+		if skew > 0 {
+			c.TimestampSkew = skew
+		}
+	}
+}
+
 // WithServiceName will set a custom service name
 func WithServiceName(serviceName string) ConfigOps {
 	return func(c *Configuration) {
@@ -136,6 +368,32 @@ func WithDomain(domain string) ConfigOps {
 	}
 }
 
+// WithDomainConfig will add the domain (or wildcard pattern) with its own configuration (service provider,
+// allowed capabilities, public profile provider and sender validation), the other domains use the defaults
+func WithDomainConfig(domain string, config DomainConfig) ConfigOps {
+	return func(c *Configuration) {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); len(domain) == 0 {
+			return
+		}
+		_ = c.AddDomain(domain)
+		if c.domainConfigs == nil {
+			c.domainConfigs = make(map[string]*DomainConfig)
+		}
+		c.domainConfigs[domain] = &config
+	}
+}
+
+// WithAllowedDomains will add the domains or wildcard patterns (*.example.com) if not found
+func WithAllowedDomains(patterns ...string) ConfigOps {
+	return func(c *Configuration) {
+		for _, pattern := range patterns {
+			if len(pattern) > 0 {
+				_ = c.AddDomain(pattern)
+			}
+		}
+	}
+}
+
 // WithPort will overwrite the default port
 func WithPort(port int) ConfigOps {
 	return func(c *Configuration) {
@@ -152,6 +410,127 @@ func WithDomainValidationDisabled() ConfigOps {
 	}
 }
 
+// WithAuditSink will record an audit event for every resolution, issued destination and recorded transaction
+//
+// Default is the NopAuditSink (no events), see NewJSONAuditSink()
+func WithAuditSink(sink AuditSink) ConfigOps {
+	return func(c *Configuration) {
+		if sink != nil {
+			c.auditSink = sink
+		}
+	}
+}
+
+// WithMessageVerifier will verify the sender and P2P transaction signatures using the verifier
+// (e.g. hardware-backed verification or a stub for testing), the signature scheme of the request is not used.
+// Default is the SchemeMessageVerifier of the signature scheme
+func WithMessageVerifier(verifier MessageVerifier) ConfigOps {
+	return func(c *Configuration) {
+		c.customVerifier = verifier
+	}
+}
+
+// WithDestinationSigner will sign the outputs and reference of every issued P2P payment destination
+//
+// The signature and the pubkey of the signer are set in the response (see PaymentDestinationPayload.VerifySignature())
+func WithDestinationSigner(signer Signer) ConfigOps {
+	return func(c *Configuration) {
+		c.destinationSigner = signer
+	}
+}
+
+// WithKeyStore will set the signing keys of the server by domain (e.g. the receiver attestation of the P2P payment destinations)
+//
+// The signer of the domain is used, the WithDestinationSigner() signer is used for the domains without a key
+func WithKeyStore(store KeyStore) ConfigOps {
+	return func(c *Configuration) {
+		c.keyStore = store
+	}
+}
+
+// WithBroadcaster will broadcast every received P2P transaction (after it was recorded)
+//
+// If beforeRecord is true, the transaction is broadcast first and not recorded if the broadcast failed
+func WithBroadcaster(broadcaster Broadcaster, beforeRecord bool) ConfigOps {
+	return func(c *Configuration) {
+		c.broadcaster = broadcaster
+		c.BroadcastBeforeRecord = beforeRecord
+	}
+}
+
+// WithBroadcastCallback will register the callback URL (e.g. ARC merkle proof delivery) on every broadcast
+func WithBroadcastCallback(callbackURL string) ConfigOps {
+	return func(c *Configuration) {
+		c.BroadcastCallbackURL = callbackURL
+	}
+}
+
+// WithMetrics will record Prometheus metrics (request count by outcome & latency) for every capability
+// The collectors are registered by NewConfig (a failed registration is returned as ErrMetricsRegistration)
+func WithMetrics(registerer prometheus.Registerer) ConfigOps {
+	return func(c *Configuration) {
+		c.metricsRegisterer = registerer
+	}
+}
+
+// WithMaxConcurrentRecords will limit the concurrent RecordTransaction calls of the received P2P transactions
+// A transaction waiting longer than the timeout for a slot returns ErrServerBusy (503).
+// A timeout of 0 uses DefaultRecordWait. Default is no limit
+func WithMaxConcurrentRecords(n int, timeout time.Duration) ConfigOps {
+	return func(c *Configuration) {
+		if n > 0 {
+			c.recordSlots = semaphore.NewWeighted(int64(n))
+			c.recordWait = timeout
+			if c.recordWait <= 0 {
+				c.recordWait = DefaultRecordWait
+			}
+		}
+	}
+}
+
+// WithRateLimit will limit the requests per minute (with a burst) for every remote IP and paymail domain
+func WithRateLimit(requestsPerMinute int, burst int) ConfigOps {
+	return func(c *Configuration) {
+		if requestsPerMinute > 0 {
+			c.rateLimiter = newRateLimiter(requestsPerMinute, burst)
+		}
+	}
+}
+
+// WithCORS will enable CORS for the allowed origins ("*" allows any origin)
+// Default is disabled (no Access-Control headers and no OPTIONS preflight routes)
+func WithCORS(allowedOrigins []string) ConfigOps {
+	return func(c *Configuration) {
+		c.CORSAllowedOrigins = append(c.CORSAllowedOrigins, allowedOrigins...)
+	}
+}
+
+// WithSecurityHeaders will set the security headers (HSTS, nosniff and CSP) on the capability responses
+// Use DefaultSecurityHeaders() for the defaults, an empty value is not set.
+// Default is disabled (e.g. a TLS-terminating proxy can set the headers)
+func WithSecurityHeaders(headers SecurityHeaders) ConfigOps {
+	return func(c *Configuration) {
+		c.SecurityHeaders = headers
+	}
+}
+
+// WithBlockedAliases will block the aliases (case-insensitive) on every domain
+// Requests for a blocked alias return ErrCouldNotFindPaymail, even if the alias exists in the data layer
+func WithBlockedAliases(aliases ...string) ConfigOps {
+	return func(c *Configuration) {
+		c.BlockedAliases = append(c.BlockedAliases, aliases...)
+	}
+}
+
+// WithReservedAliases will reserve the aliases (case-insensitive) on every domain
+// Reserved aliases are served by the reserved alias service (RegisterReservedAliasService),
+// if it is not registered the requests return ErrCouldNotFindPaymail
+func WithReservedAliases(aliases ...string) ConfigOps {
+	return func(c *Configuration) {
+		c.ReservedAliases = append(c.ReservedAliases, aliases...)
+	}
+}
+
 // WithLogger will set a custom logger
 func WithLogger(logger *zerolog.Logger) ConfigOps {
 	return func(c *Configuration) {
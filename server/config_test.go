@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/AmanTrance/go-paymail/server"
+)
+
+// newTestConfig will return the configuration of a server using the test provider
+func newTestConfig(t *testing.T, opts ...server.ConfigOps) (*server.Configuration, error) {
+	t.Helper()
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(newTestProvider())
+	return server.NewConfig(locator, opts...)
+}
+
+func TestIsAllowedDomain_Wildcard(t *testing.T) {
+	config, err := newTestConfig(t, server.WithAllowedDomains("*.example.com", "plain.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for domain, allowed := range map[string]bool{
+		"a.example.com":   true,
+		"A.Example.COM":   true,
+		"example.com":     false, // The wildcard needs a label
+		"a.b.example.com": false, // Single label only
+		"aexample.com":    false,
+		"plain.com":       true, // Plain domains match exactly
+		"a.plain.com":     false,
+	} {
+		if config.IsAllowedDomain(domain) != allowed {
+			t.Errorf("%s: expected allowed=%t", domain, allowed)
+		}
+	}
+}
+
+func TestIsAllowedDomain_NestedWildcard(t *testing.T) {
+	for _, pattern := range []string{"*.*.example.com", "a.*.example.com"} {
+		if _, err := newTestConfig(t, server.WithAllowedDomains(pattern)); !stderrors.Is(err, errors.ErrDomainPatternInvalid) {
+			t.Errorf("%s: expected an invalid pattern, got %v", pattern, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS defaults
+const (
+	corsAllowAllOrigins = "*"                                   // Allows any origin
+	corsAllowedHeaders  = "Accept, Authorization, Content-Type" // Used if the preflight does not request headers
+	corsAllowedMethods  = "GET, POST, OPTIONS"                  // Methods used by the paymail routes
+	corsMaxAge          = 600                                   // Seconds a preflight response can be cached
+)
+
+// isAllowedOrigin will return true if the origin is in the configured list (or all origins are allowed)
+func (c *Configuration) isAllowedOrigin(origin string) bool {
+	return slices.ContainsFunc(c.CORSAllowedOrigins, func(allowed string) bool {
+		return allowed == corsAllowAllOrigins || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// corsMiddleware will set the Access-Control headers for allowed origins and answer the OPTIONS preflight
+//
+// The headers are set before the handler runs, so error responses also carry them
+func (c *Configuration) corsMiddleware() gin.HandlerFunc {
+	allowAll := slices.Contains(c.CORSAllowedOrigins, corsAllowAllOrigins)
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if len(origin) == 0 || !c.isAllowedOrigin(origin) {
+			if ctx.Request.Method == http.MethodOptions {
+				ctx.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Writer.Header()
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", corsAllowAllOrigins)
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		}
+
+		if ctx.Request.Method == http.MethodOptions {
+			allowedHeaders := ctx.GetHeader("Access-Control-Request-Headers")
+			if len(allowedHeaders) == 0 {
+				allowedHeaders = corsAllowedHeaders
+			}
+			header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			header.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		ctx.Next()
+	}
+}
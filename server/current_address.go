@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/AmanTrance/go-paymail"
+)
+
+// currentAddress will return the current (fresh) deposit address for the corresponding paymail address
+//
+// No amount is negotiated (unlike the P2P payment destination), a single output is returned. If the actions
+// do not implement CurrentAddressProvider, the address resolution response is used. The request is not signed,
+// so the fallback is rejected if sender validation is enabled for the domain (it would bypass the address resolution)
+func (c *Configuration) currentAddress(context *gin.Context) {
+	incomingPaymail := context.Param(PaymailAddressParamName)
+
+	// Parse, sanitize and basic validation
+	alias, domain, address := paymail.SanitizePaymail(incomingPaymail)
+	if len(address) == 0 {
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
+		return
+	} else if !c.IsAllowedDomain(domain) {
+		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
+		return
+	}
+
+	// The fallback cannot validate a sender
+	actions := c.aliasActions(alias, domain)
+	provider, isProvider := actions.(CurrentAddressProvider)
+	if !isProvider && c.senderValidationEnabled(domain) {
+		errors.ErrorResponse(context, errors.ErrCapabilityNotAllowed.WithDetails("sender validation is required"), c.Logger)
+		return
+	}
+
+	// Create the metadata struct
+	md := c.createMetadata(context.Request, alias, domain, "")
+
+	// Get from the data layer
+	foundPaymail, err := actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	if err != nil {
+		errors.ErrorResponse(context, err, c.Logger)
+		return
+	} else if foundPaymail == nil {
+		errors.ErrorResponse(context, errors.ErrCouldNotFindPaymail, c.Logger)
+		return
+	}
+
+	// Get the current address
+	var response *paymail.CurrentAddressPayload
+	if isProvider {
+		response, err = provider.CreateCurrentAddressResponse(context.Request.Context(), alias, domain, md)
+	} else {
+		var resolution *paymail.ResolutionPayload
+		if resolution, err = actions.CreateAddressResolutionResponse(
+			context.Request.Context(), alias, domain, false, md,
+		); err == nil {
+			response = &paymail.CurrentAddressPayload{Address: resolution.Address, Output: resolution.Output}
+		}
+	}
+	if err != nil {
+		errors.ErrorResponse(context, err, c.Logger)
+		return
+	}
+
+	c.recordAuditEvent(context.Request.Context(), md, AuditEvent{
+		Alias:  alias,
+		Domain: domain,
+		Type:   AuditEventAddressResolved,
+	})
+
+	// Set the response
+	context.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,66 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/AmanTrance/go-paymail/server/mockactions"
+)
+
+// testPubKey is the (compressed) public key of the test paymail address
+const testPubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+// newTestProvider will return a mock provider with the test paymail address
+func newTestProvider() *mockactions.ServiceProvider {
+	return mockactions.New(&paymail.AddressInformation{Alias: testAlias, Domain: testDomain, PubKey: testPubKey})
+}
+
+// newTestHandler will return the handler of a server using the provider
+func newTestHandler(t *testing.T, provider server.PaymailServiceProvider, opts ...server.ConfigOps) http.Handler {
+	t.Helper()
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(provider)
+	config, err := server.NewConfig(locator, append([]server.ConfigOps{server.WithDomain(testDomain)}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create the config: %s", err)
+	}
+	return server.Handlers(config)
+}
+
+func getCurrentAddress(handler http.Handler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/bsvalias/current-address/"+testAlias+"@"+testDomain, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCurrentAddress_Fallback(t *testing.T) {
+	handler := newTestHandler(t, newTestProvider(), server.WithCurrentAddressCapabilities())
+
+	rec := getCurrentAddress(handler)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload paymail.CurrentAddressPayload
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Output) == 0 || len(payload.Address) == 0 {
+		t.Errorf("expected an output and address, got %+v", payload)
+	}
+}
+
+func TestCurrentAddress_FallbackRejectedWithSenderValidation(t *testing.T) {
+	handler := newTestHandler(t, newTestProvider(),
+		server.WithCurrentAddressCapabilities(),
+		server.WithSenderValidation(),
+	)
+
+	if rec := getCurrentAddress(handler); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"time"
 
 	"github.com/AmanTrance/go-paymail"
@@ -9,7 +10,12 @@ import (
 // Server default values
 const (
 	DefaultAPIVersion       = "v1"             // Version of API
+	DefaultHealthPath       = "/health"        // Path of the health route (outside the bsvalias namespace)
+	DefaultMaxNoteLength    = 500              // Max length (in characters) of the note of a received P2P transaction
+	DefaultMaxTxSizeBytes   = 1024 * 1024      // Max size of a received P2P transaction (1 MB)
 	DefaultPrefix           = "https://"       // Paymail specs require SSL
+	DefaultRecordWait       = 5 * time.Second  // Max wait for a record slot (see WithMaxConcurrentRecords)
+	DefaultReferenceTTL     = 5 * time.Minute  // Time to submit the transaction of a P2P payment destination reference
 	DefaultSenderValidation = false            // If true, it requires extra sender validation
 	DefaultServerPort       = 3000             // Port for the server
 	DefaultTimeout          = 15 * time.Second // Default timeouts
@@ -23,22 +29,54 @@ const (
 	PubKeyTemplate          = "{pubkey}"             // Used as a placeholder in capabilities list
 )
 
+// Health statuses and the readiness query param
+const (
+	HealthReadyParamName    = "ready"       // Query param to run the readiness check (value "true")
+	HealthStatusOK          = "ok"          // The server is healthy
+	HealthStatusUnavailable = "unavailable" // The readiness check failed
+)
+
+// Dry-run (validate a P2P transaction without recording it)
+const (
+	DryRunHeaderName = "x-paymail-dry-run" // Header to request a dry-run (value "true")
+	DryRunParamName  = "dry_run"           // Query param to request a dry-run (value "true")
+)
+
 // basicRoutes is the configuration for basic server routes
 type basicRoutes struct {
-	Add404Route    bool `json:"add_404_route,omitempty"`
-	AddHealthRoute bool `json:"add_health_route,omitempty"`
-	AddIndexRoute  bool `json:"add_index_route,omitempty"`
-	AddNotAllowed  bool `json:"add_not_allowed,omitempty"`
+	Add404Route    bool   `json:"add_404_route,omitempty"`
+	AddHealthRoute bool   `json:"add_health_route,omitempty"`
+	AddIndexRoute  bool   `json:"add_index_route,omitempty"`
+	AddNotAllowed  bool   `json:"add_not_allowed,omitempty"`
+	HealthPath     string `json:"health_path,omitempty"`
+}
+
+// HealthChecker can be implemented by the PaymailServiceProvider to check the data layer (readiness)
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthResponse is the response of the health route
+type HealthResponse struct {
+	Actions     bool   `json:"actions"`         // The PaymailServiceProvider is set
+	Broadcaster bool   `json:"broadcaster"`     // A Broadcaster is set
+	BsvAlias    string `json:"bsvalias"`        // Version of the bsvalias capabilities
+	Error       string `json:"error,omitempty"` // The readiness check error (if any)
+	Ready       *bool  `json:"ready,omitempty"` // Result of the readiness check (only if requested)
+	Status      string `json:"status"`          // "ok" or "unavailable"
 }
 
 // RequestMetadata is the struct with extra metadata
 type RequestMetadata struct {
-	Alias              string                  `json:"alias,omitempty"`               // Alias of the paymail
-	Domain             string                  `json:"domain,omitempty"`              // Domain of the request
-	IPAddress          string                  `json:"ip_address,omitempty"`          // IP address of the requesting user
-	Note               string                  `json:"note,omitempty"`                // Generic note field used for extra information
-	PaymentDestination *paymail.PaymentRequest `json:"payment_destination,omitempty"` // Information from the P2P Payment Destination request
-	RequestURI         string                  `json:"request_uri,omitempty"`         // Full requesting URL path
-	ResolveAddress     *paymail.SenderRequest  `json:"resolve_address,omitempty"`     // Information from the Resolve Address request
-	UserAgent          string                  `json:"user_agent,omitempty"`          // User agent of the requesting user
+	Alias              string                  `json:"alias,omitempty"`                // Alias of the paymail
+	Domain             string                  `json:"domain,omitempty"`               // Domain of the request
+	Extra              map[string]any          `json:"extra,omitempty"`                // Custom information (set by a MetadataEnricher)
+	IPAddress          string                  `json:"ip_address,omitempty"`           // IP address of the requesting user
+	Note               string                  `json:"note,omitempty"`                 // Generic note field used for extra information
+	OutputAmounts      []uint64                `json:"output_amounts,omitempty"`       // Satoshis of each output of the P2P Payment Destination (see WithOutputSplit)
+	ReferenceExpiresAt *time.Time              `json:"reference_expires_at,omitempty"` // Expiry to store with the P2P Payment Destination reference (see WithReferenceTTL)
+	PaymentDestination *paymail.PaymentRequest `json:"payment_destination,omitempty"`  // Information from the P2P Payment Destination request
+	RequestURI         string                  `json:"request_uri,omitempty"`          // Full requesting URL path
+	ResolveAddress     *paymail.SenderRequest  `json:"resolve_address,omitempty"`      // Information from the Resolve Address request
+	UserAgent          string                  `json:"user_agent,omitempty"`           // User agent of the requesting user
 }
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// DomainConfig is the configuration of a single paymail domain (set using WithDomainConfig())
+//
+// Unset fields use the defaults of the Configuration
+type DomainConfig struct {
+	Actions                 PaymailServiceProvider       // Service provider of the domain (default is the registered service)
+	Capabilities            []string                     // Allowed capabilities (BRFC IDs) of the domain (empty allows all)
+	ProfileActions          PublicProfileServiceProvider // Public profile provider of the domain (default is the registered provider)
+	SenderValidationEnabled *bool                        // Overrides SenderValidationEnabled for the domain
+}
+
+// domainConfig will return the configuration of the (sanitized) domain (nil uses the defaults)
+//
+// An exact domain is preferred over a matching wildcard pattern, the most specific (longest) pattern is used
+// if several patterns match
+func (c *Configuration) domainConfig(domain string) *DomainConfig {
+	if len(c.domainConfigs) == 0 {
+		return nil
+	} else if config, ok := c.domainConfigs[domain]; ok {
+		return config
+	}
+	var matched string
+	for name := range c.domainConfigs {
+		if !(&Domain{Name: name}).matches(domain) {
+			continue
+		} else if len(name) > len(matched) || (len(name) == len(matched) && name < matched) {
+			matched = name
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	return c.domainConfigs[matched]
+}
+
+// domainActions will return the service provider of the domain
+func (c *Configuration) domainActions(domain string) PaymailServiceProvider {
+	if config := c.domainConfig(domain); config != nil && config.Actions != nil {
+		return config.Actions
+	}
+	return c.actions
+}
+
+// domainProfileActions will return the public profile provider of the domain
+func (c *Configuration) domainProfileActions(domain string) PublicProfileServiceProvider {
+	if config := c.domainConfig(domain); config != nil && config.ProfileActions != nil {
+		return config.ProfileActions
+	}
+	return c.profileActions
+}
+
+// senderValidationEnabled will return true if the sender validation is enabled for the domain
+func (c *Configuration) senderValidationEnabled(domain string) bool {
+	if config := c.domainConfig(domain); config != nil && config.SenderValidationEnabled != nil {
+		return *config.SenderValidationEnabled
+	}
+	return c.SenderValidationEnabled
+}
+
+// isCapabilityAllowed will return true if the capability (BRFC ID) is allowed for the domain
+//
+// Nested capabilities (e.g. "8c4ed5ef8ace.invite") are allowed by their parent key
+func (c *Configuration) isCapabilityAllowed(domain, key string) bool {
+	config := c.domainConfig(domain)
+	if config == nil || len(config.Capabilities) == 0 {
+		return true
+	}
+	parent, _, _ := strings.Cut(key, ".")
+	return slices.Contains(config.Capabilities, key) || slices.Contains(config.Capabilities, parent)
+}
+
+// domainCapabilityHandler will reject the requests for a capability that is not allowed for the domain
+func (c *Configuration) domainCapabilityHandler(key string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		_, domain, _ := paymail.SanitizePaymail(ctx.Param(PaymailAddressParamName))
+		if !c.isCapabilityAllowed(domain, key) {
+			errors.ErrorResponse(ctx, errors.ErrCapabilityNotAllowed, c.Logger)
+			return
+		}
+		handler(ctx)
+	}
+}
+
+// capabilitiesDomain will return the domain of the capabilities document
+//
+// The request host is used if it has a domain configuration, otherwise the configured Domain
+func (c *Configuration) capabilitiesDomain(ctx *gin.Context) string {
+	host := ctx.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host = strings.ToLower(host); c.domainConfig(host) != nil {
+		return host
+	}
+	return c.Domain
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+)
+
+// stubProvider only knows the paymail addresses of its domain (the other methods are not implemented)
+type stubProvider struct {
+	PaymailServiceProvider
+	domain string
+}
+
+func (s *stubProvider) GetPaymailByAlias(_ context.Context, alias, domain string,
+	_ *RequestMetadata) (*paymail.AddressInformation, error) {
+	if !strings.EqualFold(domain, s.domain) {
+		return nil, nil
+	}
+	return &paymail.AddressInformation{Alias: alias, Domain: domain, PubKey: "02" + s.domain}, nil
+}
+
+// newTwoDomainsConfig will return a configuration with a provider per domain (and a default provider)
+func newTwoDomainsConfig(t *testing.T, first, second PaymailServiceProvider) *Configuration {
+	t.Helper()
+	locator := &PaymailServiceLocator{}
+	locator.RegisterPaymailService(&stubProvider{domain: "default.com"})
+	config, err := NewConfig(locator,
+		WithDomain("default.com"),
+		WithDomainConfig("first.com", DomainConfig{Actions: first}),
+		WithDomainConfig("*.second.com", DomainConfig{Actions: second}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create the config: %s", err)
+	}
+	return config
+}
+
+func TestDomainConfig_MostSpecificPattern(t *testing.T) {
+	config := defaultConfigOptions()
+	exact, long, short := &DomainConfig{}, &DomainConfig{}, &DomainConfig{}
+	config.domainConfigs = map[string]*DomainConfig{
+		"a.b.example.com":   exact,
+		"*.b.example.com":   long,
+		"*.example.com":     short,
+		"*.other.test.com":  {},
+		"*.unrelated.co.uk": {},
+	}
+
+	for i := 0; i < 20; i++ {
+		if got := config.domainConfig("a.b.example.com"); got != exact {
+			t.Fatal("expected the exact domain config")
+		}
+		if got := config.domainConfig("c.b.example.com"); got != long {
+			t.Fatal("expected the most specific pattern")
+		}
+		if got := config.domainConfig("b.example.com"); got != short {
+			t.Fatal("expected the matching pattern")
+		}
+	}
+	if config.domainConfig("unknown.com") != nil {
+		t.Error("expected no config for an unknown domain")
+	}
+}
+
+func TestDomainConfig_TwoDomains(t *testing.T) {
+	// Each provider knows a different domain, a domain only resolves with its own provider
+	first := &stubProvider{domain: "first.com"}
+	second := &stubProvider{domain: "a.second.com"}
+	handler := Handlers(newTwoDomainsConfig(t, first, second))
+
+	for paymailAddress, pubKey := range map[string]string{
+		"alice@first.com":   "02first.com",
+		"bob@a.second.com":  "02a.second.com",
+		"carol@default.com": "02default.com",
+		"dave@b.second.com": "", // the provider of *.second.com only knows a.second.com
+		"erin@unknown.com":  "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/bsvalias/id/"+paymailAddress, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if len(pubKey) == 0 {
+			if rec.Code == http.StatusOK {
+				t.Errorf("%s: expected an error, got %s", paymailAddress, rec.Body.String())
+			}
+		} else if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), pubKey) {
+			t.Errorf("%s: expected the pubkey %s, got %d: %s", paymailAddress, pubKey, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMerkleRootVerifier_DomainActions(t *testing.T) {
+	first, second := &stubProvider{domain: "first.com"}, &stubProvider{domain: "a.second.com"}
+	config := newTwoDomainsConfig(t, first, second)
+
+	if verifier := config.merkleRootVerifier("alice", "first.com"); verifier != first {
+		t.Error("expected the verifier of first.com")
+	}
+	if verifier := config.merkleRootVerifier("bob", "a.second.com"); verifier != second {
+		t.Error("expected the verifier of a.second.com")
+	}
+	if verifier := config.merkleRootVerifier("carol", "default.com"); verifier != config.actions {
+		t.Error("expected the default verifier")
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/AmanTrance/go-paymail"
 	"github.com/AmanTrance/go-paymail/spv"
@@ -11,6 +12,9 @@ type PaymailServiceLocator struct {
 	paymailService     PaymailServiceProvider
 	pikeContactService PikeContactServiceProvider
 	pikePaymentService PikePaymentServiceProvider
+	profileService     PublicProfileServiceProvider
+	approvalService    TransactionApprovalServiceProvider
+	reservedService    PaymailServiceProvider
 }
 
 func (l *PaymailServiceLocator) RegisterPaymailService(s PaymailServiceProvider) {
@@ -49,6 +53,42 @@ func (l *PaymailServiceLocator) GetPikePaymentService() PikePaymentServiceProvid
 	return l.pikePaymentService
 }
 
+func (l *PaymailServiceLocator) RegisterPublicProfileService(s PublicProfileServiceProvider) {
+	l.profileService = s
+}
+
+func (l *PaymailServiceLocator) GetPublicProfileService() PublicProfileServiceProvider {
+	if l.profileService == nil {
+		panic("PublicProfileServiceProvider was not registered")
+	}
+
+	return l.profileService
+}
+
+func (l *PaymailServiceLocator) RegisterTransactionApprovalService(s TransactionApprovalServiceProvider) {
+	l.approvalService = s
+}
+
+func (l *PaymailServiceLocator) GetTransactionApprovalService() TransactionApprovalServiceProvider {
+	if l.approvalService == nil {
+		panic("TransactionApprovalServiceProvider was not registered")
+	}
+
+	return l.approvalService
+}
+
+func (l *PaymailServiceLocator) RegisterReservedAliasService(s PaymailServiceProvider) {
+	l.reservedService = s
+}
+
+func (l *PaymailServiceLocator) GetReservedAliasService() PaymailServiceProvider {
+	if l.reservedService == nil {
+		panic("reserved alias PaymailServiceProvider was not registered")
+	}
+
+	return l.reservedService
+}
+
 // PaymailServiceProvider the paymail server interface that needs to be implemented
 type PaymailServiceProvider interface {
 	CreateAddressResolutionResponse(
@@ -65,12 +105,15 @@ type PaymailServiceProvider interface {
 		metaData *RequestMetadata,
 	) (*paymail.PaymentDestinationPayload, error)
 
+	// GetPaymailByAlias returns the paymail (nil if not found), PubKeys can hold the other active keys (key rotation)
 	GetPaymailByAlias(
 		ctx context.Context,
 		alias, domain string,
 		metaData *RequestMetadata,
 	) (*paymail.AddressInformation, error)
 
+	// RecordTransaction records the tx (idempotent). If the reference/txid was already recorded it must return
+	// the original payload with errors.ErrTransactionAlreadyRecorded, and the handler responds with the original payload
 	RecordTransaction(
 		ctx context.Context,
 		p2pTx *paymail.P2PTransaction,
@@ -83,6 +126,41 @@ type PaymailServiceProvider interface {
 	) error
 }
 
+// ReferenceProvider can be implemented by the PaymailServiceProvider to verify the reference of a received
+// P2P transaction (the transaction must pay the outputs issued for the reference before the expiry).
+// If not implemented, the reference is not verified
+type ReferenceProvider interface {
+	// GetReference returns the payment destination issued for the reference (nil if it was never issued)
+	GetReference(
+		ctx context.Context,
+		alias, domain, reference string,
+	) (*paymail.PaymentDestinationPayload, error)
+}
+
+// ScriptTypeDestinationProvider can be implemented by the PaymailServiceProvider to issue P2P payment
+// destinations with a script type other than P2PKH (see Configuration.SupportedScriptTypes)
+type ScriptTypeDestinationProvider interface {
+	CreateDestination(
+		ctx context.Context,
+		alias, domain string,
+		satoshis uint64,
+		scriptType string,
+		metaData *RequestMetadata,
+	) (*paymail.PaymentDestinationPayload, error)
+}
+
+// CurrentAddressProvider can be implemented by the PaymailServiceProvider to issue the current (fresh) deposit
+// address, if not implemented the address resolution response is used (see Configuration.CurrentAddressCapabilitiesEnabled)
+//
+// The request is not signed by a sender, if not implemented the request is rejected for a domain with sender validation
+type CurrentAddressProvider interface {
+	CreateCurrentAddressResponse(
+		ctx context.Context,
+		alias, domain string,
+		metaData *RequestMetadata,
+	) (*paymail.CurrentAddressPayload, error)
+}
+
 type PikeContactServiceProvider interface {
 	AddContact(
 		ctx context.Context,
@@ -99,3 +177,59 @@ type PikePaymentServiceProvider interface {
 		metaData *RequestMetadata,
 	) (*paymail.PikePaymentOutputsResponse, error)
 }
+
+// PublicProfileServiceProvider returns the public profile (name & avatar) of a paymail (nil if not found)
+type PublicProfileServiceProvider interface {
+	GetProfile(
+		ctx context.Context,
+		alias, domain string,
+		metaData *RequestMetadata,
+	) (*paymail.PublicProfilePayload, error)
+}
+
+// TransactionApprovalServiceProvider is used for the receiver approvals, RecordTransaction returns a pending status
+// for the transactions that require an approval
+//
+// The reference is scoped to the paymail address (alias@domain), a reference of another paymail is unknown (nil)
+type TransactionApprovalServiceProvider interface {
+	// ApproveTransaction accepts (or rejects) the pending transaction and returns the payload with the new status
+	ApproveTransaction(
+		ctx context.Context,
+		alias, domain, reference string,
+		approved bool,
+		metaData *RequestMetadata,
+	) (*TransactionApproval, error)
+
+	// GetTransactionStatus returns the payload of the recorded transaction (nil if the reference is unknown)
+	GetTransactionStatus(
+		ctx context.Context,
+		alias, domain, reference string,
+		metaData *RequestMetadata,
+	) (*paymail.P2PTransactionPayload, error)
+}
+
+// TransactionApproval is the result of ApproveTransaction
+type TransactionApproval struct {
+	// Hex is the raw transaction, set when the pending transaction was accepted by this approval
+	// (it is broadcast by the Broadcaster, unless it was broadcast before recording)
+	Hex string
+
+	// Payload is the transaction payload with the new status
+	Payload *paymail.P2PTransactionPayload
+}
+
+// ApprovalAuthorizer authenticates the approve requests (e.g. a session or an API key of the receiver),
+// an error rejects the request (an SPVError is returned as is, otherwise errors.ErrApprovalUnauthorized)
+type ApprovalAuthorizer func(req *http.Request, alias, domain string) error
+
+// Broadcaster submits a recorded P2P transaction to the network (e.g. a node or ARC)
+type Broadcaster interface {
+	// Broadcast returns the txid and status, the callbackURL (optional) is registered for the merkle proof delivery
+	Broadcast(ctx context.Context, txHex, callbackURL string) (*BroadcastResult, error)
+}
+
+// BroadcastResult is the result of a Broadcaster
+type BroadcastResult struct {
+	Status string `json:"status"` // Status of the transaction (e.g. SEEN_ON_NETWORK)
+	TxID   string `json:"txid"`   // The txid of the broadcast transaction
+}
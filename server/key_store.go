@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// KeyStore holds the signing keys of the server by paymail domain (see WithKeyStore())
+//
+// The private keys stay in the key store (e.g. a KMS or an HSM), the handlers only use the Signer of the domain
+type KeyStore interface {
+	// Signer returns the signer of the domain (nil if the domain has no key)
+	Signer(ctx context.Context, domain string) (Signer, error)
+}
+
+// DomainKeyStore is a KeyStore of signers by domain, with an (optional) default signer for the other domains
+type DomainKeyStore struct {
+	defaultSigner Signer
+	mu            sync.RWMutex
+	signers       map[string]Signer
+}
+
+// NewDomainKeyStore will create a key store using the default signer (nil for no default key)
+func NewDomainKeyStore(defaultSigner Signer) *DomainKeyStore {
+	return &DomainKeyStore{
+		defaultSigner: defaultSigner,
+		signers:       make(map[string]Signer),
+	}
+}
+
+// SetSigner will set the signer of the domain (nil removes the key of the domain)
+func (s *DomainKeyStore) SetSigner(domain string, signer Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if signer == nil {
+		delete(s.signers, keyStoreDomain(domain))
+		return
+	}
+	s.signers[keyStoreDomain(domain)] = signer
+}
+
+// Signer will return the signer of the domain, or the default signer
+func (s *DomainKeyStore) Signer(_ context.Context, domain string) (Signer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if signer, ok := s.signers[keyStoreDomain(domain)]; ok {
+		return signer, nil
+	}
+	return s.defaultSigner, nil
+}
+
+// keyStoreDomain will standardize the domain used as a key store key
+func keyStoreDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// domainSigner will return the signer of the domain from the key store, or the destination signer (nil if neither)
+func (c *Configuration) domainSigner(ctx context.Context, domain string) (Signer, error) {
+	if c.keyStore != nil {
+		signer, err := c.keyStore.Signer(ctx, domain)
+		if err != nil || signer != nil {
+			return signer, err
+		}
+	}
+	return c.destinationSigner, nil
+}
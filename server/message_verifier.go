@@ -0,0 +1,28 @@
+package server
+
+import "github.com/AmanTrance/go-paymail"
+
+// MessageVerifier verifies the (base64 encoded) signature of a message for an address
+//
+// Used for the sender validation (address resolution) and the P2P transaction signatures, see WithMessageVerifier()
+type MessageVerifier interface {
+	Verify(address, signature, message string) error
+}
+
+// SchemeMessageVerifier is the default MessageVerifier using paymail.VerifySignature() with the signature scheme
+type SchemeMessageVerifier struct {
+	Scheme string // Signature scheme (empty is the legacy Bitcoin signed message)
+}
+
+// Verify will verify the signature of the message for the address using the signature scheme
+func (v SchemeMessageVerifier) Verify(address, signature, message string) error {
+	return paymail.VerifySignature(v.Scheme, address, signature, []byte(message))
+}
+
+// messageVerifier will return the custom MessageVerifier (if set), or the verifier of the signature scheme
+func (c *Configuration) messageVerifier(scheme string) MessageVerifier {
+	if c.customVerifier != nil {
+		return c.customVerifier
+	}
+	return SchemeMessageVerifier{Scheme: scheme}
+}
@@ -1,25 +1,112 @@
 package server
 
 import (
+	"net"
 	"net/http"
+	"strings"
 )
 
+// MetadataEnricher can add information (e.g. a request ID or the auth subject) to the metadata of
+// the request, it runs after the base fields are set and before the metadata is passed to the actions
+type MetadataEnricher func(req *http.Request, md *RequestMetadata)
+
 // CreateMetadata will create the base metadata using the request
+//
+// The IP address is the direct RemoteAddr (the proxy headers are not trusted), see ClientIP()
 func CreateMetadata(req *http.Request, alias, domain, optionalNote string) *RequestMetadata {
-	ipAddress := req.Header.Get("X-Real-IP")
-	if ipAddress == "" {
-		ipAddress = req.Header.Get("X-Forwarded-For")
-		if ipAddress == "" {
-			ipAddress = req.RemoteAddr
-		}
-	}
-
 	return &RequestMetadata{
 		Alias:      alias,
 		Domain:     domain,
-		IPAddress:  ipAddress,
+		IPAddress:  ClientIP(req, nil),
 		Note:       optionalNote,
 		RequestURI: req.RequestURI,
 		UserAgent:  req.UserAgent(),
 	}
 }
+
+// createMetadata will create the base metadata using the request, enriched by the MetadataEnricher (if set)
+func (c *Configuration) createMetadata(req *http.Request, alias, domain, optionalNote string) *RequestMetadata {
+	md := CreateMetadata(req, alias, domain, optionalNote)
+	md.IPAddress = ClientIP(req, c.trustedProxies)
+	if c.metadataEnricher != nil {
+		c.metadataEnricher(req, md)
+	}
+	return md
+}
+
+// ClientIP will return the IP address of the client that made the request
+//
+// X-Forwarded-For and X-Real-IP are only used if the request came from a trusted proxy. The
+// X-Forwarded-For chain is read from right to left, skipping the trusted proxies, so a value
+// set (spoofed) by the client is ignored
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	remoteIP := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
+	}
+
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	if forwardedFor := req.Header.Get("X-Forwarded-For"); len(forwardedFor) > 0 {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			} else if !isTrustedProxy(hop, trustedProxies) {
+				return hop
+			}
+		}
+	}
+
+	if realIP := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return remoteIP
+}
+
+// isTrustedProxy will return true if the ip is in one of the trusted proxy networks
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	if len(trustedProxies) == 0 {
+		return false
+	}
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies will parse the CIDRs (a single IP is also allowed) of the trusted proxies
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: proxy}
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// forwardedRequest will return a request from the remote address with the proxy headers (if set)
+func forwardedRequest(remoteAddr, forwardedFor, realIP string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if len(forwardedFor) > 0 {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if len(realIP) > 0 {
+		req.Header.Set("X-Real-IP", realIP)
+	}
+	return req
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		req      *http.Request
+		expected string
+	}{
+		"direct request": {
+			forwardedRequest("203.0.113.1:1234", "", ""), "203.0.113.1",
+		},
+		"spoofed forwarded for from an untrusted client": {
+			forwardedRequest("203.0.113.1:1234", "198.51.100.1", ""), "203.0.113.1",
+		},
+		"spoofed real ip from an untrusted client": {
+			forwardedRequest("203.0.113.1:1234", "", "198.51.100.1"), "203.0.113.1",
+		},
+		"forwarded by a trusted proxy": {
+			forwardedRequest("10.0.0.1:1234", "198.51.100.1", ""), "198.51.100.1",
+		},
+		"spoofed hop before the client is ignored": {
+			forwardedRequest("10.0.0.1:1234", "198.51.100.9, 198.51.100.1, 10.0.0.2", ""), "198.51.100.1",
+		},
+		"real ip set by a trusted proxy": {
+			forwardedRequest("192.0.2.1:1234", "", "198.51.100.1"), "198.51.100.1",
+		},
+	}
+	for name, test := range tests {
+		if ip := ClientIP(test.req, trustedProxies); ip != test.expected {
+			t.Errorf("%s: expected %s, got %s", name, test.expected, ip)
+		}
+	}
+}
+
+func TestCreateMetadata_ClientIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := forwardedRequest("10.0.0.1:1234", "198.51.100.1", "")
+
+	// Without trusted proxies the direct RemoteAddr is used
+	if md := CreateMetadata(req, "alice", "test.com", ""); md.IPAddress != "10.0.0.1" {
+		t.Errorf("expected the remote address, got %s", md.IPAddress)
+	}
+
+	c := &Configuration{trustedProxies: trustedProxies}
+	if md := c.createMetadata(req, "alice", "test.com", ""); md.IPAddress != "198.51.100.1" {
+		t.Errorf("expected the forwarded client ip, got %s", md.IPAddress)
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	for _, proxy := range []string{"not-an-ip", "10.0.0.0/33"} {
+		if _, err := parseTrustedProxies([]string{proxy}); err == nil {
+			t.Errorf("%s: expected an error", proxy)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric outcomes
+const (
+	metricOutcomeError   = "error"
+	metricOutcomeSuccess = "success"
+)
+
+// metricCapabilityResolve is the capability label of the service discovery (capabilities document)
+const metricCapabilityResolve = "resolve"
+
+// metricCapabilityNames are the capability labels by BRFC ID (the other capabilities use the builder names)
+var metricCapabilityNames = map[string]string{
+	paymail.BRFCP2PPaymentDestination: "p2pDestination",
+	paymail.BRFCP2PTransactions:       "p2pReceiveTx",
+	paymail.BRFCPaymentDestination:    "paymentDestination",
+	paymail.BRFCPike:                  "pike",
+	paymail.BRFCPki:                   "pki",
+}
+
+// metricCapabilityName will return the capability label of the route name (BRFC ID or nested BRFC ID.key)
+func metricCapabilityName(routeName string) string {
+	brfcID, key, nested := strings.Cut(routeName, ".")
+	name, ok := metricCapabilityNames[brfcID]
+	if !ok {
+		name = brfcID
+		for builderName, definition := range builderCapabilities {
+			if definition.brfcID == brfcID {
+				name = builderName
+				break
+			}
+		}
+	}
+	if nested {
+		return name + "." + key
+	}
+	return name
+}
+
+// serverMetrics are the Prometheus collectors for the capability handlers
+type serverMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// newServerMetrics will create and register the collectors
+//
+// Collectors that are already registered (e.g. by another server) are reused
+func newServerMetrics(registerer prometheus.Registerer) (*serverMetrics, error) {
+	requests, err := registerCollector(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "paymail",
+		Subsystem: "server",
+		Name:      "requests_total",
+		Help:      "Total number of capability requests by outcome",
+	}, []string{"capability", "outcome"}))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registerCollector(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "paymail",
+		Subsystem: "server",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of capability requests in seconds",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"capability"}))
+	if err != nil {
+		return nil, err
+	}
+	return &serverMetrics{requests: requests, duration: duration}, nil
+}
+
+// registerCollector will register the collector (or return the same collector already registered)
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	if err := registerer.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return collector, err
+	}
+	return collector, nil
+}
+
+// middleware will record the request count and latency for the capability
+//
+// It runs before the other middleware, so the rejected requests (e.g. rate limited) are counted as errors
+func (m *serverMetrics) middleware(capability string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		outcome := metricOutcomeSuccess
+		if c.Writer.Status() >= http.StatusBadRequest {
+			outcome = metricOutcomeError
+		}
+		m.requests.WithLabelValues(capability, outcome).Inc()
+		m.duration.WithLabelValues(capability).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	stderrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetrics_CapabilityOutcomes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	handler := newTestHandler(t, newTestProvider(),
+		server.WithGenericCapabilities(),
+		server.WithMetrics(registry),
+		server.WithRateLimit(1, 1),
+	)
+
+	if rec := getPKI(handler, testAlias+"@"+testDomain); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The rate limited request is counted as an error
+	if rec := getPKI(handler, testAlias+"@"+testDomain); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "paymail_server_requests_total" {
+			continue
+		}
+		counts := make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["capability"]+"/"+labels["outcome"]] = metric.GetCounter().GetValue()
+		}
+		if counts["pki/success"] != 1 || counts["pki/error"] != 1 {
+			t.Errorf("expected a pki success and error, got %v", counts)
+		}
+		return
+	}
+	t.Error("expected the requests counter to be registered")
+}
+
+func TestMetrics_RegistrationConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "paymail_server_requests_total",
+		Help: "A conflicting collector",
+	}))
+
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(newTestProvider())
+	_, err := server.NewConfig(locator, server.WithDomain(testDomain), server.WithMetrics(registry))
+	if !stderrors.Is(err, errors.ErrMetricsRegistration) {
+		t.Fatalf("expected ErrMetricsRegistration, got %v", err)
+	}
+
+	// The same collectors can be registered by another server
+	other := prometheus.NewRegistry()
+	for i := 0; i < 2; i++ {
+		if _, err = server.NewConfig(locator, server.WithDomain(testDomain), server.WithMetrics(other)); err != nil {
+			t.Fatalf("expected the collectors to be reused, got %s", err)
+		}
+	}
+}
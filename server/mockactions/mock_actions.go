@@ -0,0 +1,397 @@
+// Package mockactions is an in-memory implementation of the PaymailServiceProvider (and the optional
+// PublicProfileServiceProvider and TransactionApprovalServiceProvider) used for testing servers
+package mockactions
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/AmanTrance/go-paymail/spv"
+
+	script "github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+)
+
+// Method is the name of a PaymailServiceProvider method (used for error injection)
+type Method string
+
+// All the methods that support error injection
+const (
+	MethodApproveTransaction              Method = "ApproveTransaction"
+	MethodCreateAddressResolutionResponse Method = "CreateAddressResolutionResponse"
+	MethodCreateP2PDestinationResponse    Method = "CreateP2PDestinationResponse"
+	MethodGetPaymailByAlias               Method = "GetPaymailByAlias"
+	MethodGetProfile                      Method = "GetProfile"
+	MethodGetReference                    Method = "GetReference"
+	MethodGetTransactionStatus            Method = "GetTransactionStatus"
+	MethodHealthCheck                     Method = "HealthCheck"
+	MethodRecordTransaction               Method = "RecordTransaction"
+	MethodVerifyMerkleRoots               Method = "VerifyMerkleRoots"
+)
+
+// issuedDestination is a payment destination issued for a paymail address
+type issuedDestination struct {
+	paymail     string
+	destination *paymail.PaymentDestinationPayload
+}
+
+// RecordedTransaction is a transaction that was passed to RecordTransaction
+type RecordedTransaction struct {
+	MetaData    *server.RequestMetadata
+	Transaction *paymail.P2PTransaction
+}
+
+// ServiceProvider is an in-memory PaymailServiceProvider
+type ServiceProvider struct {
+	destinations    map[string]*issuedDestination
+	injected        map[Method]error
+	mu              sync.RWMutex
+	payloads        map[string]*paymail.P2PTransactionPayload
+	paymails        map[string]*paymail.AddressInformation
+	recorded        []*RecordedTransaction
+	requireApproval bool
+}
+
+// New will create a new ServiceProvider preloaded with the given paymail addresses
+func New(paymails ...*paymail.AddressInformation) *ServiceProvider {
+	p := &ServiceProvider{
+		injected:     make(map[Method]error),
+		payloads:     make(map[string]*paymail.P2PTransactionPayload),
+		paymails:     make(map[string]*paymail.AddressInformation),
+		destinations: make(map[string]*issuedDestination),
+	}
+	for _, info := range paymails {
+		p.AddPaymail(info)
+	}
+	return p
+}
+
+// AddPaymail will register the paymail address (alias@domain) for the given information
+func (p *ServiceProvider) AddPaymail(info *paymail.AddressInformation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paymails[paymailKey(info.Alias, info.Domain)] = info
+}
+
+// SetError will make the given method return the error (nil removes the error)
+func (p *ServiceProvider) SetError(method Method, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		delete(p.injected, method)
+		return
+	}
+	p.injected[method] = err
+}
+
+// SetRequireApproval will make RecordTransaction return a pending status (until ApproveTransaction is called)
+func (p *ServiceProvider) SetRequireApproval(requireApproval bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requireApproval = requireApproval
+}
+
+// RecordedTransactions will return all transactions passed to RecordTransaction (in order)
+func (p *ServiceProvider) RecordedTransactions() []*RecordedTransaction {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	recorded := make([]*RecordedTransaction, len(p.recorded))
+	copy(recorded, p.recorded)
+	return recorded
+}
+
+// WasRecorded will return true if RecordTransaction was called with the given hex and reference
+func (p *ServiceProvider) WasRecorded(txHex, reference string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, r := range p.recorded {
+		if r.Transaction.Hex == txHex && r.Transaction.Reference == reference {
+			return true
+		}
+	}
+	return false
+}
+
+// Destination will return the payment destination issued for the given reference (if found)
+func (p *ServiceProvider) Destination(reference string) *paymail.PaymentDestinationPayload {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if issued, ok := p.destinations[reference]; ok {
+		return issued.destination
+	}
+	return nil
+}
+
+// GetPaymailByAlias will return the registered paymail address information
+func (p *ServiceProvider) GetPaymailByAlias(_ context.Context, alias, domain string,
+	_ *server.RequestMetadata,
+) (*paymail.AddressInformation, error) {
+	if err := p.injectedError(MethodGetPaymailByAlias); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.paymails[paymailKey(alias, domain)], nil
+}
+
+// GetProfile will return the name and avatar of the registered paymail address
+func (p *ServiceProvider) GetProfile(ctx context.Context, alias, domain string,
+	metaData *server.RequestMetadata,
+) (*paymail.PublicProfilePayload, error) {
+	if err := p.injectedError(MethodGetProfile); err != nil {
+		return nil, err
+	}
+
+	info, err := p.GetPaymailByAlias(ctx, alias, domain, metaData)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	return &paymail.PublicProfilePayload{
+		Avatar: info.Avatar,
+		Name:   info.Name,
+	}, nil
+}
+
+// GetReference will return the payment destination issued for the paymail and reference
+func (p *ServiceProvider) GetReference(_ context.Context, alias, domain, reference string,
+) (*paymail.PaymentDestinationPayload, error) {
+	if err := p.injectedError(MethodGetReference); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if issued, ok := p.destinations[reference]; ok && issued.paymail == paymailKey(alias, domain) {
+		return issued.destination, nil
+	}
+	return nil, nil
+}
+
+// CreateAddressResolutionResponse will return a P2PKH output for the paymail's PubKey
+func (p *ServiceProvider) CreateAddressResolutionResponse(ctx context.Context, alias, domain string,
+	_ bool, metaData *server.RequestMetadata,
+) (*paymail.ResolutionPayload, error) {
+	if err := p.injectedError(MethodCreateAddressResolutionResponse); err != nil {
+		return nil, err
+	}
+
+	output, address, err := p.lockingScript(ctx, alias, domain, metaData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &paymail.ResolutionPayload{
+		Address: address,
+		Output:  output,
+	}, nil
+}
+
+// CreateP2PDestinationResponse will issue P2PKH outputs (one per metadata output amount) and a new reference
+func (p *ServiceProvider) CreateP2PDestinationResponse(ctx context.Context, alias, domain string,
+	satoshis uint64, metaData *server.RequestMetadata,
+) (*paymail.PaymentDestinationPayload, error) {
+	if err := p.injectedError(MethodCreateP2PDestinationResponse); err != nil {
+		return nil, err
+	}
+
+	output, address, err := p.lockingScript(ctx, alias, domain, metaData)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := []uint64{satoshis}
+	if metaData != nil && len(metaData.OutputAmounts) > 0 {
+		amounts = metaData.OutputAmounts
+	}
+
+	destination := &paymail.PaymentDestinationPayload{
+		Outputs:   make([]*paymail.PaymentOutput, 0, len(amounts)),
+		Reference: paymail.GenerateReference(),
+	}
+	if metaData != nil {
+		destination.ExpiresAt = metaData.ReferenceExpiresAt
+	}
+	for _, amount := range amounts {
+		destination.Outputs = append(destination.Outputs, &paymail.PaymentOutput{
+			Address:  address,
+			Satoshis: amount,
+			Script:   output,
+		})
+	}
+
+	p.mu.Lock()
+	p.destinations[destination.Reference] = &issuedDestination{
+		paymail:     paymailKey(alias, domain),
+		destination: destination,
+	}
+	p.mu.Unlock()
+
+	return destination, nil
+}
+
+// RecordTransaction will store the transaction and return its txid
+//
+// A transaction with a reference that was already recorded returns the original payload
+// with errors.ErrTransactionAlreadyRecorded
+func (p *ServiceProvider) RecordTransaction(_ context.Context, p2pTx *paymail.P2PTransaction,
+	metaData *server.RequestMetadata,
+) (*paymail.P2PTransactionPayload, error) {
+	if err := p.injectedError(MethodRecordTransaction); err != nil {
+		return nil, err
+	}
+
+	tx, err := sdk.NewTransactionFromHex(p2pTx.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if original, ok := p.payloads[p2pTx.Reference]; ok {
+		return original, errors.ErrTransactionAlreadyRecorded
+	}
+
+	p.recorded = append(p.recorded, &RecordedTransaction{
+		MetaData:    metaData,
+		Transaction: p2pTx,
+	})
+
+	var note string
+	if p2pTx.MetaData != nil {
+		note = p2pTx.MetaData.Note
+	}
+
+	status := paymail.P2PTransactionStatusAccepted
+	if p.requireApproval {
+		status = paymail.P2PTransactionStatusPending
+	}
+
+	payload := &paymail.P2PTransactionPayload{
+		Note:   note,
+		Status: status,
+		TxID:   tx.TxID().String(),
+	}
+	p.payloads[p2pTx.Reference] = payload
+	return payload, nil
+}
+
+// ApproveTransaction will accept (or reject) the pending transaction, other statuses are not changed
+//
+// The hex of the transaction is returned when it is accepted (so it can be broadcast)
+func (p *ServiceProvider) ApproveTransaction(_ context.Context, alias, domain, reference string, approved bool,
+	_ *server.RequestMetadata,
+) (*server.TransactionApproval, error) {
+	if err := p.injectedError(MethodApproveTransaction); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recorded := p.recordedTransaction(alias, domain, reference)
+	if recorded == nil {
+		return nil, nil
+	}
+	approval := &server.TransactionApproval{Payload: p.payloads[reference]}
+	if approval.Payload.Status == paymail.P2PTransactionStatusPending {
+		if approved {
+			approval.Payload.Status = paymail.P2PTransactionStatusAccepted
+			approval.Hex = recorded.Transaction.Hex
+		} else {
+			approval.Payload.Status = paymail.P2PTransactionStatusRejected
+		}
+	}
+	return approval, nil
+}
+
+// GetTransactionStatus will return the payload of the recorded transaction (nil if not found)
+func (p *ServiceProvider) GetTransactionStatus(_ context.Context, alias, domain, reference string,
+	_ *server.RequestMetadata,
+) (*paymail.P2PTransactionPayload, error) {
+	if err := p.injectedError(MethodGetTransactionStatus); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.recordedTransaction(alias, domain, reference) == nil {
+		return nil, nil
+	}
+	return p.payloads[reference], nil
+}
+
+// recordedTransaction will return the transaction recorded for the reference of the paymail address (nil if not found)
+func (p *ServiceProvider) recordedTransaction(alias, domain, reference string) *RecordedTransaction {
+	for _, r := range p.recorded {
+		if r.Transaction.Reference == reference && r.MetaData != nil &&
+			paymailKey(r.MetaData.Alias, r.MetaData.Domain) == paymailKey(alias, domain) {
+			return r
+		}
+	}
+	return nil
+}
+
+// HealthCheck will always succeed (unless an error is injected)
+func (p *ServiceProvider) HealthCheck(_ context.Context) error {
+	return p.injectedError(MethodHealthCheck)
+}
+
+// VerifyMerkleRoots will accept all merkle roots (unless an error is injected)
+func (p *ServiceProvider) VerifyMerkleRoots(_ context.Context,
+	_ []*spv.MerkleRootConfirmationRequestItem,
+) error {
+	return p.injectedError(MethodVerifyMerkleRoots)
+}
+
+// lockingScript will return the P2PKH locking script (hex) and address for the paymail's PubKey
+func (p *ServiceProvider) lockingScript(ctx context.Context, alias, domain string,
+	metaData *server.RequestMetadata,
+) (string, string, error) {
+	info, err := p.GetPaymailByAlias(ctx, alias, domain, metaData)
+	if err != nil {
+		return "", "", err
+	} else if info == nil {
+		return "", "", errors.ErrCouldNotFindPaymail
+	}
+
+	address, err := script.NewAddressFromPublicKeyString(info.PubKey, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	lockingScript, err := p2pkh.Lock(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	return lockingScript.String(), address.AddressString, nil
+}
+
+// injectedError will return the error set for the given method (if any)
+func (p *ServiceProvider) injectedError(method Method) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.injected[method]
+}
+
+// paymailKey will return the standardized alias@domain key
+func paymailKey(alias, domain string) string {
+	return strings.ToLower(alias + "@" + domain)
+}
+
+// Ensure the ServiceProvider implements the interfaces
+var (
+	_ server.HealthChecker                      = (*ServiceProvider)(nil)
+	_ server.PaymailServiceProvider             = (*ServiceProvider)(nil)
+	_ server.PublicProfileServiceProvider       = (*ServiceProvider)(nil)
+	_ server.ReferenceProvider                  = (*ServiceProvider)(nil)
+	_ server.TransactionApprovalServiceProvider = (*ServiceProvider)(nil)
+)
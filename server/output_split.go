@@ -0,0 +1,89 @@
+package server
+
+import (
+	"math/rand/v2"
+
+	"github.com/AmanTrance/go-paymail/errors"
+)
+
+// Output split strategies of the P2P payment destination
+const (
+	OutputSplitSingle    = "single"     // A single output with the full amount (default)
+	OutputSplitFixedSize = "fixed_size" // Outputs of a fixed size, the last output has the remainder
+	OutputSplitRandom    = "random"     // Outputs of random amounts
+)
+
+// DefaultMaxSplitOutputs is the default max number of outputs of a split payment destination
+const DefaultMaxSplitOutputs = 10
+
+// OutputSplit is the strategy used to split the amount of a P2P payment destination into several outputs
+type OutputSplit struct {
+	MaxOutputs int    `json:"max_outputs"` // Max number of outputs (default is DefaultMaxSplitOutputs)
+	Size       uint64 `json:"size"`        // Satoshis of each output (OutputSplitFixedSize only)
+	Strategy   string `json:"strategy"`    // OutputSplitSingle, OutputSplitFixedSize or OutputSplitRandom
+}
+
+// validate will check the strategy and its settings
+func (s *OutputSplit) validate() error {
+	switch s.Strategy {
+	case "", OutputSplitSingle, OutputSplitRandom:
+	case OutputSplitFixedSize:
+		if s.Size == 0 {
+			return errors.ErrOutputSplitInvalid.WithDetails("fixed size requires a size")
+		}
+	default:
+		return errors.ErrOutputSplitInvalid.WithDetails("unknown strategy: " + s.Strategy)
+	}
+	if s.MaxOutputs < 0 {
+		return errors.ErrOutputSplitInvalid.WithDetails("max outputs cannot be negative")
+	}
+	return nil
+}
+
+// maxOutputs will return the max number of outputs (capped by the satoshis, every output has at least 1)
+func (s *OutputSplit) maxOutputs(satoshis uint64) uint64 {
+	maxOutputs := uint64(DefaultMaxSplitOutputs)
+	if s.MaxOutputs > 0 {
+		maxOutputs = uint64(s.MaxOutputs)
+	}
+	return min(maxOutputs, satoshis)
+}
+
+// SplitSatoshis will split the satoshis into the output amounts (totaling the satoshis) using the strategy
+//
+// A nil split (or OutputSplitSingle) returns a single amount
+func SplitSatoshis(satoshis uint64, split *OutputSplit) []uint64 {
+	if split == nil || satoshis <= 1 {
+		return []uint64{satoshis}
+	}
+
+	var amounts []uint64
+	switch split.Strategy {
+	case OutputSplitFixedSize:
+		maxOutputs := split.maxOutputs(satoshis)
+		for remaining := satoshis; remaining > 0; {
+			amount := min(split.Size, remaining)
+			if uint64(len(amounts)) == maxOutputs-1 {
+				amount = remaining
+			}
+			amounts = append(amounts, amount)
+			remaining -= amount
+		}
+	case OutputSplitRandom:
+		count := split.maxOutputs(satoshis)
+		if count > 2 {
+			count = 2 + rand.Uint64N(count-1)
+		}
+		remaining := satoshis
+		for i := uint64(0); i < count-1; i++ {
+			// Leave at least 1 satoshi for each of the remaining outputs
+			amount := 1 + rand.Uint64N(remaining-(count-1-i))
+			amounts = append(amounts, amount)
+			remaining -= amount
+		}
+		amounts = append(amounts, remaining)
+	default:
+		amounts = []uint64{satoshis}
+	}
+	return amounts
+}
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"net/http"
+	"slices"
+	"strings"
+
 	"github.com/AmanTrance/go-paymail/errors"
 	"github.com/gin-gonic/gin"
-	"net/http"
 
 	"github.com/AmanTrance/go-paymail"
 )
@@ -13,14 +16,19 @@ Incoming Data Object Example:
 
 	{
 	  "satoshis": 1000100,
+	  "scriptType": "p2pkh"
 	}
 */
 type p2pDestinationRequestBody struct {
-	Satoshis uint64 `json:"satoshis,omitempty"`
+	Satoshis   uint64 `json:"satoshis,omitempty"`
+	ScriptType string `json:"scriptType,omitempty"`
 }
 
 // p2pDestination will return an output script(s) for a destination (used with SendP2PTransaction)
 //
+// A script type other than P2PKH must be in the SupportedScriptTypes and requires the actions to implement
+// ScriptTypeDestinationProvider
+//
 // Specs: https://docs.moneybutton.com/docs/paymail-07-p2p-payment-destination.html
 func (c *Configuration) p2pDestination(context *gin.Context) {
 	var b p2pDestinationRequestBody
@@ -36,13 +44,51 @@ func (c *Configuration) p2pDestination(context *gin.Context) {
 		return
 	}
 
+	scriptType := strings.ToLower(strings.TrimSpace(b.ScriptType))
+	md.PaymentDestination.ScriptType = scriptType
+	md.OutputAmounts = SplitSatoshis(b.Satoshis, c.OutputSplit)
+	if c.ReferenceTTL > 0 {
+		expiresAt := c.now().UTC().Add(c.ReferenceTTL)
+		md.ReferenceExpiresAt = &expiresAt
+	}
+
 	var response *paymail.PaymentDestinationPayload
-	if response, err = c.actions.CreateP2PDestinationResponse(
-		context.Request.Context(), alias, domain, b.Satoshis, md,
-	); err != nil {
+	if len(scriptType) == 0 || scriptType == paymail.ScriptTypeP2PKH {
+		response, err = c.aliasActions(alias, domain).CreateP2PDestinationResponse(
+			context.Request.Context(), alias, domain, b.Satoshis, md,
+		)
+	} else {
+		provider, supported := c.aliasActions(alias, domain).(ScriptTypeDestinationProvider)
+		if !supported || !slices.Contains(c.SupportedScriptTypes, scriptType) {
+			errors.ErrorResponse(context, errors.ErrUnsupportedScriptType, c.Logger)
+			return
+		}
+		response, err = provider.CreateDestination(
+			context.Request.Context(), alias, domain, b.Satoshis, scriptType, md,
+		)
+	}
+	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
 	}
 
+	// Only the expiry returned by the provider is advertised to the sender (md.ReferenceExpiresAt is not
+	// copied), the server cannot enforce an expiry that the provider did not store (see GetReference)
+
+	// Sign the issued destination (receiver attestation)
+	if err = c.signDestination(context.Request.Context(), domain, response); err != nil {
+		c.Logger.Error().Err(err).Str("reference", response.Reference).Msg("failed to sign the payment destination")
+		errors.ErrorResponse(context, errors.ErrDestinationSigningFailed, c.Logger)
+		return
+	}
+
+	c.recordAuditEvent(context.Request.Context(), md, AuditEvent{
+		Alias:     alias,
+		Domain:    domain,
+		Reference: response.Reference,
+		Satoshis:  b.Satoshis,
+		Type:      AuditEventDestinationIssued,
+	})
+
 	context.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+)
+
+// testClock is a clock set to a fixed time
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+// noExpiryProvider does not store the expiry of the references
+type noExpiryProvider struct {
+	server.PaymailServiceProvider
+}
+
+func (p *noExpiryProvider) CreateP2PDestinationResponse(ctx context.Context, alias, domain string,
+	satoshis uint64, md *server.RequestMetadata,
+) (*paymail.PaymentDestinationPayload, error) {
+	destination, err := p.PaymailServiceProvider.CreateP2PDestinationResponse(ctx, alias, domain, satoshis, md)
+	if err == nil {
+		destination = &paymail.PaymentDestinationPayload{Outputs: destination.Outputs, Reference: destination.Reference}
+	}
+	return destination, err
+}
+
+// issueDestination will request a P2P payment destination of the satoshis
+func issueDestination(t *testing.T, handler http.Handler, satoshis uint64) *paymail.PaymentDestinationPayload {
+	t.Helper()
+	rec := requestDestination(handler, satoshis)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var destination paymail.PaymentDestinationPayload
+	if err := json.Unmarshal(rec.Body.Bytes(), &destination); err != nil {
+		t.Fatal(err)
+	}
+	return &destination
+}
+
+func TestP2PDestination_ReferenceExpiry(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+
+	t.Run("the stored expiry is advertised and enforced", func(t *testing.T) {
+		handler := newTestHandler(t, newTestProvider(), server.WithP2PCapabilities(), server.WithClock(clock))
+		destination := issueDestination(t, handler, 1000)
+		if destination.ExpiresAt == nil || !destination.ExpiresAt.After(clock.now) {
+			t.Fatalf("expected the reference to expire after the ttl, got %v", destination.ExpiresAt)
+		}
+
+		txHex := testSignedTx(t, testOutput{destination.Outputs[0].Script, destination.Outputs[0].Satoshis})
+		clock.now = destination.ExpiresAt.Add(time.Second)
+		defer func() { clock.now = time.Now() }()
+		rec := receiveTransaction(handler, txHex, destination.Reference)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-p2p-reference-expired") {
+			t.Fatalf("expected the reference to be expired, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("an expiry that is not stored is not advertised", func(t *testing.T) {
+		handler := newTestHandler(t, &noExpiryProvider{PaymailServiceProvider: newTestProvider()},
+			server.WithP2PCapabilities(),
+			server.WithClock(clock),
+		)
+		if destination := issueDestination(t, handler, 1000); destination.ExpiresAt != nil {
+			t.Errorf("expected no expiry, got %v", destination.ExpiresAt)
+		}
+	})
+}
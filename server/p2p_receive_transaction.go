@@ -1,12 +1,17 @@
 package server
 
 import (
+	stderrors "errors"
+
 	"github.com/AmanTrance/go-paymail/errors"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 	"net/http"
+	"strconv"
 
 	"github.com/AmanTrance/go-paymail"
 	"github.com/AmanTrance/go-paymail/spv"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
 )
 
 type p2pPayloadFormat uint
@@ -32,31 +37,11 @@ Incoming Data Object Example:
 
 // p2pReceiveTx will receive a P2P transaction (from previous request: P2P Payment Destination)
 //
+// The transaction can be given either as a raw "hex" or as a "beef", but not both
+//
 // Specs: https://docs.moneybutton.com/docs/paymail-06-p2p-transactions.html
 func (c *Configuration) p2pReceiveTx(context *gin.Context) {
-	p2pFormat := basicP2pPayload
-
-	incomingPaymail := context.Param(PaymailAddressParamName)
-
-	requestPayload, _, md, err := processP2pReceiveTxRequest(c, context.Request, incomingPaymail, p2pFormat)
-	if err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
-		return
-	}
-
-	if len(requestPayload.Hex) == 0 {
-		panic("empty hex after parsing!")
-	}
-
-	var response *paymail.P2PTransactionPayload
-	if response, err = c.actions.RecordTransaction(
-		context.Request.Context(), requestPayload.P2PTransaction, md,
-	); err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
-		return
-	}
-
-	context.JSON(http.StatusOK, response)
+	c.receiveP2pTx(context, basicP2pPayload)
 }
 
 /*
@@ -74,36 +59,180 @@ Incoming Data Object Example:
 */
 // p2pReceiveBeefTx will receive a P2P transaction in BEEF format
 func (c *Configuration) p2pReceiveBeefTx(context *gin.Context) {
-	p2pFormat := beefP2pPayload
+	c.receiveP2pTx(context, beefP2pPayload)
+}
+
+// receiveP2pTx will process, verify and record the incoming P2P transaction
+//
+// A dry-run (DryRunHeaderName or DryRunParamName) runs all the validation but does not record the transaction
+func (c *Configuration) receiveP2pTx(context *gin.Context, p2pFormat p2pPayloadFormat) {
 	incomingPaymail := context.Param(PaymailAddressParamName)
 
 	requestPayload, dBeef, md, err := processP2pReceiveTxRequest(c, context.Request, incomingPaymail, p2pFormat)
 	if err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
+		log := c.Logger.With().Str("paymail", incomingPaymail).Logger()
+		errors.ErrorResponse(context, err, &log)
 		return
 	}
 
+	log := c.Logger.With().
+		Str("alias", requestPayload.incomingPaymailAlias).
+		Str("domain", requestPayload.incomingPaymailDomain).
+		Str("reference", requestPayload.Reference).
+		Logger()
+
 	if len(requestPayload.Hex) == 0 {
-		panic("empty hex after parsing!")
+		errors.ErrorResponse(context, errors.ErrMissingFieldHex, &log)
+		return
 	}
 
-	if dBeef == nil {
-		panic("empty beef after parsing!")
+	if requestPayload.format == beefP2pPayload {
+		if dBeef == nil {
+			errors.ErrorResponse(context, errors.ErrMissingFieldBEEF, &log)
+			return
+		}
+
+		err = spv.ExecuteSimplifiedPaymentVerification(
+			context.Request.Context(), dBeef, c.merkleRootVerifier(md.Alias, md.Domain),
+		)
+		if err != nil {
+			log.Warn().Err(err).Msg("simplified payment verification failed")
+			if stderrors.Is(err, errors.ErrInvalidMerkleProof) {
+				errors.ErrorResponse(context, errors.ErrInvalidMerkleProof, &log)
+			} else {
+				errors.ErrorResponse(context, errors.ErrSPVFailed, &log)
+			}
+			return
+		}
 	}
 
-	err = spv.ExecuteSimplifiedPaymentVerification(context.Request.Context(), dBeef, c.actions)
-	if err != nil {
-		errors.ErrorResponse(context, errors.ErrSPVFailed, c.Logger)
+	if isDryRun(context) {
+		var tx *sdk.Transaction
+		if tx, err = sdk.NewTransactionFromHex(requestPayload.Hex); err != nil {
+			errors.ErrorResponse(context, errors.ErrProcessingHex, &log)
+			return
+		}
+
+		log.Info().Str("txid", tx.TxID().String()).Msg("p2p transaction validated (dry-run)")
+		context.JSON(http.StatusOK, &paymail.P2PTransactionPayload{
+			DryRun:  true,
+			Note:    requestPayload.MetaData.Note,
+			Outputs: requestPayload.paidOutputs,
+			TxID:    tx.TxID().String(),
+		})
 		return
 	}
 
+	// Broadcast before recording (a transaction that failed to broadcast is not recorded)
+	var broadcastResult *BroadcastResult
+	if c.broadcaster != nil && c.BroadcastBeforeRecord {
+		if broadcastResult, err = c.broadcast(context, requestPayload.Hex, &log); err != nil {
+			errors.ErrorResponse(context, err, &log)
+			return
+		}
+	}
+
 	var response *paymail.P2PTransactionPayload
-	if response, err = c.actions.RecordTransaction(
-		context.Request.Context(), requestPayload.P2PTransaction, md,
-	); err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
+	alreadyRecorded := false
+	response, err = c.recordTransaction(context.Request.Context(), requestPayload.P2PTransaction, md)
+	if stderrors.Is(err, errors.ErrServerBusy) {
+		log.Warn().Err(err).Msg("no record slot available")
+	}
+	if err != nil {
+		// A retried transaction returns the original payload
+		if !stderrors.Is(err, errors.ErrTransactionAlreadyRecorded) || response == nil {
+			errors.ErrorResponse(context, err, &log)
+			return
+		}
+		alreadyRecorded = true
+	}
+
+	// A transaction without a status (from the service provider) is accepted
+	if response != nil && len(response.Status) == 0 {
+		response.Status = paymail.P2PTransactionStatusAccepted
+	}
+
+	// Broadcast after recording (a transaction that failed to broadcast is still recorded)
+	// A transaction pending the approval of the receiver is not broadcast
+	if c.broadcaster != nil && !c.BroadcastBeforeRecord &&
+		(response == nil || response.Status == paymail.P2PTransactionStatusAccepted) {
+		if broadcastResult, err = c.broadcast(context, requestPayload.Hex, &log); err != nil {
+			errors.ErrorResponse(context, err, &log)
+			return
+		}
+	}
+	if broadcastResult != nil && response != nil {
+		response.BroadcastStatus = broadcastResult.Status
+	}
+
+	// Return the issued outputs that were paid (unless set by the service provider)
+	if response != nil && len(response.Outputs) == 0 {
+		response.Outputs = requestPayload.paidOutputs
+	}
+
+	if alreadyRecorded {
+		log.Info().Str("txid", response.TxID).Msg("p2p transaction was already recorded")
+		context.JSON(http.StatusOK, response)
 		return
 	}
 
+	// The txid of a BEEF transaction is always the subject (latest) transaction
+	if dBeef != nil && response != nil {
+		response.TxID = dBeef.GetLatestTx().TxID().String()
+	}
+
+	if response != nil {
+		log.Info().Str("txid", response.TxID).Msg("p2p transaction recorded")
+	}
+	event := AuditEvent{
+		Alias:     requestPayload.incomingPaymailAlias,
+		Domain:    requestPayload.incomingPaymailDomain,
+		Reference: requestPayload.Reference,
+		Type:      AuditEventTransactionRecorded,
+	}
+	for _, output := range requestPayload.paidOutputs {
+		event.Satoshis += output.Satoshis
+	}
+	if response != nil {
+		event.TxID = response.TxID
+	}
+	c.recordAuditEvent(context.Request.Context(), md, event)
+
 	context.JSON(http.StatusOK, response)
 }
+
+// merkleRootVerifier will return the verifier of the BEEF merkle roots (nil if the validation is disabled)
+//
+// The HeaderValidator is used if set, otherwise the merkle roots are verified by the service provider
+// of the paymail address (see WithDomainConfig())
+func (c *Configuration) merkleRootVerifier(alias, domain string) spv.MerkleRootVerifier {
+	if c.MerkleProofValidationDisabled {
+		return nil
+	} else if c.headerValidator != nil {
+		return spv.NewHeaderValidatorVerifier(c.headerValidator)
+	}
+	return c.aliasActions(alias, domain)
+}
+
+// broadcast will submit the transaction using the configured Broadcaster (with the server callback)
+func (c *Configuration) broadcast(context *gin.Context, txHex string, log *zerolog.Logger) (*BroadcastResult, error) {
+	result, err := c.broadcaster.Broadcast(context.Request.Context(), txHex, c.BroadcastCallbackURL)
+	if err != nil {
+		log.Error().Err(err).Msg("p2p transaction broadcast failed")
+		return nil, errors.ErrBroadcastFailed
+	}
+	if result != nil {
+		log.Debug().Str("txid", result.TxID).Str("status", result.Status).Msg("p2p transaction broadcast")
+	}
+	return result, nil
+}
+
+// isDryRun will return true if a dry-run was requested using the header or the query param
+func isDryRun(context *gin.Context) bool {
+	value := context.GetHeader(DryRunHeaderName)
+	if len(value) == 0 {
+		value = context.Query(DryRunParamName)
+	}
+	dryRun, _ := strconv.ParseBool(value)
+	return dryRun
+}
@@ -2,17 +2,28 @@ package server
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/AmanTrance/go-paymail/errors"
 
 	"github.com/AmanTrance/go-paymail"
 )
 
+// maxTxBodyOverhead is the extra body size allowed for the metadata and reference of a P2P transaction
+const maxTxBodyOverhead = 64 * 1024
+
 func parseP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPaymail string, format p2pPayloadFormat) (*p2pReceiveTxReqPayload, error) {
 	alias, domain, paymailAddress := paymail.SanitizePaymail(incomingPaymail)
 	if len(paymailAddress) == 0 {
-		return nil, errors.ErrInvalidPaymail
+		return nil, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail)
 
 	} else if !c.IsAllowedDomain(domain) {
 		return nil, errors.ErrDomainUnknown
@@ -23,13 +34,37 @@ func parseP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPayma
 		incomingPaymailDomain: domain,
 	}
 
-	var p2pTransaction paymail.P2PTransaction
-	err := json.NewDecoder(req.Body).Decode(&p2pTransaction)
+	// Bound the body before decoding (hex encoding doubles the tx size)
+	body := req.Body
+	if c.MaxTxSizeBytes > 0 {
+		body = http.MaxBytesReader(nil, req.Body, 2*c.MaxTxSizeBytes+maxTxBodyOverhead)
+	}
+
+	p2pTransaction, err := decodeP2pTransaction(req, body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			return nil, errors.ErrTxTooLarge
+		}
 		return nil, errors.ErrCannotBindRequest
 	}
+	if p2pTransaction.MetaData == nil {
+		p2pTransaction.MetaData = &paymail.P2PMetaData{}
+	}
+	if c.MaxTxSizeBytes > 0 && (int64(len(p2pTransaction.Hex)) > 2*c.MaxTxSizeBytes ||
+		int64(len(p2pTransaction.Beef)) > 2*c.MaxTxSizeBytes) {
+		return nil, errors.ErrTxTooLarge
+	}
 	if len(p2pTransaction.Reference) == 0 {
 		return nil, errors.ErrMissingFieldReference
+	} else if err = c.validateReference(p2pTransaction.Reference); err != nil {
+		return nil, errors.ErrInvalidReference.WithDetails(err.Error())
+	}
+	if format == basicP2pPayload && len(p2pTransaction.Beef) > 0 {
+		if len(p2pTransaction.Hex) > 0 {
+			return nil, errors.ErrInvalidParameter
+		}
+		format = beefP2pPayload
 	}
 	if format == basicP2pPayload {
 		if len(p2pTransaction.Hex) == 0 {
@@ -40,19 +75,54 @@ func parseP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPayma
 			return nil, errors.ErrMissingFieldBEEF
 		}
 	}
-	vErr := validateMetadata(c, p2pTransaction.MetaData)
+	requestData.format = format
+	vErr := validateMetadata(c, domain, p2pTransaction.MetaData)
 
 	if vErr != nil {
 		return nil, vErr
 	}
 
-	requestData.P2PTransaction = &p2pTransaction
+	requestData.P2PTransaction = p2pTransaction
 	return &requestData, nil
 }
 
-func validateMetadata(c *Configuration, metadata *paymail.P2PMetaData) error {
+// decodeP2pTransaction will decode the P2P transaction from the JSON body (including the nested metadata)
+//
+// Form encoded requests are read from the params instead (hex, beef, reference and the metadata as a JSON object)
+func decodeP2pTransaction(req *http.Request, body io.ReadCloser) (*paymail.P2PTransaction, error) {
+	p2pTransaction := new(paymail.P2PTransaction)
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		if err := json.NewDecoder(body).Decode(p2pTransaction); err != nil {
+			return nil, err
+		}
+		return p2pTransaction, nil
+	}
+
+	req.Body = body
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = req.ParseMultipartForm(maxTxBodyOverhead)
+	} else {
+		err = req.ParseForm()
+	}
+	if err != nil {
+		return nil, err
+	}
+	p2pTransaction.Hex = req.PostFormValue("hex")
+	p2pTransaction.Beef = req.PostFormValue("beef")
+	p2pTransaction.Reference = req.PostFormValue("reference")
+	if metadata := req.PostFormValue("metadata"); len(metadata) > 0 {
+		if err = json.Unmarshal([]byte(metadata), &p2pTransaction.MetaData); err != nil {
+			return nil, err
+		}
+	}
+	return p2pTransaction, nil
+}
+
+func validateMetadata(c *Configuration, domain string, metadata *paymail.P2PMetaData) error {
 	// Check signature if: 1) sender validation enabled or 2) a signature was given (optional)
-	if c.SenderValidationEnabled || len(metadata.Signature) > 0 {
+	if c.senderValidationEnabled(domain) || len(metadata.Signature) > 0 {
 
 		// Check required fields for signature validation
 		if len(metadata.Signature) == 0 {
@@ -64,5 +134,40 @@ func validateMetadata(c *Configuration, metadata *paymail.P2PMetaData) error {
 		}
 	}
 
+	// The note (optional) is echoed back and stored, remove the control characters and check the length
+	// Callers rendering the note must still escape it (e.g. HTML)
+	metadata.Note = sanitizeNote(metadata.Note)
+	if c.MaxNoteLength > 0 && utf8.RuneCountInString(metadata.Note) > c.MaxNoteLength {
+		return errors.ErrNoteTooLong
+	}
+
+	// The sender callback (optional) must be https
+	if len(metadata.CallbackURL) > 0 {
+		if callbackURL, err := url.Parse(metadata.CallbackURL); err != nil ||
+			callbackURL.Scheme != "https" || len(callbackURL.Host) == 0 {
+			return errors.ErrInvalidCallbackURL
+		}
+	}
+
+	return nil
+}
+
+// sanitizeNote will remove the control characters (except new lines and tabs) and invalid UTF-8 from the note
+func sanitizeNote(note string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(note, ""))
+}
+
+// validateReference will check the reference with paymail.ValidateReference() and the min length (if set)
+func (c *Configuration) validateReference(reference string) error {
+	if err := paymail.ValidateReference(reference); err != nil {
+		return err
+	} else if c.MinReferenceLength > 0 && len(reference) < c.MinReferenceLength {
+		return fmt.Errorf("reference must be at least %d characters long", c.MinReferenceLength)
+	}
 	return nil
 }
@@ -2,15 +2,19 @@ package server
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/AmanTrance/go-paymail/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/AmanTrance/go-paymail"
 	"github.com/AmanTrance/go-paymail/beef"
+	"github.com/AmanTrance/go-paymail/spv"
 
-	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
 	script "github.com/bsv-blockchain/go-sdk/script"
 	sdk "github.com/bsv-blockchain/go-sdk/transaction"
 )
@@ -18,6 +22,8 @@ import (
 type p2pReceiveTxReqPayload struct {
 	*paymail.P2PTransaction
 	incomingPaymailAlias, incomingPaymailDomain string
+	format                                      p2pPayloadFormat
+	paidOutputs                                 []*paymail.PaymentOutput
 }
 
 func processP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPaymail string, format p2pPayloadFormat) (
@@ -28,26 +34,48 @@ func processP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPay
 		return returnError(err)
 	}
 
-	md := CreateMetadata(req, payload.incomingPaymailAlias, payload.incomingPaymailDomain, "")
+	md := c.createMetadata(req, payload.incomingPaymailAlias, payload.incomingPaymailDomain, "")
 	err = verifyIncomingPaymail(req.Context(), c, md, payload.incomingPaymailAlias, payload.incomingPaymailDomain)
 
 	if err != nil {
 		return returnError(err)
 	}
 
-	tx, beefData, err := getProcessedTxData(payload, format, c.Logger)
+	tx, beefData, err := getProcessedTxData(payload, payload.format, c.Logger)
 	if err != nil {
 		return returnError(err)
 	}
 
-	if c.SenderValidationEnabled || len(payload.MetaData.Signature) > 0 {
-		err = verifySignature(payload.MetaData, tx.TxID().String())
+	err = verifyReference(req.Context(), c, payload, tx)
+	if err != nil {
+		return returnError(err)
+	}
+
+	// Structural checks before the transaction is recorded (or broadcast), only the outputs
+	// paying the receiver (the issued outputs) must be standard (all the outputs without a verified reference)
+	var isReceiverOutput func(lockingScript *script.Script) bool
+	if payload.paidOutputs != nil {
+		isReceiverOutput = payload.isPaidScript
+	}
+	if err = spv.VerifyTransaction(tx.Hex(), isReceiverOutput); err != nil {
+		return returnError(err)
+	}
+
+	if c.senderValidationEnabled(payload.incomingPaymailDomain) || len(payload.MetaData.Signature) > 0 {
+		err = c.verifySignature(payload.MetaData, tx.TxID().String())
 		if err != nil {
 			return returnError(err)
 		}
+
+		if c.SenderPKIValidationEnabled {
+			err = verifySenderPubKey(req.Context(), payload.MetaData, c.Logger)
+			if err != nil {
+				return returnError(err)
+			}
+		}
 	}
 
-	if format == beefP2pPayload {
+	if payload.format == beefP2pPayload {
 		payload.Hex = tx.String()
 		payload.DecodedBeef = beefData
 	}
@@ -55,6 +83,13 @@ func processP2pReceiveTxRequest(c *Configuration, req *http.Request, incomingPay
 	return payload, beefData, md, nil
 }
 
+// isPaidScript will return true if the locking script is the script of a paid (issued) output
+func (p *p2pReceiveTxReqPayload) isPaidScript(lockingScript *script.Script) bool {
+	return lockingScript != nil && slices.ContainsFunc(p.paidOutputs, func(output *paymail.PaymentOutput) bool {
+		return strings.EqualFold(output.Script, lockingScript.String())
+	})
+}
+
 func getProcessedTxData(payload *p2pReceiveTxReqPayload, format p2pPayloadFormat, log *zerolog.Logger) (*sdk.Transaction, *beef.DecodedBEEF, error) {
 	var processedTx *sdk.Transaction
 	var beefData *beef.DecodedBEEF
@@ -88,7 +123,7 @@ func verifyIncomingPaymail(ctx context.Context, c *Configuration, md *RequestMet
 	var foundPaymail *paymail.AddressInformation
 	var err error
 
-	foundPaymail, err = c.actions.GetPaymailByAlias(ctx, alias, domain, md)
+	foundPaymail, err = c.aliasActions(alias, domain).GetPaymailByAlias(ctx, alias, domain, md)
 	if err != nil {
 		return err
 	} else if foundPaymail == nil {
@@ -98,23 +133,93 @@ func verifyIncomingPaymail(ctx context.Context, c *Configuration, md *RequestMet
 	return nil
 }
 
-func verifySignature(metadata *paymail.P2PMetaData, txID string) error {
+// verifyReference will check that the reference was issued for the paymail and that the tx pays the issued outputs
+// (matching both the locking script and the satoshis of every issued output, see paymail.MatchOutputs)
+//
+// The reference is not verified if the actions do not implement ReferenceProvider (no paid outputs are set)
+func verifyReference(ctx context.Context, c *Configuration, payload *p2pReceiveTxReqPayload, tx *sdk.Transaction) error {
+	provider, ok := c.aliasActions(payload.incomingPaymailAlias, payload.incomingPaymailDomain).(ReferenceProvider)
+	if !ok {
+		return nil
+	}
+
+	destination, err := provider.GetReference(ctx, payload.incomingPaymailAlias, payload.incomingPaymailDomain, payload.Reference)
+	if err != nil {
+		return err
+	} else if destination == nil {
+		return errors.ErrUnknownReference
+	} else if destination.ExpiresAt != nil && c.now().After(*destination.ExpiresAt) {
+		return errors.ErrReferenceExpired
+	}
+
+	// Every issued output must be paid by a different tx output (the issued outputs can share a script)
+	var total uint64
+	payload.paidOutputs = make([]*paymail.PaymentOutput, 0, len(destination.Outputs))
+	for index, matched := range paymail.MatchOutputs(tx.Outputs, destination.Outputs) {
+		expected := destination.Outputs[index]
+		if matched < 0 {
+			return errors.ErrTransactionMismatch.WithDetails(fmt.Sprintf(
+				"missing output %d paying %d satoshis to script %s", index, expected.Satoshis, expected.Script,
+			))
+		}
+		payload.paidOutputs = append(payload.paidOutputs, &paymail.PaymentOutput{
+			Address:  expected.Address,
+			Satoshis: tx.Outputs[matched].Satoshis,
+			Script:   expected.Script,
+		})
+		total += tx.Outputs[matched].Satoshis
+	}
+
+	// The receiver must be paid (outputs without an issued amount can be paid any amount above the dust limit)
+	if total == 0 {
+		return errors.ErrTransactionZeroAmount
+	} else if c.exceedsMaxSatoshis(total) {
+		return errors.ErrAmountExceedsLimit
+	}
+
+	return nil
+}
+
+// verifySignature will verify the signature of the tx id (using the signature scheme of the metadata)
+func (c *Configuration) verifySignature(metadata *paymail.P2PMetaData, txID string) error {
+	scheme, err := c.signatureScheme(metadata.SignatureScheme)
+	if err != nil {
+		return err
+	}
+
 	// Get the address from pubKey
 	var rawAddress *script.Address
-	var err error
-
 	if rawAddress, err = script.NewAddressFromPublicKeyString(metadata.PublicKey, true); err != nil {
 		return errors.ErrInvalidPubKey
 	}
 
-	// Validate the signature of the tx id
-	if err = bsm.VerifyMessage(rawAddress.AddressString, []byte(metadata.Signature), []byte(txID)); err != nil {
+	// Validate the (base64 encoded) signature of the tx id
+	if err = c.messageVerifier(scheme).Verify(rawAddress.AddressString, metadata.Signature, txID); err != nil {
 		return errors.ErrInvalidSignature
 	}
 
 	return nil
 }
 
+// verifySenderPubKey will check that the pubkey belongs to the sender (using the PKI of the sender paymail)
+func verifySenderPubKey(ctx context.Context, metadata *paymail.P2PMetaData, log *zerolog.Logger) error {
+	if err := paymail.ValidatePaymail(metadata.Sender); err != nil {
+		return invalidPaymailError(metadata.Sender, errors.ErrInvalidSenderHandle)
+	}
+
+	senderPubKey, err := getSenderPubKey(ctx, metadata.Sender)
+	if err != nil {
+		log.Warn().Err(err).Str("sender", metadata.Sender).Msg("failed to get the sender pubkey")
+		return errors.ErrPubKeyMismatch
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(senderPubKey.Compressed()), metadata.PublicKey) {
+		return errors.ErrPubKeyMismatch
+	}
+
+	return nil
+}
+
 func returnError(err error) (
 	*p2pReceiveTxReqPayload, *beef.DecodedBEEF, *RequestMetadata, error,
 ) {
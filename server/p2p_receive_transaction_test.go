@@ -0,0 +1,287 @@
+package server_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	script "github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// legacyProvider only implements the PaymailServiceProvider (not the optional ReferenceProvider)
+type legacyProvider struct {
+	server.PaymailServiceProvider
+}
+
+// testOutput is an output (script hex and satoshis) of a test transaction
+type testOutput struct {
+	script   string
+	satoshis uint64
+}
+
+// testSignedTx will return the hex of a (push only) signed transaction paying the outputs
+func testSignedTx(t *testing.T, outputs ...testOutput) string {
+	t.Helper()
+	unlockingScript, err := script.NewFromHex("020102")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := sdk.NewTransaction()
+	tx.AddInput(&sdk.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: unlockingScript,
+		SequenceNumber:  0xffffffff,
+	})
+	for _, output := range outputs {
+		lockingScript, err := script.NewFromHex(output.script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: output.satoshis})
+	}
+	return tx.Hex()
+}
+
+// receiveTransaction will submit the P2P transaction (hex) to the paymail address
+func receiveTransaction(handler http.Handler, txHex, reference string) *httptest.ResponseRecorder {
+	return postTransaction(handler, &paymail.P2PTransaction{Hex: txHex, Reference: reference})
+}
+
+// postTransaction will submit the P2P transaction to the paymail address
+func postTransaction(handler http.Handler, transaction *paymail.P2PTransaction) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(transaction)
+	req := httptest.NewRequest(http.MethodPost,
+		"/v1/bsvalias/receive-transaction/"+testAlias+"@"+testDomain, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReceiveTransaction_UnknownReference(t *testing.T) {
+	handler := newTestHandler(t, newTestProvider(), server.WithP2PCapabilities())
+
+	rec := receiveTransaction(handler, testSignedTx(t, testOutput{testOutputScript, 1000}), "unknown-reference")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-p2p-reference-unknown") {
+		t.Fatalf("expected an unknown reference, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReceiveTransaction_WithoutReferenceProvider(t *testing.T) {
+	provider := newTestProvider()
+	handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider}, server.WithP2PCapabilities())
+
+	// The reference is not verified without a ReferenceProvider
+	txHex := testSignedTx(t, testOutput{testOutputScript, 1000})
+	if rec := receiveTransaction(handler, txHex, "unverified-reference"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !provider.WasRecorded(txHex, "unverified-reference") {
+		t.Error("expected the transaction to be recorded")
+	}
+}
+
+// referenceProvider has issued the destination for every reference
+type referenceProvider struct {
+	server.PaymailServiceProvider
+	destination *paymail.PaymentDestinationPayload
+}
+
+func (p *referenceProvider) GetReference(context.Context, string, string, string,
+) (*paymail.PaymentDestinationPayload, error) {
+	return p.destination, nil
+}
+
+func TestReceiveTransaction_IssuedOutputs(t *testing.T) {
+	provider := &referenceProvider{PaymailServiceProvider: newTestProvider()}
+	handler := newTestHandler(t, provider, server.WithP2PCapabilities())
+
+	// The any amount output is issued before the exact amount output of the same script
+	provider.destination = &paymail.PaymentDestinationPayload{Outputs: []*paymail.PaymentOutput{
+		{Script: testOutputScript},
+		{Script: testOutputScript, Satoshis: 1000},
+	}}
+
+	t.Run("exact amount is matched first", func(t *testing.T) {
+		txHex := testSignedTx(t, testOutput{testOutputScript, 1000}, testOutput{testOutputScript, 500})
+		if rec := receiveTransaction(handler, txHex, "reference-1"); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("missing exact amount", func(t *testing.T) {
+		txHex := testSignedTx(t, testOutput{testOutputScript, 999}, testOutput{testOutputScript, 500})
+		if rec := receiveTransaction(handler, txHex, "reference-2"); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("any amount below the dust limit", func(t *testing.T) {
+		txHex := testSignedTx(t, testOutput{testOutputScript, 1000}, testOutput{testOutputScript, 0})
+		if rec := receiveTransaction(handler, txHex, "reference-3"); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestReceiveTransaction_MinReferenceLength(t *testing.T) {
+	provider := newTestProvider()
+	txHex := testSignedTx(t, testOutput{testOutputScript, 1000})
+
+	// Short references are accepted by default
+	handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider}, server.WithP2PCapabilities())
+	if rec := receiveTransaction(handler, txHex, "ref1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	handler = newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider},
+		server.WithP2PCapabilities(),
+		server.WithMinReferenceLength(8),
+	)
+	rec := receiveTransaction(handler, txHex, "ref2")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-p2p-reference-invalid") {
+		t.Fatalf("expected an invalid reference, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReceiveTransaction_Idempotent(t *testing.T) {
+	provider := newTestProvider()
+	handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider}, server.WithP2PCapabilities())
+	txHex := testSignedTx(t, testOutput{testOutputScript, 1000})
+
+	// The retried transaction returns the original payload (without recording it again)
+	var payloads [2]paymail.P2PTransactionPayload
+	for i := range payloads {
+		rec := receiveTransaction(handler, txHex, "reference-1")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("submission %d: expected 200, got %d: %s", i+1, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &payloads[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if payloads[0].TxID != payloads[1].TxID || len(payloads[0].TxID) == 0 {
+		t.Errorf("expected the same txid, got %q and %q", payloads[0].TxID, payloads[1].TxID)
+	}
+	if recorded := len(provider.RecordedTransactions()); recorded != 1 {
+		t.Errorf("expected the transaction to be recorded once, got %d", recorded)
+	}
+}
+
+func TestReceiveTransaction_SignedBySender(t *testing.T) {
+	handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: newTestProvider()},
+		server.WithP2PCapabilities(),
+		server.WithSenderValidation(),
+	)
+	privateKey, err := primitives.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyHex := hex.EncodeToString(privateKey.Serialize())
+
+	t.Run("signed transaction is accepted", func(t *testing.T) {
+		transaction := &paymail.P2PTransaction{
+			Hex:       testSignedTx(t, testOutput{testOutputScript, 1000}),
+			MetaData:  &paymail.P2PMetaData{Sender: "bob@test.com"},
+			Reference: "reference-1",
+		}
+		if err = transaction.Sign(privateKeyHex); err != nil {
+			t.Fatal(err)
+		}
+		if rec := postTransaction(handler, transaction); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("signature of another transaction is rejected", func(t *testing.T) {
+		transaction := &paymail.P2PTransaction{
+			Hex:       testSignedTx(t, testOutput{testOutputScript, 1000}),
+			MetaData:  &paymail.P2PMetaData{Sender: "bob@test.com"},
+			Reference: "reference-2",
+		}
+		if err = transaction.Sign(privateKeyHex); err != nil {
+			t.Fatal(err)
+		}
+		transaction.Hex = testSignedTx(t, testOutput{testOutputScript, 2000})
+		if rec := postTransaction(handler, transaction); rec.Code == http.StatusOK {
+			t.Fatalf("expected the signature to be rejected, got %d", rec.Code)
+		}
+	})
+}
+
+// recordingProvider calls the record func instead of recording the transaction
+type recordingProvider struct {
+	server.PaymailServiceProvider
+	record func()
+}
+
+func (p *recordingProvider) RecordTransaction(ctx context.Context, p2pTx *paymail.P2PTransaction,
+	md *server.RequestMetadata,
+) (*paymail.P2PTransactionPayload, error) {
+	p.record()
+	return p.PaymailServiceProvider.RecordTransaction(ctx, p2pTx, md)
+}
+
+func TestReceiveTransaction_MaxConcurrentRecords(t *testing.T) {
+	t.Run("slot is released after a panic", func(t *testing.T) {
+		panics := true
+		provider := &recordingProvider{PaymailServiceProvider: newTestProvider(), record: func() {
+			if panics {
+				panics = false
+				panic("record failed")
+			}
+		}}
+		handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider},
+			server.WithP2PCapabilities(),
+			server.WithMaxConcurrentRecords(1, 10*time.Millisecond),
+		)
+
+		txHex := testSignedTx(t, testOutput{testOutputScript, 1000})
+		if rec := receiveTransaction(handler, txHex, "reference-1"); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := receiveTransaction(handler, txHex, "reference-2"); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("busy after the timeout", func(t *testing.T) {
+		recording, done := make(chan struct{}), make(chan struct{})
+		provider := &recordingProvider{PaymailServiceProvider: newTestProvider(), record: func() {
+			close(recording)
+			<-done
+		}}
+		handler := newTestHandler(t, &legacyProvider{PaymailServiceProvider: provider},
+			server.WithP2PCapabilities(),
+			server.WithMaxConcurrentRecords(1, 10*time.Millisecond),
+		)
+
+		// The first transaction holds the only slot (until the test is done)
+		txHex := testSignedTx(t, testOutput{testOutputScript, 1000})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receiveTransaction(handler, txHex, "reference-1")
+		}()
+		<-recording
+		defer wg.Wait()
+		defer close(done)
+
+		rec := receiveTransaction(handler, testSignedTx(t, testOutput{testOutputScript, 2000}), "reference-2")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	stderrors "errors"
+
 	"github.com/AmanTrance/go-paymail"
 	"github.com/AmanTrance/go-paymail/errors"
 	"github.com/gin-gonic/gin"
@@ -13,7 +15,7 @@ func (c *Configuration) GetPaymailAndCreateMetadata(context *gin.Context, satosh
 	// Parse, sanitize and basic validation
 	alias, domain, paymailAddress := paymail.SanitizePaymail(incomingPaymail)
 	if len(paymailAddress) == 0 {
-		errors.ErrorResponse(context, errors.ErrInvalidPaymail, c.Logger)
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
 		return
 	}
 	if !c.IsAllowedDomain(domain) {
@@ -30,14 +32,20 @@ func (c *Configuration) GetPaymailAndCreateMetadata(context *gin.Context, satosh
 	if paymentRequest.Satoshis == 0 {
 		errors.ErrorResponse(context, errors.ErrMissingFieldSatoshis, c.Logger)
 		return
+	} else if paymentRequest.Satoshis > paymail.MaxSatoshis {
+		errors.ErrorResponse(context, errors.ErrInvalidSatoshis, c.Logger)
+		return
+	} else if c.exceedsMaxSatoshis(paymentRequest.Satoshis) {
+		errors.ErrorResponse(context, errors.ErrAmountExceedsLimit, c.Logger)
+		return
 	}
 
 	// Create the metadata struct
-	md = CreateMetadata(context.Request, alias, domain, "")
+	md = c.createMetadata(context.Request, alias, domain, "")
 	md.PaymentDestination = paymentRequest
 
 	// Get from the data layer
-	foundPaymail, err := c.actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	foundPaymail, err := c.aliasActions(alias, domain).GetPaymailByAlias(context.Request.Context(), alias, domain, md)
 	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
@@ -50,3 +58,16 @@ func (c *Configuration) GetPaymailAndCreateMetadata(context *gin.Context, satosh
 	ok = true
 	return
 }
+
+// invalidPaymailError will return the invalid paymail error with the reason (from paymail.ValidatePaymail)
+func invalidPaymailError(incomingPaymail string, base errors.SPVError) errors.SPVError {
+	if reason := stderrors.Unwrap(paymail.ValidatePaymail(incomingPaymail)); reason != nil {
+		return base.WithDetails(reason.Error())
+	}
+	return base
+}
+
+// exceedsMaxSatoshis will return true if the amount is above the max satoshis (if set)
+func (c *Configuration) exceedsMaxSatoshis(satoshis uint64) bool {
+	return c.MaxSatoshis > 0 && satoshis > c.MaxSatoshis
+}
@@ -0,0 +1,64 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+)
+
+// testMaxSatoshis is the max satoshis of the test server
+const testMaxSatoshis = 10_000
+
+func requestDestination(handler http.Handler, satoshis uint64) *httptest.ResponseRecorder {
+	body := `{"satoshis":` + strconv.FormatUint(satoshis, 10) + `}`
+	req := httptest.NewRequest(http.MethodPost,
+		"/v1/bsvalias/p2p-payment-destination/"+testAlias+"@"+testDomain, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMaxSatoshis_Destination(t *testing.T) {
+	handler := newTestHandler(t, newTestProvider(), server.WithP2PCapabilities(), server.WithMaxSatoshis(testMaxSatoshis))
+
+	if rec := requestDestination(handler, testMaxSatoshis); rec.Code != http.StatusOK {
+		t.Fatalf("expected the max satoshis to be allowed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := requestDestination(handler, testMaxSatoshis+1)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-amount-exceeds-limit") {
+		t.Fatalf("expected the amount to exceed the limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// No limit by default (up to the max supply)
+	handler = newTestHandler(t, newTestProvider(), server.WithP2PCapabilities())
+	if rec = requestDestination(handler, paymail.MaxSatoshis); rec.Code != http.StatusOK {
+		t.Fatalf("expected no limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMaxSatoshis_ReceivedTransaction(t *testing.T) {
+	provider := &referenceProvider{
+		PaymailServiceProvider: newTestProvider(),
+		destination: &paymail.PaymentDestinationPayload{Outputs: []*paymail.PaymentOutput{
+			{Script: testOutputScript}, // Any amount
+		}},
+	}
+	handler := newTestHandler(t, provider, server.WithP2PCapabilities(), server.WithMaxSatoshis(testMaxSatoshis))
+
+	txHex := testSignedTx(t, testOutput{testOutputScript, testMaxSatoshis})
+	if rec := receiveTransaction(handler, txHex, "reference-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the max satoshis to be allowed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	txHex = testSignedTx(t, testOutput{testOutputScript, testMaxSatoshis + 1})
+	rec := receiveTransaction(handler, txHex, "reference-2")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "error-amount-exceeds-limit") {
+		t.Fatalf("expected the amount to exceed the limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
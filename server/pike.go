@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/AmanTrance/go-paymail/errors"
 	"net/http"
@@ -44,7 +45,7 @@ func (c *Configuration) pikeGetOutputTemplates(rc *gin.Context) {
 		return
 	}
 
-	pki, err := getPKI(paymentDestinationRequest.SenderPaymail)
+	pki, err := getPKI(rc.Request.Context(), paymentDestinationRequest.SenderPaymail)
 	if err != nil {
 		errors.ErrorResponse(rc, err, c.Logger)
 		return
@@ -61,10 +62,10 @@ func (c *Configuration) pikeGetOutputTemplates(rc *gin.Context) {
 	rc.JSON(http.StatusOK, response)
 }
 
-func getPKI(paymailAddress string) (*paymail.PKIResponse, error) {
-	alias, domain, paymailAddress := paymail.SanitizePaymail(paymailAddress)
-	if len(paymailAddress) == 0 {
-		return nil, errors.ErrInvalidPaymail
+func getPKI(ctx context.Context, paymailAddress string) (*paymail.PKIResponse, error) {
+	alias, domain, sanitized := paymail.SanitizePaymail(paymailAddress)
+	if len(sanitized) == 0 {
+		return nil, invalidPaymailError(paymailAddress, errors.ErrInvalidPaymail)
 	}
 
 	client, err := paymail.NewClient()
@@ -73,14 +74,14 @@ func getPKI(paymailAddress string) (*paymail.PKIResponse, error) {
 	}
 
 	var capabilities *paymail.CapabilitiesResponse
-	if capabilities, err = client.GetCapabilities(domain, paymail.DefaultPort); err != nil {
+	if capabilities, err = client.GetCapabilities(ctx, domain, paymail.DefaultPort); err != nil {
 		return nil, err
 	}
 
 	pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
 
 	var pki *paymail.PKIResponse
-	if pki, err = client.GetPKI(pkiURL, alias, domain); err != nil {
+	if pki, err = client.GetPKI(ctx, pkiURL, alias, domain); err != nil {
 		return nil, err
 	}
 	return pki, nil
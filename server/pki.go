@@ -23,9 +23,9 @@ func (c *Configuration) showPKI(context *gin.Context) {
 		return
 	}
 
-	md := CreateMetadata(context.Request, alias, domain, "")
+	md := c.createMetadata(context.Request, alias, domain, "")
 
-	foundPaymail, err := c.actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	foundPaymail, err := c.aliasActions(alias, domain).GetPaymailByAlias(context.Request.Context(), alias, domain, md)
 	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
@@ -17,7 +17,7 @@ func (c *Configuration) publicProfile(context *gin.Context) {
 	// Parse, sanitize and basic validation
 	alias, domain, address := paymail.SanitizePaymail(incomingPaymail)
 	if len(address) == 0 {
-		errors.ErrorResponse(context, errors.ErrInvalidPaymail, c.Logger)
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
 		return
 	} else if !c.IsAllowedDomain(domain) {
 		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
@@ -25,23 +25,18 @@ func (c *Configuration) publicProfile(context *gin.Context) {
 	}
 
 	// Create the metadata struct
-	md := CreateMetadata(context.Request, alias, domain, "")
+	md := c.createMetadata(context.Request, alias, domain, "")
 
 	// Get from the data layer
-	foundPaymail, err := c.actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	profile, err := c.domainProfileActions(domain).GetProfile(context.Request.Context(), alias, domain, md)
 	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
-	} else if foundPaymail == nil {
+	} else if profile == nil {
 		errors.ErrorResponse(context, errors.ErrCouldNotFindPaymail, c.Logger)
 		return
 	}
 
-	payload := paymail.PublicProfilePayload{
-		Avatar: foundPaymail.Avatar,
-		Name:   foundPaymail.Name,
-	}
-
 	// Set the response
-	context.JSON(http.StatusOK, payload)
+	context.JSON(http.StatusOK, profile)
 }
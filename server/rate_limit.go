@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// Limiters idle for longer than this are removed
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+// rateLimiter is a concurrency-safe token-bucket limiter keyed by remote IP and target domain
+type rateLimiter struct {
+	burst       int
+	lastCleanup time.Time
+	limit       rate.Limit
+	limiters    map[string]*keyedLimiter
+	mu          sync.Mutex
+}
+
+// keyedLimiter is the token bucket for a single key
+type keyedLimiter struct {
+	lastSeen time.Time
+	limiter  *rate.Limiter
+}
+
+// newRateLimiter will create a limiter allowing requestsPerMinute (with the given burst) per key
+func newRateLimiter(requestsPerMinute, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		burst:       burst,
+		lastCleanup: time.Now(),
+		limit:       rate.Limit(float64(requestsPerMinute) / time.Minute.Seconds()),
+		limiters:    make(map[string]*keyedLimiter),
+	}
+}
+
+// allow will return true if the request for the key is within the rate limit
+func (r *rateLimiter) allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastCleanup) > rateLimiterIdleTimeout {
+		for k, l := range r.limiters {
+			if now.Sub(l.lastSeen) > rateLimiterIdleTimeout {
+				delete(r.limiters, k)
+			}
+		}
+		r.lastCleanup = now
+	}
+
+	l, ok := r.limiters[key]
+	if !ok {
+		l = &keyedLimiter{limiter: rate.NewLimiter(r.limit, r.burst)}
+		r.limiters[key] = l
+	}
+	l.lastSeen = now
+
+	return l.limiter.AllowN(now, 1)
+}
+
+// middleware will reject requests over the rate limit with ErrRateLimited
+//
+// The client IP only uses the proxy headers of the trusted proxies (see ClientIP())
+func (r *rateLimiter) middleware(trustedProxies []*net.IPNet, log *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, domain, _ := paymail.SanitizePaymail(c.Param(PaymailAddressParamName))
+		if !r.allow(ClientIP(c.Request, trustedProxies) + "|" + domain) {
+			errors.ErrorResponse(c, errors.ErrRateLimited, log)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitedEngine will return an engine allowing a single request per key
+func rateLimitedEngine(trustedProxies []*net.IPNet) *gin.Engine {
+	engine := gin.New()
+	engine.GET("/:"+PaymailAddressParamName,
+		newRateLimiter(1, 1).middleware(trustedProxies, logging.GetDefaultLogger()),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+	return engine
+}
+
+func rateLimitedRequest(engine *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/alice@test.com", nil)
+	req.RemoteAddr = remoteAddr
+	if len(forwardedFor) > 0 {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimiter_IgnoresUntrustedForwardedFor(t *testing.T) {
+	engine := rateLimitedEngine(nil)
+
+	if code := rateLimitedRequest(engine, "203.0.113.1:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	// A spoofed header does not get a new bucket
+	if code := rateLimitedRequest(engine, "203.0.113.1:1234", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+}
+
+func TestRateLimiter_TrustedProxy(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := rateLimitedEngine(trustedProxies)
+
+	// The clients behind the trusted proxy have their own bucket
+	if code := rateLimitedRequest(engine, "10.0.0.1:1234", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := rateLimitedRequest(engine, "10.0.0.1:1234", "198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := rateLimitedRequest(engine, "10.0.0.1:1234", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+}
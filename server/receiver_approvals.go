@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
+)
+
+/*
+Incoming Data Object Example:
+{
+  "reference": "someRefId",
+  "approved": true
+}
+*/
+
+// approveTransaction will accept (or reject) a pending P2P transaction of the paymail address
+//
+// The request must be authorized by the ApprovalAuthorizer, an accepted transaction is then broadcast
+// (if a Broadcaster is set and it was not broadcast before recording)
+//
+// Specs: http://bsvalias.org/04-03-receiver-approvals.html
+func (c *Configuration) approveTransaction(context *gin.Context) {
+	alias, domain, ok := c.approvalPaymail(context)
+	if !ok {
+		return
+	}
+
+	if err := c.approvalAuthorizer(context.Request, alias, domain); err != nil {
+		var spvErr errors.SPVError
+		if !stderrors.As(err, &spvErr) {
+			c.Logger.Warn().Err(err).Str("alias", alias).Str("domain", domain).Msg("unauthorized approval")
+			err = errors.ErrApprovalUnauthorized
+		}
+		errors.ErrorResponse(context, err, c.Logger)
+		return
+	}
+
+	var request paymail.TransactionApprovalRequest
+	if err := json.NewDecoder(context.Request.Body).Decode(&request); err != nil {
+		errors.ErrorResponse(context, errors.ErrCannotBindRequest, c.Logger)
+		return
+	} else if len(request.Reference) == 0 {
+		errors.ErrorResponse(context, errors.ErrMissingFieldReference, c.Logger)
+		return
+	} else if err := c.validateReference(request.Reference); err != nil {
+		errors.ErrorResponse(context, errors.ErrInvalidReference.WithDetails(err.Error()), c.Logger)
+		return
+	}
+
+	md := c.createMetadata(context.Request, alias, domain, "")
+
+	approval, err := c.approvalActions.ApproveTransaction(
+		context.Request.Context(), alias, domain, request.Reference, request.Approved, md,
+	)
+	if err != nil {
+		errors.ErrorResponse(context, err, c.Logger)
+		return
+	} else if approval == nil || approval.Payload == nil {
+		errors.ErrorResponse(context, errors.ErrUnknownReference, c.Logger)
+		return
+	}
+	response := approval.Payload
+
+	log := c.Logger.With().Str("alias", alias).Str("domain", domain).Str("reference", request.Reference).Logger()
+
+	// A pending transaction is not broadcast after recording, it is broadcast once accepted
+	if c.broadcaster != nil && !c.BroadcastBeforeRecord && len(approval.Hex) > 0 &&
+		response.Status == paymail.P2PTransactionStatusAccepted {
+		var broadcastResult *BroadcastResult
+		if broadcastResult, err = c.broadcast(context, approval.Hex, &log); err != nil {
+			errors.ErrorResponse(context, err, &log)
+			return
+		} else if broadcastResult != nil {
+			response.BroadcastStatus = broadcastResult.Status
+		}
+	}
+
+	log.Info().Str("status", response.Status).Msg("p2p transaction approval")
+	context.JSON(http.StatusOK, response)
+}
+
+// transactionStatus will return the status (accepted, pending or rejected) of a P2P transaction
+//
+// The reference is given as a query param
+func (c *Configuration) transactionStatus(context *gin.Context) {
+	alias, domain, ok := c.approvalPaymail(context)
+	if !ok {
+		return
+	}
+
+	reference := context.Query("reference")
+	if len(reference) == 0 {
+		errors.ErrorResponse(context, errors.ErrMissingFieldReference, c.Logger)
+		return
+	} else if err := c.validateReference(reference); err != nil {
+		errors.ErrorResponse(context, errors.ErrInvalidReference.WithDetails(err.Error()), c.Logger)
+		return
+	}
+
+	md := c.createMetadata(context.Request, alias, domain, "")
+
+	response, err := c.approvalActions.GetTransactionStatus(context.Request.Context(), alias, domain, reference, md)
+	if err != nil {
+		errors.ErrorResponse(context, err, c.Logger)
+		return
+	} else if response == nil {
+		errors.ErrorResponse(context, errors.ErrUnknownReference, c.Logger)
+		return
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// approvalPaymail will parse and validate the paymail address of the receiver approvals request
+func (c *Configuration) approvalPaymail(context *gin.Context) (alias, domain string, ok bool) {
+	var address string
+	incomingPaymail := context.Param(PaymailAddressParamName)
+	alias, domain, address = paymail.SanitizePaymail(incomingPaymail)
+	if len(address) == 0 {
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
+		return
+	} else if !c.IsAllowedDomain(domain) {
+		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
+		return
+	}
+	return alias, domain, true
+}
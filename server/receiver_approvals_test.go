@@ -0,0 +1,161 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/server"
+	"github.com/AmanTrance/go-paymail/server/mockactions"
+	script "github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+const (
+	testAlias  = "alice"
+	testDomain = "test.com"
+
+	testOutputScript = "76a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac"
+)
+
+// testBroadcaster records the broadcast transactions
+type testBroadcaster struct {
+	broadcast []string
+}
+
+func (b *testBroadcaster) Broadcast(_ context.Context, txHex, _ string) (*server.BroadcastResult, error) {
+	b.broadcast = append(b.broadcast, txHex)
+	return &server.BroadcastResult{Status: "SEEN_ON_NETWORK"}, nil
+}
+
+// testTxHex will return the hex of a transaction with a single P2PKH output
+func testTxHex(t *testing.T) string {
+	t.Helper()
+	lockingScript, err := script.NewFromHex(testOutputScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := sdk.NewTransaction()
+	tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: 1000})
+	return tx.Hex()
+}
+
+// newApprovalsHandler will return the handler of a server with the receiver approvals enabled
+func newApprovalsHandler(t *testing.T, provider *mockactions.ServiceProvider, broadcaster server.Broadcaster,
+	authorizer server.ApprovalAuthorizer) http.Handler {
+	t.Helper()
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(provider)
+	locator.RegisterTransactionApprovalService(provider)
+	config, err := server.NewConfig(locator,
+		server.WithDomain(testDomain),
+		server.WithReceiverApprovalsCapabilities(authorizer),
+		server.WithBroadcaster(broadcaster, false),
+	)
+	if err != nil {
+		t.Fatalf("failed to create the config: %s", err)
+	}
+	return server.Handlers(config)
+}
+
+// recordPending will record a pending transaction for the paymail address and return its hex
+func recordPending(t *testing.T, provider *mockactions.ServiceProvider, alias, reference string) string {
+	t.Helper()
+	txHex := testTxHex(t)
+	provider.SetRequireApproval(true)
+	if _, err := provider.RecordTransaction(context.Background(), &paymail.P2PTransaction{
+		Hex:       txHex,
+		MetaData:  &paymail.P2PMetaData{},
+		Reference: reference,
+	}, &server.RequestMetadata{Alias: alias, Domain: testDomain}); err != nil {
+		t.Fatalf("failed to record the transaction: %s", err)
+	}
+	return txHex
+}
+
+func approve(handler http.Handler, alias, reference string) *httptest.ResponseRecorder {
+	body := `{"reference":"` + reference + `","approved":true}`
+	req := httptest.NewRequest(http.MethodPost,
+		"/v1/bsvalias/receiver-approvals/approve/"+alias+"@"+testDomain, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func allowAll(*http.Request, string, string) error { return nil }
+
+func TestApproveTransaction_BroadcastsAccepted(t *testing.T) {
+	provider := mockactions.New()
+	broadcaster := &testBroadcaster{}
+	handler := newApprovalsHandler(t, provider, broadcaster, allowAll)
+	txHex := recordPending(t, provider, testAlias, "reference-1")
+
+	rec := approve(handler, testAlias, "reference-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload paymail.P2PTransactionPayload
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Status != paymail.P2PTransactionStatusAccepted {
+		t.Errorf("expected the accepted status, got %q", payload.Status)
+	}
+	if len(broadcaster.broadcast) != 1 || broadcaster.broadcast[0] != txHex {
+		t.Errorf("expected the approved transaction to be broadcast once, got %v", broadcaster.broadcast)
+	}
+
+	// Approving again does not broadcast again
+	if rec = approve(handler, testAlias, "reference-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if len(broadcaster.broadcast) != 1 {
+		t.Errorf("expected a single broadcast, got %d", len(broadcaster.broadcast))
+	}
+}
+
+func TestApproveTransaction_Unauthorized(t *testing.T) {
+	provider := mockactions.New()
+	broadcaster := &testBroadcaster{}
+	handler := newApprovalsHandler(t, provider, broadcaster, func(*http.Request, string, string) error {
+		return stderrors.New("no session")
+	})
+	recordPending(t, provider, testAlias, "reference-1")
+
+	if rec := approve(handler, testAlias, "reference-1"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	if len(broadcaster.broadcast) != 0 {
+		t.Errorf("expected no broadcast, got %d", len(broadcaster.broadcast))
+	}
+}
+
+func TestApproveTransaction_ScopedToPaymail(t *testing.T) {
+	provider := mockactions.New()
+	handler := newApprovalsHandler(t, provider, &testBroadcaster{}, allowAll)
+	recordPending(t, provider, testAlias, "reference-1")
+
+	// The reference of alice is unknown for bob
+	if rec := approve(handler, "bob", "reference-1"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestNewConfig_ApprovalAuthorizerRequired(t *testing.T) {
+	provider := mockactions.New()
+	locator := &server.PaymailServiceLocator{}
+	locator.RegisterPaymailService(provider)
+	locator.RegisterTransactionApprovalService(provider)
+	if _, err := server.NewConfig(locator,
+		server.WithDomain(testDomain),
+		server.WithReceiverApprovalsCapabilities(nil),
+	); err == nil {
+		t.Fatal("expected an error without an approval authorizer")
+	}
+}
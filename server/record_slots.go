@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+
+	"github.com/AmanTrance/go-paymail"
+	"github.com/AmanTrance/go-paymail/errors"
+)
+
+// acquireRecordSlot will wait (up to the record wait) for a RecordTransaction slot (see WithMaxConcurrentRecords())
+//
+// The returned func releases the slot, ErrServerBusy is returned if no slot is available in time
+func (c *Configuration) acquireRecordSlot(ctx context.Context) (func(), error) {
+	if c.recordSlots == nil {
+		return func() {}, nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.recordWait)
+	defer cancel()
+	if err := c.recordSlots.Acquire(waitCtx, 1); err != nil {
+		return nil, errors.ErrServerBusy
+	}
+	return func() { c.recordSlots.Release(1) }, nil
+}
+
+// recordTransaction will record the transaction (with the service provider) holding a record slot
+//
+// The slot is released even if RecordTransaction panics (recovered by the router)
+func (c *Configuration) recordTransaction(ctx context.Context, p2pTx *paymail.P2PTransaction,
+	md *RequestMetadata,
+) (*paymail.P2PTransactionPayload, error) {
+	release, err := c.acquireRecordSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return c.aliasActions(md.Alias, md.Domain).RecordTransaction(ctx, p2pTx, md)
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"time"
@@ -38,101 +39,118 @@ func (c *Configuration) resolveAddress(context *gin.Context) {
 	// Parse, sanitize and basic validation
 	alias, domain, paymailAddress := paymail.SanitizePaymail(incomingPaymail)
 	if len(paymailAddress) == 0 {
-		context.JSON(http.StatusBadRequest, "invalid paymail: "+incomingPaymail)
-		errors.ErrorResponse(context, errors.ErrInvalidPaymail, c.Logger)
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
 		return
 	} else if !c.IsAllowedDomain(domain) {
 		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
 		return
 	}
 
+	log := c.Logger.With().Str("alias", alias).Str("domain", domain).Logger()
+
 	var senderRequest paymail.SenderRequest
 	err := context.Bind(&senderRequest)
 	if err != nil {
-		errors.ErrorResponse(context, errors.ErrCannotBindRequest, c.Logger)
+		errors.ErrorResponse(context, errors.ErrCannotBindRequest, &log)
 		return
 	}
 
 	// Check for required fields
 	if len(senderRequest.SenderHandle) == 0 {
-		errors.ErrorResponse(context, errors.ErrSenderHandleEmpty, c.Logger)
+		errors.ErrorResponse(context, errors.ErrSenderHandleEmpty, &log)
 		return
 	} else if len(senderRequest.Dt) == 0 {
-		errors.ErrorResponse(context, errors.ErrDtEmpty, c.Logger)
+		errors.ErrorResponse(context, errors.ErrDtEmpty, &log)
 		return
 	}
 
 	// Validate the timestamp
-	if err = paymail.ValidateTimestamp(senderRequest.Dt); err != nil {
-		errors.ErrorResponse(context, errors.ErrInvalidTimestamp, c.Logger)
+	if err = paymail.ValidateTimestampAt(senderRequest.Dt, c.TimestampSkew, c.now()); err != nil {
+		errors.ErrorResponse(context, errors.ErrInvalidTimestamp, &log)
 		return
 	}
 
 	// Basic validation on sender handle
 	if err = paymail.ValidatePaymail(senderRequest.SenderHandle); err != nil {
-		errors.ErrorResponse(context, errors.ErrInvalidSenderHandle, c.Logger)
+		errors.ErrorResponse(context, invalidPaymailError(senderRequest.SenderHandle, errors.ErrInvalidSenderHandle), &log)
 		return
 	}
 
 	// Only validate signatures if sender validation is enabled (skip if disabled)
-	if c.SenderValidationEnabled {
+	if c.senderValidationEnabled(domain) {
 		if len(senderRequest.Signature) > 0 {
 
+			// Use the default signature scheme if the request does not set one
+			if senderRequest.SignatureScheme, err = c.signatureScheme(senderRequest.SignatureScheme); err != nil {
+				errors.ErrorResponse(context, err, &log)
+				return
+			}
+
 			// Get the pubKey from the corresponding sender paymail address
 			var senderPubKey *ec.PublicKey
-			senderPubKey, err = getSenderPubKey(senderRequest.SenderHandle)
+			senderPubKey, err = getSenderPubKey(context.Request.Context(), senderRequest.SenderHandle)
 			if err != nil {
-				errors.ErrorResponse(context, err, c.Logger)
+				errors.ErrorResponse(context, err, &log)
 				return
 			}
 
 			// Derive address from pubKey
 			var rawAddress *script.Address
 			if rawAddress, err = script.NewAddressFromPublicKey(senderPubKey, true); err != nil {
-				errors.ErrorResponse(context, errors.ErrInvalidSenderHandle, c.Logger)
+				errors.ErrorResponse(context, errors.ErrInvalidSenderHandle, &log)
 				return
 			}
 
 			// Verify the signature
-			if err = senderRequest.Verify(rawAddress.AddressString, senderRequest.Signature); err != nil {
-				errors.ErrorResponse(context, errors.ErrInvalidSignature, c.Logger)
+			if err = c.messageVerifier(senderRequest.SignatureScheme).Verify(
+				rawAddress.AddressString, senderRequest.Signature, string(senderRequest.Message()),
+			); err != nil {
+				errors.ErrorResponse(context, errors.ErrInvalidSignature, &log)
 				return
 			}
 		} else {
-			errors.ErrorResponse(context, errors.ErrMissingFieldSignature, c.Logger)
+			errors.ErrorResponse(context, errors.ErrMissingFieldSignature, &log)
 			return
 		}
 	}
 
 	// Create the metadata struct
-	md := CreateMetadata(context.Request, alias, domain, "")
+	md := c.createMetadata(context.Request, alias, domain, "")
 	md.ResolveAddress = &senderRequest
 
 	// Get from the data layer
-	foundPaymail, err := c.actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	foundPaymail, err := c.aliasActions(alias, domain).GetPaymailByAlias(context.Request.Context(), alias, domain, md)
 	if err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
+		errors.ErrorResponse(context, err, &log)
 		return
 	} else if foundPaymail == nil {
-		errors.ErrorResponse(context, errors.ErrCouldNotFindPaymail, c.Logger)
+		errors.ErrorResponse(context, errors.ErrCouldNotFindPaymail, &log)
 		return
 	}
 
 	// Get the resolution information
 	var response *paymail.ResolutionPayload
-	if response, err = c.actions.CreateAddressResolutionResponse(
-		context.Request.Context(), alias, domain, c.SenderValidationEnabled, md,
+	if response, err = c.aliasActions(alias, domain).CreateAddressResolutionResponse(
+		context.Request.Context(), alias, domain, c.senderValidationEnabled(domain), md,
 	); err != nil {
-		errors.ErrorResponse(context, err, c.Logger)
+		errors.ErrorResponse(context, err, &log)
 		return
 	}
 
+	log.Info().Str("sender", senderRequest.SenderHandle).Msg("address resolved")
+	c.recordAuditEvent(context.Request.Context(), md, AuditEvent{
+		Alias:    alias,
+		Domain:   domain,
+		Satoshis: senderRequest.Amount,
+		Type:     AuditEventAddressResolved,
+	})
+
 	// Set the response
 	context.JSON(http.StatusOK, response)
 }
 
 // getSenderPubKey will fetch the pubKey from a PKI request for the sender handle
-func getSenderPubKey(senderPaymailAddress string) (*ec.PublicKey, error) {
+func getSenderPubKey(ctx context.Context, senderPaymailAddress string) (*ec.PublicKey, error) {
 
 	// Sanitize and break apart
 	alias, domain, _ := paymail.SanitizePaymail(senderPaymailAddress)
@@ -155,7 +173,7 @@ func getSenderPubKey(senderPaymailAddress string) (*ec.PublicKey, error) {
 	// This is required first to get the corresponding PKI endpoint url
 	var capabilities *paymail.CapabilitiesResponse
 	if capabilities, err = client.GetCapabilities(
-		srv.Target, paymail.DefaultPort,
+		ctx, srv.Target, paymail.DefaultPort,
 	); err != nil {
 		return nil, err
 	}
@@ -166,7 +184,7 @@ func getSenderPubKey(senderPaymailAddress string) (*ec.PublicKey, error) {
 	// Get the actual PKI
 	var pki *paymail.PKIResponse
 	if pki, err = client.GetPKI(
-		pkiURL, alias, domain,
+		ctx, pkiURL, alias, domain,
 	); err != nil {
 		return nil, err
 	}
@@ -2,8 +2,11 @@ package server
 
 import (
 	"fmt"
-	"github.com/gin-gonic/gin"
+	"net/http"
 	"strings"
+
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/gin-gonic/gin"
 )
 
 // Handlers are used to isolate loading the routes (used for testing)
@@ -32,40 +35,106 @@ func (c *Configuration) RegisterBasicRoutes(engine *gin.Engine) {
 
 	// Set the health request (used for load balancers)
 	if c.BasicRoutes.AddHealthRoute {
-		engine.GET("/health", health)
-		engine.OPTIONS("/health", health)
-		engine.HEAD("/health", health)
+		healthPath := c.BasicRoutes.HealthPath
+		if len(healthPath) == 0 {
+			healthPath = DefaultHealthPath
+		}
+		engine.GET(healthPath, c.health)
+		engine.OPTIONS(healthPath, c.health)
+		engine.HEAD(healthPath, c.health)
 	}
 }
 
 // RegisterRoutes register all the available paymail routes to the http router
 func (c *Configuration) RegisterRoutes(engine *gin.Engine) {
-	engine.GET("/.well-known/"+c.ServiceName, c.showCapabilities) // service discovery
+	discoveryPath := "/.well-known/" + c.ServiceName
+	engine.GET(discoveryPath, c.routeHandlers(metricCapabilityResolve, c.showCapabilities)...)  // service discovery
+	engine.HEAD(discoveryPath, c.routeHandlers(metricCapabilityResolve, c.showCapabilities)...) // availability checks (no body)
+	c.registerPreflightRoute(engine, discoveryPath)
 
-	for _, cap := range c.callableCapabilities {
-		c.registerRoute(engine, cap)
+	for key, cap := range c.callableCapabilities {
+		c.registerRoute(engine, key, cap)
 	}
 
-	for _, nestedCap := range c.nestedCapabilities {
-		for _, cap := range nestedCap {
-			c.registerRoute(engine, cap)
+	for nestedKey, nestedCap := range c.nestedCapabilities {
+		for key, cap := range nestedCap {
+			c.registerRoute(engine, nestedKey+"."+key, cap)
 		}
 	}
 }
 
-func (c *Configuration) registerRoute(engine *gin.Engine, cap CallableCapability) {
+func (c *Configuration) registerRoute(engine *gin.Engine, name string, cap CallableCapability) {
 	routerPath := c.templateToRouterPath(cap.Path)
+	handler := cap.Handler
+	if len(c.domainConfigs) > 0 {
+		handler = c.domainCapabilityHandler(name, handler)
+	}
 	engine.Handle(
 		cap.Method,
 		routerPath,
-		cap.Handler,
+		c.routeHandlers(metricCapabilityName(name), handler)...,
 	)
+
+	// GET capabilities also answer HEAD requests (availability checks, the body is not sent)
+	if cap.Method == http.MethodGet {
+		engine.HEAD(routerPath, c.routeHandlers(metricCapabilityName(name), handler)...)
+	}
+	c.registerPreflightRoute(engine, routerPath)
+}
+
+// registerPreflightRoute will register the OPTIONS (CORS preflight) route for the path (if CORS is enabled)
+func (c *Configuration) registerPreflightRoute(engine *gin.Engine, routerPath string) {
+	if len(c.CORSAllowedOrigins) == 0 {
+		return
+	}
+	for _, route := range engine.Routes() {
+		if route.Method == http.MethodOptions && route.Path == routerPath {
+			return
+		}
+	}
+	engine.OPTIONS(routerPath, c.corsMiddleware())
+}
+
+// routeHandlers will prepend any configured middleware to the route handler (of the capability name)
+func (c *Configuration) routeHandlers(capability string, handler gin.HandlerFunc) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	if c.metrics != nil {
+		handlers = append(handlers, c.metrics.middleware(capability))
+	}
+	if len(c.CORSAllowedOrigins) > 0 {
+		handlers = append(handlers, c.corsMiddleware())
+	}
+	if c.SecurityHeaders != (SecurityHeaders{}) {
+		handlers = append(handlers, c.securityHeadersMiddleware())
+	}
+	if c.ErrorStatusCode > 0 || len(c.ErrorStatusCodes) > 0 {
+		handlers = append(handlers, c.errorStatusCodesMiddleware())
+	}
+	if c.rateLimiter != nil {
+		handlers = append(handlers, c.rateLimiter.middleware(c.trustedProxies, c.Logger))
+	}
+	if len(c.blockedAliases) > 0 || len(c.reservedAliases) > 0 {
+		handlers = append(handlers, c.aliasesMiddleware())
+	}
+	return append(handlers, handler)
+}
+
+// errorStatusCodesMiddleware will set the configured error status overrides on the request
+func (c *Configuration) errorStatusCodesMiddleware() gin.HandlerFunc {
+	statusCodes := &errors.StatusCodes{
+		Codes:   c.ErrorStatusCodes,
+		Default: c.ErrorStatusCode,
+	}
+	return func(ctx *gin.Context) {
+		errors.SetStatusCodes(ctx, statusCodes)
+		ctx.Next()
+	}
 }
 
 func (c *Configuration) templateToRouterPath(template string) string {
 	template = strings.ReplaceAll(template, PaymailAddressTemplate, _routerParam(PaymailAddressParamName))
 	template = strings.ReplaceAll(template, PubKeyTemplate, _routerParam(PubKeyParamName))
-	return fmt.Sprintf("/%s/%s/%s", c.APIVersion, c.ServiceName, strings.TrimPrefix(template, "/"))
+	return fmt.Sprintf("%s/%s/%s/%s", c.servicePath(), c.APIVersion, c.ServiceName, strings.TrimPrefix(template, "/"))
 }
 
 func _routerParam(name string) string {
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Security header defaults (see DefaultSecurityHeaders())
+const (
+	DefaultContentSecurityPolicy   = "default-src 'none'; frame-ancestors 'none'" // Responses are JSON, nothing is loaded or framed
+	DefaultContentTypeOptions      = "nosniff"                                    // The content type is not sniffed
+	DefaultStrictTransportSecurity = "max-age=31536000; includeSubDomains"        // https only, for one year
+)
+
+// SecurityHeaders are the security headers set on the capability responses (an empty value is not set, none by default)
+type SecurityHeaders struct {
+	ContentSecurityPolicy   string `json:"content_security_policy"`   // Content-Security-Policy
+	ContentTypeOptions      string `json:"content_type_options"`      // X-Content-Type-Options
+	StrictTransportSecurity string `json:"strict_transport_security"` // Strict-Transport-Security (HSTS)
+}
+
+// DefaultSecurityHeaders will return the default (restrictive) security headers
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		ContentSecurityPolicy:   DefaultContentSecurityPolicy,
+		ContentTypeOptions:      DefaultContentTypeOptions,
+		StrictTransportSecurity: DefaultStrictTransportSecurity,
+	}
+}
+
+// securityHeadersMiddleware will set the security headers before the handler runs (error responses also carry them)
+func (c *Configuration) securityHeadersMiddleware() gin.HandlerFunc {
+	headers := map[string]string{
+		"Content-Security-Policy":   c.SecurityHeaders.ContentSecurityPolicy,
+		"Strict-Transport-Security": c.SecurityHeaders.StrictTransportSecurity,
+		"X-Content-Type-Options":    c.SecurityHeaders.ContentTypeOptions,
+	}
+	return func(ctx *gin.Context) {
+		for name, value := range headers {
+			if len(value) > 0 {
+				ctx.Header(name, value)
+			}
+		}
+		ctx.Next()
+	}
+}
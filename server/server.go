@@ -2,12 +2,41 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
 
+// Server is a Paymail server (http.Server) that can be shut down gracefully
+//
+// The Router is exposed for those who manage their own http.Server
+type Server struct {
+	Router *gin.Engine // All the Paymail routes (see Handlers())
+
+	// private
+	config     *Configuration
+	httpServer *http.Server
+}
+
+// NewServer will create a Paymail Server with all the routes of the configuration
+func NewServer(c *Configuration) *Server {
+	s := &Server{
+		Router: Handlers(c),
+		config: c,
+	}
+	s.httpServer = &http.Server{
+		Handler:           s.Router,
+		ReadHeaderTimeout: c.Timeout,
+		ReadTimeout:       c.Timeout,
+		WriteTimeout:      c.Timeout,
+	}
+	return s
+}
+
 // CreateServer will create a basic Paymail Server
 func CreateServer(c *Configuration) *http.Server {
 	return &http.Server{
@@ -24,3 +53,29 @@ func StartServer(srv *http.Server, logger *zerolog.Logger) {
 	logger.Info().Str("address", srv.Addr).Msg("starting go paymail server...")
 	logger.Fatal().Msg(srv.ListenAndServe().Error())
 }
+
+// Start will run the Paymail server on the address (blocking until the server is shut down)
+//
+// An empty address uses the configured port. Returns nil after a Shutdown() (a shut down server cannot be started again)
+func (s *Server) Start(addr string) error {
+	if len(addr) == 0 {
+		addr = fmt.Sprintf(":%d", s.config.Port)
+	}
+
+	s.httpServer.Addr = addr
+
+	s.config.Logger.Info().Str("address", addr).Msg("starting go paymail server...")
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown will stop accepting new requests and wait for the active handlers to complete
+//
+// The handlers (e.g. a slow RecordTransaction) are not cancelled, the context deadline
+// limits the wait and its error is returned if the handlers did not complete in time
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.config.Logger.Info().Msg("shutting down go paymail server...")
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AmanTrance/go-paymail"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// Signer signs messages with a key of the server, e.g. the issued P2P payment destinations (see WithKeyStore())
+//
+// The key can be held elsewhere (e.g. a KMS), the signature must be a Bitcoin signed message of the pubkey
+type Signer interface {
+	// PubKey returns the (hex encoded, compressed) pubkey of the signature
+	PubKey() string
+
+	// Sign returns the (base64 encoded) Bitcoin signed message of the message
+	Sign(ctx context.Context, message []byte) (string, error)
+}
+
+// PrivateKeySigner is a Signer using a private key
+type PrivateKeySigner struct {
+	privateKey *primitives.PrivateKey
+}
+
+// NewPrivateKeySigner will create a Signer using the (hex encoded) private key
+func NewPrivateKeySigner(privateKey string) (*PrivateKeySigner, error) {
+	if len(privateKey) == 0 {
+		return nil, fmt.Errorf("missing private key")
+	}
+	key, err := primitives.PrivateKeyFromHex(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeySigner{privateKey: key}, nil
+}
+
+// PubKey will return the (hex encoded, compressed) pubkey of the private key
+func (s *PrivateKeySigner) PubKey() string {
+	return s.privateKey.PubKey().ToDERHex()
+}
+
+// Sign will return the (base64 encoded) Bitcoin signed message of the message
+func (s *PrivateKeySigner) Sign(_ context.Context, message []byte) (string, error) {
+	sigBytes, err := bsm.SignMessage(s.privateKey, message)
+	if err != nil {
+		return "", err
+	}
+	return paymail.EncodeSignature(sigBytes), nil
+}
+
+// signDestination will set the pubkey and the signature (see SignatureMessage()) of the destination
+//
+// The destination is signed by the signer of the domain (see WithKeyStore()), it is not signed if the domain has no key
+func (c *Configuration) signDestination(ctx context.Context, domain string,
+	destination *paymail.PaymentDestinationPayload) error {
+
+	signer, err := c.domainSigner(ctx, domain)
+	if err != nil || signer == nil {
+		return err
+	}
+	destination.PubKey = signer.PubKey()
+	destination.Signature, err = signer.Sign(ctx, destination.SignatureMessage())
+	return err
+}
@@ -18,7 +18,7 @@ func (c *Configuration) verifyPubKey(context *gin.Context) {
 	// Parse, sanitize and basic validation
 	alias, domain, address := paymail.SanitizePaymail(incomingPaymail)
 	if len(address) == 0 {
-		errors.ErrorResponse(context, errors.ErrInvalidPaymail, c.Logger)
+		errors.ErrorResponse(context, invalidPaymailError(incomingPaymail, errors.ErrInvalidPaymail), c.Logger)
 		return
 	} else if !c.IsAllowedDomain(domain) {
 		errors.ErrorResponse(context, errors.ErrDomainUnknown, c.Logger)
@@ -32,10 +32,10 @@ func (c *Configuration) verifyPubKey(context *gin.Context) {
 	}
 
 	// Create the metadata struct
-	md := CreateMetadata(context.Request, alias, domain, "")
+	md := c.createMetadata(context.Request, alias, domain, "")
 
 	// Get from the data layer
-	foundPaymail, err := c.actions.GetPaymailByAlias(context.Request.Context(), alias, domain, md)
+	foundPaymail, err := c.aliasActions(alias, domain).GetPaymailByAlias(context.Request.Context(), alias, domain, md)
 	if err != nil {
 		errors.ErrorResponse(context, err, c.Logger)
 		return
@@ -44,11 +44,12 @@ func (c *Configuration) verifyPubKey(context *gin.Context) {
 		return
 	}
 
+	// The response contains the consulted pubkey (not the stored one)
 	verPayload := paymail.VerificationPayload{
 		BsvAlias: c.BSVAliasVersion,
 		Handle:   address,
-		PubKey:   foundPaymail.PubKey,
-		Match:    foundPaymail.PubKey == incomingPubKey,
+		PubKey:   incomingPubKey,
+		Match:    foundPaymail.HasPubKey(incomingPubKey),
 	}
 
 	context.JSON(http.StatusOK, verPayload)
@@ -0,0 +1,201 @@
+package paymail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/AmanTrance/go-paymail/errors"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	primitives "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+	sighash "github.com/bsv-blockchain/go-sdk/transaction/sighash"
+)
+
+// Signature schemes
+const (
+	SignatureSchemeBitcoinMessage = "bsm"    // Legacy Bitcoin signed message (default)
+	SignatureSchemeBIP322         = "bip322" // BIP-322 generic signed message (simple format, P2PKH keys)
+)
+
+// bip322Tag is the tag of the BIP-322 message hash
+const bip322Tag = "BIP0322-signed-message"
+
+// ErrUnsupportedSignatureScheme is returned when the signature scheme is not known
+//
+// It is the server errors.ErrUnsupportedSignatureScheme, so errors.Is matches the client and server errors
+var ErrUnsupportedSignatureScheme error = errors.ErrUnsupportedSignatureScheme
+
+// IsSupportedSignatureScheme will return true if the scheme is known (empty is the legacy scheme)
+func IsSupportedSignatureScheme(scheme string) bool {
+	switch scheme {
+	case "", SignatureSchemeBitcoinMessage, SignatureSchemeBIP322:
+		return true
+	}
+	return false
+}
+
+// VerifySignature will verify the (base64 encoded) signature of the message for the address using the scheme
+//
+// An empty scheme is the legacy Bitcoin signed message. BIP-322 signatures use the simple format
+// (a serialized witness stack of the signature and the compressed public key)
+// Specs: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki
+func VerifySignature(scheme, address, signature string, message []byte) error {
+	sigBytes, err := DecodeSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	switch scheme {
+	case "", SignatureSchemeBitcoinMessage:
+		return bsm.VerifyMessage(address, sigBytes, message)
+	case SignatureSchemeBIP322:
+		return verifyBIP322(address, sigBytes, message)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedSignatureScheme, scheme)
+}
+
+// verifyBIP322 will verify a BIP-322 simple signature of the message for the (P2PKH) address
+//
+// The message challenge is the P2PKH script of the address, the P2WPKH script of the same key hash
+// is also accepted (signatures made by segwit wallets)
+func verifyBIP322(address string, witness, message []byte) error {
+	rawAddress, err := script.NewAddressFromString(address)
+	if err != nil {
+		return err
+	}
+
+	var items [][]byte
+	if items, err = parseWitnessStack(witness); err != nil {
+		return err
+	} else if len(items) != 2 || len(items[0]) == 0 {
+		return fmt.Errorf("bip322 signature must be a signature and a public key")
+	}
+	sigBytes, pubKeyBytes := items[0], items[1]
+
+	// The public key must belong to the address
+	if !bytes.Equal(crypto.Hash160(pubKeyBytes), rawAddress.PublicKeyHash) {
+		return fmt.Errorf("bip322 public key does not match the address")
+	}
+	var pubKey *primitives.PublicKey
+	if pubKey, err = primitives.PublicKeyFromBytes(pubKeyBytes); err != nil {
+		return err
+	}
+
+	flag := sighash.Flag(sigBytes[len(sigBytes)-1])
+	if flag != sighash.All && flag != sighash.AllForkID {
+		return fmt.Errorf("bip322 signature has an unsupported sighash flag: %d", flag)
+	}
+	var sig *primitives.Signature
+	if sig, err = primitives.ParseDERSignature(sigBytes[:len(sigBytes)-1]); err != nil {
+		return err
+	}
+
+	scriptCode := append(append([]byte{script.OpDUP, script.OpHASH160, script.OpDATA20},
+		rawAddress.PublicKeyHash...), script.OpEQUALVERIFY, script.OpCHECKSIG)
+	challenges := [][]byte{
+		scriptCode,
+		append([]byte{script.Op0, script.OpDATA20}, rawAddress.PublicKeyHash...),
+	}
+	for _, challenge := range challenges {
+		var hash []byte
+		if hash, err = bip322SignatureHash(challenge, scriptCode, message, flag); err != nil {
+			return err
+		}
+		if sig.Verify(hash, pubKey) {
+			return nil
+		}
+	}
+	return fmt.Errorf("bip322 signature is invalid")
+}
+
+// bip322SignatureHash will return the (BIP-143) signature hash of the virtual to_sign transaction
+func bip322SignatureHash(challenge, scriptCode, message []byte, flag sighash.Flag) ([]byte, error) {
+	tag := crypto.Sha256([]byte(bip322Tag))
+	messageHash := crypto.Sha256(append(append(append([]byte{}, tag...), tag...), message...))
+
+	toSpendUnlock := script.Script(append([]byte{script.Op0, script.OpDATA32}, messageHash...))
+	toSpendChallenge := script.Script(challenge)
+	toSpend := &sdk.Transaction{
+		Inputs: []*sdk.TransactionInput{{
+			SourceTXID:       new(chainhash.Hash),
+			SourceTxOutIndex: 0xFFFFFFFF,
+			UnlockingScript:  &toSpendUnlock,
+		}},
+		Outputs: []*sdk.TransactionOutput{{LockingScript: &toSpendChallenge}},
+	}
+
+	toSignInput := &sdk.TransactionInput{SourceTXID: toSpend.TxID()}
+	code := script.Script(scriptCode)
+	toSignInput.SetSourceTxOutput(&sdk.TransactionOutput{LockingScript: &code})
+	toSignReturn := script.Script([]byte{script.OpRETURN})
+	toSign := &sdk.Transaction{
+		Inputs:  []*sdk.TransactionInput{toSignInput},
+		Outputs: []*sdk.TransactionOutput{{LockingScript: &toSignReturn}},
+	}
+
+	// BIP-322 uses the BIP-143 digest (also used by the replay-protected sighash)
+	preimage, err := toSign.CalcInputPreimage(0, flag)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sha256d(preimage), nil
+}
+
+// parseWitnessStack will parse a serialized witness stack (a var int count of var int prefixed items)
+func parseWitnessStack(witness []byte) ([][]byte, error) {
+	reader := bytes.NewReader(witness)
+	count, err := readVarInt(reader)
+	if err != nil {
+		return nil, err
+	} else if count > uint64(len(witness)) {
+		return nil, fmt.Errorf("witness stack count is invalid")
+	}
+
+	items := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var size uint64
+		if size, err = readVarInt(reader); err != nil {
+			return nil, err
+		} else if size > uint64(reader.Len()) {
+			return nil, fmt.Errorf("witness stack item is truncated")
+		}
+		item := make([]byte, size)
+		_, _ = reader.Read(item)
+		items = append(items, item)
+	}
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("witness stack has trailing data")
+	}
+	return items, nil
+}
+
+// readVarInt will read a bitcoin var int
+func readVarInt(reader *bytes.Reader) (uint64, error) {
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int
+	switch prefix {
+	case 0xfd:
+		size = 2
+	case 0xfe:
+		size = 4
+	case 0xff:
+		size = 8
+	default:
+		return uint64(prefix), nil
+	}
+
+	buf := make([]byte, 8)
+	if _, err = io.ReadFull(reader, buf[:size]); err != nil {
+		return 0, fmt.Errorf("var int is truncated")
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
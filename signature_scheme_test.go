@@ -0,0 +1,23 @@
+package paymail
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/AmanTrance/go-paymail/errors"
+)
+
+func TestErrUnsupportedSignatureScheme(t *testing.T) {
+	err := VerifySignature("unknown", "address", "c2lnbmF0dXJl", []byte("message"))
+	if !stderrors.Is(err, ErrUnsupportedSignatureScheme) {
+		t.Errorf("expected the client error, got %v", err)
+	}
+	if !stderrors.Is(err, errors.ErrUnsupportedSignatureScheme) {
+		t.Errorf("expected the server error, got %v", err)
+	}
+
+	// The server error (with details) is the client error
+	if serverErr := errors.ErrUnsupportedSignatureScheme.WithDetails("unknown"); !stderrors.Is(serverErr, ErrUnsupportedSignatureScheme) {
+		t.Errorf("expected the client error, got %v", serverErr)
+	}
+}
@@ -0,0 +1,38 @@
+package spv
+
+import (
+	"context"
+	"math"
+
+	"github.com/AmanTrance/go-paymail/errors"
+)
+
+// HeaderValidator validates merkle roots against a block header source (e.g. a headers service)
+type HeaderValidator interface {
+	IsValidRootForHeight(root string, height uint32) bool
+}
+
+// headerValidatorVerifier is a MerkleRootVerifier using a HeaderValidator
+type headerValidatorVerifier struct {
+	validator HeaderValidator
+}
+
+// NewHeaderValidatorVerifier will create a MerkleRootVerifier that checks every merkle root using the HeaderValidator
+//
+// A merkle root that is not valid for its block height fails with errors.ErrInvalidMerkleProof
+func NewHeaderValidatorVerifier(validator HeaderValidator) MerkleRootVerifier {
+	return &headerValidatorVerifier{validator: validator}
+}
+
+// VerifyMerkleRoots will check the merkle roots using the HeaderValidator
+func (v *headerValidatorVerifier) VerifyMerkleRoots(_ context.Context,
+	merkleRoots []*MerkleRootConfirmationRequestItem,
+) error {
+	for _, item := range merkleRoots {
+		if item.BlockHeight > math.MaxUint32 ||
+			!v.validator.IsValidRootForHeight(item.MerkleRoot, uint32(item.BlockHeight)) {
+			return errors.ErrInvalidMerkleProof
+		}
+	}
+	return nil
+}
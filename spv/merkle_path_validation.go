@@ -11,6 +11,10 @@ func verifyMerkleRoots(ctx context.Context, dBeef *beef.DecodedBEEF, provider Me
 		return err
 	}
 
+	if provider == nil {
+		return nil
+	}
+
 	verifyReq, err := getMerkleRootsVerificationRequests(dBeef.BUMPs)
 	if err != nil {
 		return err
@@ -25,6 +25,9 @@ type MerkleRootConfirmationRequestItem struct {
 }
 
 // ExecuteSimplifiedPaymentVerification executes the SPV for decoded BEEF tx
+//
+// The merkle roots of the BUMPs are verified using the provider, a nil provider skips the merkle root verification
+// (the mined ancestors must still be present in the BUMPs)
 func ExecuteSimplifiedPaymentVerification(ctx context.Context, dBeef *beef.DecodedBEEF, provider MerkleRootVerifier) error {
 
 	for _, txDt := range dBeef.Transactions {
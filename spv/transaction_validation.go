@@ -0,0 +1,95 @@
+package spv
+
+import (
+	"fmt"
+
+	"github.com/AmanTrance/go-paymail/errors"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// VerifyTransaction will run the structural checks of a (fully signed) transaction before it is recorded
+//
+// Every input must have an unlocking script made of minimal data pushes (no malleable scripts) and spend a
+// different outpoint, every output paying the receiver (isReceiverOutput, all the outputs if nil) must be a
+// standard type (P2PKH, P2PK, multisig or data). The other outputs (e.g. the change of the sender or ordinals)
+// are not checked. The scripts are not executed (see ExecuteSimplifiedPaymentVerification), the reason is
+// set on the returned errors.ErrNonStandardTransaction
+func VerifyTransaction(txHex string, isReceiverOutput func(lockingScript *script.Script) bool) error {
+	tx, err := sdk.NewTransactionFromHex(txHex)
+	if err != nil {
+		return errors.ErrNonStandardTransaction.WithDetails("invalid transaction hex")
+	} else if len(tx.Inputs) == 0 {
+		return errors.ErrNonStandardTransaction.WithDetails("no inputs")
+	} else if len(tx.Outputs) == 0 {
+		return errors.ErrNonStandardTransaction.WithDetails("no outputs")
+	}
+
+	outpoints := make(map[string]struct{}, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		outpoint := fmt.Sprintf("%s:%d", input.SourceTXID.String(), input.SourceTxOutIndex)
+		if _, found := outpoints[outpoint]; found {
+			return errors.ErrNonStandardTransaction.WithDetails(fmt.Sprintf("input %d spends %s twice", i, outpoint))
+		}
+		outpoints[outpoint] = struct{}{}
+
+		if err = validateUnlockingScript(input.UnlockingScript); err != nil {
+			return errors.ErrNonStandardTransaction.WithDetails(fmt.Sprintf("input %d %s", i, err.Error()))
+		}
+	}
+
+	for i, output := range tx.Outputs {
+		if isReceiverOutput != nil && !isReceiverOutput(output.LockingScript) {
+			continue
+		}
+		if !isStandardOutput(output.LockingScript) {
+			return errors.ErrNonStandardTransaction.WithDetails(fmt.Sprintf("output %d has a non-standard locking script", i))
+		}
+	}
+
+	return nil
+}
+
+// validateUnlockingScript will check that the unlocking script is not empty and only has minimal data pushes
+func validateUnlockingScript(unlockingScript *script.Script) error {
+	if unlockingScript == nil || len(*unlockingScript) == 0 {
+		return fmt.Errorf("is not signed (empty unlocking script)")
+	}
+
+	chunks, err := script.DecodeScript(*unlockingScript)
+	if err != nil {
+		return fmt.Errorf("has an invalid unlocking script")
+	}
+	for _, chunk := range chunks {
+		if chunk.Op > script.Op16 {
+			return fmt.Errorf("unlocking script is not push only")
+		} else if !isMinimalPush(chunk) {
+			return fmt.Errorf("unlocking script has a non-minimal data push")
+		}
+	}
+	return nil
+}
+
+// isMinimalPush will return true if the data is pushed using the smallest opcode
+func isMinimalPush(chunk *script.ScriptChunk) bool {
+	size := len(chunk.Data)
+	switch {
+	case chunk.Op < script.OpPUSHDATA1 || chunk.Op > script.OpPUSHDATA4:
+		// A single byte 1-16 (or -1) must use the small int opcodes
+		return size != 1 || (chunk.Data[0] != 0x81 && (chunk.Data[0] == 0 || chunk.Data[0] > 16))
+	case chunk.Op == script.OpPUSHDATA1:
+		return size > 75
+	case chunk.Op == script.OpPUSHDATA2:
+		return size > 0xff
+	}
+	return size > 0xffff
+}
+
+// isStandardOutput will return true if the locking script is a standard output type
+func isStandardOutput(lockingScript *script.Script) bool {
+	if lockingScript == nil {
+		return false
+	}
+	return lockingScript.IsP2PKH() || lockingScript.IsP2PK() || lockingScript.IsData() || lockingScript.IsMultiSigOut()
+}
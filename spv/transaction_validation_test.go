@@ -0,0 +1,72 @@
+package spv
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+const (
+	testReceiverScript = "76a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac"
+	testOrdinalScript  = "0063036f726451126170706c69636174696f6e2f6a736f6e00027b7d6876a9147f11c8f67a2781df0400ebfb1f31b4c72a780b9d88ac"
+)
+
+// testTransaction will return a signed (push only) transaction paying the receiver and an ordinal output
+func testTransaction(t *testing.T) string {
+	t.Helper()
+	unlockingScript, err := script.NewFromHex("020102")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := sdk.NewTransaction()
+	tx.AddInput(&sdk.TransactionInput{
+		SourceTXID:      &chainhash.Hash{1},
+		UnlockingScript: unlockingScript,
+		SequenceNumber:  0xffffffff,
+	})
+	for _, scriptHex := range []string{testReceiverScript, testOrdinalScript} {
+		lockingScript, err := script.NewFromHex(scriptHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: 1})
+	}
+	return tx.Hex()
+}
+
+func TestVerifyTransaction_ReceiverOutputs(t *testing.T) {
+	txHex := testTransaction(t)
+	isReceiverOutput := func(lockingScript *script.Script) bool {
+		return lockingScript.String() == testReceiverScript
+	}
+
+	// The ordinal output does not pay the receiver, it is not checked
+	if err := VerifyTransaction(txHex, isReceiverOutput); err != nil {
+		t.Errorf("expected the transaction to be valid, got %s", err)
+	}
+
+	// Every output is checked without a receiver filter
+	if err := VerifyTransaction(txHex, nil); err == nil {
+		t.Error("expected the ordinal output to be non-standard")
+	}
+
+	// A non-standard output paying the receiver is rejected
+	if err := VerifyTransaction(txHex, func(*script.Script) bool { return true }); err == nil {
+		t.Error("expected the non-standard receiver output to be rejected")
+	}
+}
+
+func TestVerifyTransaction_Structure(t *testing.T) {
+	if err := VerifyTransaction("zz", nil); err == nil {
+		t.Error("expected an invalid hex to be rejected")
+	}
+
+	tx := sdk.NewTransaction()
+	lockingScript, _ := script.NewFromHex(testReceiverScript)
+	tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: 1})
+	if err := VerifyTransaction(tx.Hex(), nil); err == nil {
+		t.Error("expected a transaction without inputs to be rejected")
+	}
+}
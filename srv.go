@@ -2,6 +2,7 @@ package paymail
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -25,7 +26,7 @@ func (c *Client) defaultResolver() net.Resolver {
 	}
 }
 
-// GetSRVRecord will get the SRV record for a given domain name
+// GetSRVRecord will get the SRV record (target, port, priority & weight) for a given domain name
 //
 // Specs: http://bsvalias.org/02-01-host-discovery.html
 func (c *Client) GetSRVRecord(service, protocol, domainName string) (srv *net.SRV, err error) {
@@ -47,21 +48,35 @@ func (c *Client) GetSRVRecord(service, protocol, domainName string) (srv *net.SR
 	// The computed cname to check against
 	cnameCheck := fmt.Sprintf("_%s._%s.%s.", service, protocol, domainName)
 
-	// Lookup the SRV record
+	// Lookup the SRV record (a failed DNSSEC validation is not treated as a missing record)
 	var cname string
 	var records []*net.SRV
-	if cname, records, err = c.resolver.LookupSRV(
-		context.Background(), service, protocol, domainName,
-	); err != nil || len(records) == 0 {
+	if c.options.dnssec {
+		records, err = resolveSecureSRV(cnameCheck, c.options.nameServer, c.options.dnsPort)
+		cname = cnameCheck
+	} else {
+		cname, records, err = c.resolver.LookupSRV(
+			context.Background(), service, protocol, domainName,
+		)
+	}
+	if isMissingSRVRecord(err, records) {
+		if !c.options.wellKnownFallback {
+			err = fmt.Errorf("srv record not found for: %s", domainName)
+			return
+		}
+
 		// @rohenaz: Paymail spec says if SRV record doesn't exist, assume it is <domain>.<tld> and port of 443
-		err = nil          // Hack
-		cname = cnameCheck // Hack
-		records = append(records, &net.SRV{
+		// (the target is the standardized domain, the same host is used to check the capability URLs)
+		err = nil
+		cname = cnameCheck
+		records = []*net.SRV{{
 			Port:     DefaultPort,
 			Priority: DefaultPriority,
-			Target:   domainName,
+			Target:   strings.ToLower(domainName),
 			Weight:   DefaultWeight,
-		})
+		}}
+	} else if err != nil {
+		return
 	}
 
 	// Basic CNAME check (sanity check!)
@@ -79,9 +94,25 @@ func (c *Client) GetSRVRecord(service, protocol, domainName string) (srv *net.SR
 	// Remove any period on the end
 	srv.Target = strings.TrimSuffix(srv.Target, ".")
 
+	// The target and port are required to build the capabilities url
+	if len(srv.Target) == 0 {
+		err = fmt.Errorf("srv target is invalid or empty for: %s", domainName)
+	} else if srv.Port == 0 {
+		err = fmt.Errorf("srv port is out of range for: %s", domainName)
+	}
+
 	return
 }
 
+// isMissingSRVRecord will return true if the lookup did not find an SRV record (NXDOMAIN or no records)
+func isMissingSRVRecord(err error, records []*net.SRV) bool {
+	if err == nil {
+		return len(records) == 0
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
 // ValidateSRVRecord will check for a valid SRV record for paymail following specifications
 //
 // Specs: http://bsvalias.org/02-01-host-discovery.html
@@ -0,0 +1,50 @@
+package paymail
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// newNXDomainServer will return the address of a name server answering NXDOMAIN to every question
+func newNXDomainServer(t *testing.T) (host, port string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeNameError)
+		_ = w.WriteMsg(m)
+	})}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	host, port, _ = net.SplitHostPort(conn.LocalAddr().String())
+	return host, port
+}
+
+func TestGetSRVRecord_DNSSECMissingRecord(t *testing.T) {
+	host, port := newNXDomainServer(t)
+
+	for _, fallback := range []bool{true, false} {
+		client, err := NewClient(WithDNSSEC(true), WithNameServer(host), WithDNSPort(port), WithWellKnownFallback(fallback))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv, err := client.GetSRVRecord(DefaultServiceName, DefaultProtocol, "example.com")
+		if !fallback {
+			if err == nil {
+				t.Error("expected the missing srv record to fail without the well-known fallback")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected the well-known fallback, got %s", err)
+		}
+		if srv.Target != "example.com" || srv.Port != DefaultPort {
+			t.Errorf("expected the domain on the default port, got %s:%d", srv.Target, srv.Port)
+		}
+	}
+}
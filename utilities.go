@@ -1,14 +1,35 @@
 package paymail
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
+
+	"golang.org/x/net/idna"
 )
 
+// maxDomainLength is the max length of a domain name (DNS limit)
+const maxDomainLength = 253
+
+// AliasPattern is the allowed character set of a (sanitized) alias: lowercase alphanumerics, dots, hyphens and underscores
+const AliasPattern = `^[a-z0-9._-]+$`
+
+// Paymail validation errors (returned wrapped by ValidatePaymail)
 var (
+	ErrPaymailDomainTooLong = errors.New("paymail domain is too long")
+	ErrPaymailEmptyAlias    = errors.New("paymail alias is empty")
+	ErrPaymailEmptyDomain   = errors.New("paymail domain is empty")
+	ErrPaymailInvalidAlias  = errors.New("paymail alias has invalid characters")
+	ErrPaymailInvalidFormat = errors.New("paymail address failed format validation")
+	ErrPaymailMissingAt     = errors.New("paymail address is missing the @")
+	ErrPaymailMultipleAt    = errors.New("paymail address has multiple @")
+)
+
+var (
+	aliasRegExp    = regexp.MustCompile(AliasPattern)
 	emailRegExp    = regexp.MustCompile(`[^a-zA-Z0-9-_.@+]`)
 	pathNameRegExp = regexp.MustCompile(`[^a-zA-Z0-9-_]`)
 	portRegExp     = regexp.MustCompile(`:\d*$`)
@@ -40,42 +61,63 @@ func ValidateAndSanitisePaymail(paymail string, isBeta bool) (*SanitisedPaymail,
 // SanitizePaymail will take an input and return the sanitized version (alias@domain.tld)
 //
 // Alias is the first part of the address (alias @)
-// Domain is the lowercase sanitized version (domain.tld)
+// Domain is the lowercase sanitized version (domain.tld), international domains are in punycode
 // Address is the full sanitized paymail address (alias@domain.tld)
+// An alias with characters outside of AliasPattern (spaces, @, control characters) returns an empty result
 func SanitizePaymail(paymailAddress string) (alias, domain, address string) {
 
-	// Sanitize the paymail address
-	address = SanitizeEmail(paymailAddress)
-
 	// Split the email parts (alias @ domain)
-	parts := strings.Split(address, "@")
+	parts := strings.Split(strings.TrimSpace(paymailAddress), "@")
+	if len(parts) > 2 {
+		return
+	}
 
-	// Sanitize the domain name (force to lowercase, remove www.)
+	// Sanitize the domain name (force to lowercase, remove www., punycode)
 	if len(parts) > 1 {
 		domain, _ = SanitizeDomain(parts[1])
 	}
 
-	// Set the alias (lowercase, no spaces)
-	alias = strings.TrimSpace(strings.ToLower(parts[0]))
+	// Set the alias (lowercase), it must only have the allowed characters (see AliasPattern)
+	alias = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(parts[0])), "mailto:")
+	if !IsValidAlias(alias) {
+		return "", "", ""
+	}
 
 	// Paymail address does not meet the basic requirement of an email address
 	// Since we don't return an error, we will return an empty result
-	if len(alias) == 0 || len(domain) == 0 {
-		address = ""
+	if len(domain) == 0 {
+		return
 	}
 
+	address = alias + "@" + domain
 	return
 }
 
 // ValidatePaymail will do a basic validation on the paymail format (email address format)
 //
+// The error wraps the specific reason (ErrPaymailMissingAt, ErrPaymailMultipleAt, ErrPaymailEmptyAlias,
+// ErrPaymailEmptyDomain, ErrPaymailInvalidAlias, ErrPaymailDomainTooLong or ErrPaymailInvalidFormat)
 // This will not check to see if the paymail address is active via the provider
 func ValidatePaymail(paymailAddress string) error {
-	isValid := IsValidEmail(paymailAddress)
+	alias, domain, found := strings.Cut(paymailAddress, "@")
+	switch {
+	case !found:
+		return fmt.Errorf("%w: %s", ErrPaymailMissingAt, paymailAddress)
+	case strings.Contains(domain, "@"):
+		return fmt.Errorf("%w: %s", ErrPaymailMultipleAt, paymailAddress)
+	case len(alias) == 0:
+		return fmt.Errorf("%w: %s", ErrPaymailEmptyAlias, paymailAddress)
+	case len(domain) == 0:
+		return fmt.Errorf("%w: %s", ErrPaymailEmptyDomain, paymailAddress)
+	case !IsValidAlias(strings.ToLower(alias)):
+		return fmt.Errorf("%w: %s", ErrPaymailInvalidAlias, paymailAddress)
+	case len(domain) > maxDomainLength:
+		return fmt.Errorf("%w: %s", ErrPaymailDomainTooLong, paymailAddress)
+	}
 
 	// Validate the format for the paymail address (paymail addresses follow conventional email requirements)
-	if !isValid {
-		return fmt.Errorf("paymail address failed format validation: %s", paymailAddress)
+	if !IsValidEmail(paymailAddress) {
+		return fmt.Errorf("%w: %s", ErrPaymailInvalidFormat, paymailAddress)
 	}
 
 	return nil
@@ -116,8 +158,18 @@ func ConvertHandle(handle string, isBeta bool) string {
 // ValidateTimestamp will test if the timestamp is valid
 //
 // This is used to validate the "dt" parameter in resolve_address.go
-// Allowing 3 minutes before/after for
+// Allowing 2 minutes before/after (DefaultTimestampSkew)
 func ValidateTimestamp(timestamp string) error {
+	return ValidateTimestampWithSkew(timestamp, DefaultTimestampSkew)
+}
+
+// ValidateTimestampWithSkew will test if the timestamp is valid, allowing the given skew before/after
+func ValidateTimestampWithSkew(timestamp string, skew time.Duration) error {
+	return ValidateTimestampAt(timestamp, skew, time.Now())
+}
+
+// ValidateTimestampAt will test if the timestamp is valid at the given time, allowing the given skew before/after
+func ValidateTimestampAt(timestamp string, skew time.Duration, now time.Time) error {
 
 	// Parse the time using the RFC3339 layout
 	dt, err := time.Parse(time.RFC3339, timestamp)
@@ -125,11 +177,11 @@ func ValidateTimestamp(timestamp string) error {
 		return err
 	}
 
-	// Timestamp cannot be more than 2 minutes in the past
+	// Timestamp cannot be more than the skew in the past or future
 	// Specs: http://bsvalias.org/04-02-sender-validation.html
-	if dt.Before(time.Now().UTC().Add(-2 * time.Minute)) {
+	if dt.Before(now.UTC().Add(-skew)) {
 		return fmt.Errorf("timestamp: %s is in the past", timestamp)
-	} else if dt.After(time.Now().UTC().Add(2 * time.Minute)) {
+	} else if dt.After(now.UTC().Add(skew)) {
 		return fmt.Errorf("timestamp: %s is in the future", timestamp)
 	}
 
@@ -139,6 +191,7 @@ func ValidateTimestamp(timestamp string) error {
 // SanitizeDomain will take an input and return the sanitized version (domain.tld)
 //
 // This will not check to see if the domain is an active paymail provider
+// International (Unicode) domains are converted to their punycode (ASCII) form
 // Example: SanitizeDomain("  www.Google.com  ")
 // Result:  google.com
 func SanitizeDomain(original string) (string, error) {
@@ -160,8 +213,15 @@ func SanitizeDomain(original string) (string, error) {
 	u.Host = strings.ToLower(u.Host) // Generally all domains should be uniform and lowercase
 	u.Host = strings.TrimPrefix(u.Host, "www.")
 	u.Host = removePort(u.Host)
+	u.Host = strings.TrimSuffix(u.Host, ".")
 
-	return u.Host, nil
+	// Convert IDN to punycode (café.com => xn--caf-dma.com)
+	host, err := idna.ToASCII(u.Host)
+	if err != nil {
+		return original, err
+	}
+
+	return host, nil
 }
 
 func removePort(host string) string {
@@ -188,14 +248,45 @@ func SanitizePathName(original string) string {
 	return pathNameRegExp.ReplaceAllString(original, "")
 }
 
-// replaceAliasDomain will replace the alias and domain with the correct values
-func replaceAliasDomain(urlString, alias, domain string) string {
-	urlString = strings.ReplaceAll(urlString, "{alias}", alias)
-	urlString = strings.ReplaceAll(urlString, "{domain.tld}", domain)
-	return urlString
+// Capability URL template placeholders
+const (
+	aliasPlaceholder  = "{alias}"
+	domainPlaceholder = "{domain.tld}"
+	pubKeyPlaceholder = "{pubkey}"
+)
+
+// ResolveURL will replace the {alias} and {domain.tld} placeholders of a capability URL template
+//
+// The alias is URL-escaped, returns an error if the template is missing a placeholder
+// Example: ResolveURL("https://example.com/{alias}@{domain.tld}/id", "john", "example.com")
+// Result:  https://example.com/john@example.com/id
+func ResolveURL(template, alias, domain string) (string, error) {
+	if !strings.Contains(template, aliasPlaceholder) {
+		return "", fmt.Errorf("url template is missing the %s placeholder: %s", aliasPlaceholder, template)
+	} else if !strings.Contains(template, domainPlaceholder) {
+		return "", fmt.Errorf("url template is missing the %s placeholder: %s", domainPlaceholder, template)
+	}
+	return replaceAliasDomain(template, alias, domain), nil
+}
+
+// ResolvePubKeyURL will perform ResolveURL() and also replace the {pubkey} placeholder
+//
+// Returns an error if the template is missing a placeholder
+func ResolvePubKeyURL(template, alias, domain, pubKey string) (string, error) {
+	if !strings.Contains(template, pubKeyPlaceholder) {
+		return "", fmt.Errorf("url template is missing the %s placeholder: %s", pubKeyPlaceholder, template)
+	}
+
+	resolved, err := ResolveURL(template, alias, domain)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(resolved, pubKeyPlaceholder, url.PathEscape(pubKey)), nil
 }
 
-// replacePubKey will replace the PubKey with the correct values
-func replacePubKey(urlString, pubKey string) string {
-	return strings.ReplaceAll(urlString, "{pubkey}", pubKey)
+// replaceAliasDomain will replace the alias (escaped) and domain with the correct values
+func replaceAliasDomain(urlString, alias, domain string) string {
+	urlString = strings.ReplaceAll(urlString, aliasPlaceholder, url.PathEscape(alias))
+	urlString = strings.ReplaceAll(urlString, domainPlaceholder, domain)
+	return urlString
 }
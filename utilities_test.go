@@ -0,0 +1,35 @@
+package paymail
+
+import "testing"
+
+func TestSanitizePaymail_IDN(t *testing.T) {
+	for input, expected := range map[string]struct{ alias, domain, address string }{
+		"Alice@café.com":         {"alice", "xn--caf-dma.com", "alice@xn--caf-dma.com"},
+		"alice@CAFÉ.com":         {"alice", "xn--caf-dma.com", "alice@xn--caf-dma.com"},
+		"alice@café.com.":        {"alice", "xn--caf-dma.com", "alice@xn--caf-dma.com"},
+		"alice@xn--caf-dma.com":  {"alice", "xn--caf-dma.com", "alice@xn--caf-dma.com"},
+		"alice@XN--CAF-DMA.COM.": {"alice", "xn--caf-dma.com", "alice@xn--caf-dma.com"},
+		" Bob@Example.COM ":      {"bob", "example.com", "bob@example.com"},
+	} {
+		alias, domain, address := SanitizePaymail(input)
+		if alias != expected.alias || domain != expected.domain || address != expected.address {
+			t.Errorf("%q: expected %s %s %s, got %s %s %s", input,
+				expected.alias, expected.domain, expected.address, alias, domain, address)
+		}
+	}
+}
+
+func TestSanitizeDomain_IDN(t *testing.T) {
+	// The Unicode and punycode forms are the same (canonical) domain
+	unicodeDomain, err := SanitizeDomain("Café.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	punycodeDomain, err := SanitizeDomain("xn--caf-dma.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unicodeDomain != punycodeDomain {
+		t.Errorf("expected the same domain, got %s and %s", unicodeDomain, punycodeDomain)
+	}
+}
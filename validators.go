@@ -1,6 +1,8 @@
 package paymail
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"regexp"
 	"strings"
@@ -31,3 +33,18 @@ func IsValidDNSName(dnsName string) bool {
 func IsValidEmail(e string) bool {
 	return emailRegEx.MatchString(e)
 }
+
+// IsValidAlias will validate the given string as a (sanitized) paymail alias (see AliasPattern)
+func IsValidAlias(alias string) bool {
+	return aliasRegExp.MatchString(alias)
+}
+
+// ValidateSatoshis will check that the amount is not zero and does not exceed MaxSatoshis
+func ValidateSatoshis(satoshis uint64) error {
+	if satoshis == 0 {
+		return errors.New("satoshis is required")
+	} else if satoshis > MaxSatoshis {
+		return fmt.Errorf("satoshis %d exceeds the max supply of %d", satoshis, MaxSatoshis)
+	}
+	return nil
+}
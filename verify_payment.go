@@ -0,0 +1,85 @@
+package paymail
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// PaymentMismatchError is returned by VerifyPayment() when issued outputs are not paid by the transaction
+type PaymentMismatchError struct {
+	Missing []*PaymentOutput // The issued outputs that are not paid (script and satoshis)
+}
+
+// Error will return the issued outputs that are not paid
+func (e *PaymentMismatchError) Error() string {
+	missing := make([]string, 0, len(e.Missing))
+	for _, output := range e.Missing {
+		if output.Satoshis == 0 {
+			missing = append(missing, "any amount to script "+output.Script)
+			continue
+		}
+		missing = append(missing, fmt.Sprintf("%d satoshis to script %s", output.Satoshis, output.Script))
+	}
+	return fmt.Sprintf("transaction does not pay %d issued output(s): %s", len(e.Missing), strings.Join(missing, ", "))
+}
+
+// MatchOutputs will match every issued output to a different tx output paying it
+//
+// The issued outputs with satoshis are matched first (same locking script and satoshis), then the issued outputs
+// without satoshis (any amount) are matched to the remaining tx outputs with the same locking script that pay
+// at least DustLimit. Matching in this order keeps an any amount output from taking the tx output that an exact
+// amount output needs. Returns the index of the tx output paying each issued output (-1 if not paid)
+func MatchOutputs(outputs []*sdk.TransactionOutput, issued []*PaymentOutput) []int {
+	matches := make([]int, len(issued))
+	paid := make(map[int]bool, len(issued))
+	for _, anyAmount := range []bool{false, true} {
+		for index, expected := range issued {
+			if (expected.Satoshis == 0) != anyAmount {
+				continue
+			}
+			matches[index] = -1
+			for i, output := range outputs {
+				if paid[i] || output.LockingScript == nil ||
+					!strings.EqualFold(output.LockingScript.String(), expected.Script) {
+					continue
+				}
+				if (anyAmount && output.Satoshis >= DustLimit) || (!anyAmount && output.Satoshis == expected.Satoshis) {
+					matches[index] = i
+					paid[i] = true
+					break
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// VerifyPayment will confirm that the transaction (hex) pays every issued output of a payment destination
+//
+// Each issued output must be paid by a different tx output with the same locking script and satoshis
+// (an issued output without satoshis can be paid any amount above the dust limit), using the same
+// MatchOutputs as the P2P transaction receiver.
+// Returns a *PaymentMismatchError listing the missing outputs
+func VerifyPayment(txHex string, issued []*PaymentOutput) error {
+	if len(issued) == 0 {
+		return fmt.Errorf("missing issued outputs")
+	}
+	tx, err := sdk.NewTransactionFromHex(txHex)
+	if err != nil {
+		return fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	var missing []*PaymentOutput
+	for index, matched := range MatchOutputs(tx.Outputs, issued) {
+		if matched < 0 {
+			missing = append(missing, issued[index])
+		}
+	}
+
+	if len(missing) > 0 {
+		return &PaymentMismatchError{Missing: missing}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package paymail
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// testPaymentTx will return the hex of a transaction paying the satoshis to the P2PKH script
+func testPaymentTx(t *testing.T, satoshis ...uint64) string {
+	t.Helper()
+	lockingScript, err := script.NewFromHex(testP2PKHScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := sdk.NewTransaction()
+	for _, amount := range satoshis {
+		tx.AddOutput(&sdk.TransactionOutput{LockingScript: lockingScript, Satoshis: amount})
+	}
+	return tx.Hex()
+}
+
+func TestVerifyPayment(t *testing.T) {
+	// The any amount output is issued before the exact amount output of the same script
+	issued := []*PaymentOutput{
+		{Script: testP2PKHScript},
+		{Script: testP2PKHScript, Satoshis: 1000},
+	}
+
+	t.Run("exact amount is matched first", func(t *testing.T) {
+		if err := VerifyPayment(testPaymentTx(t, 1000, 500), issued); err != nil {
+			t.Errorf("expected the payment to be valid, got %s", err)
+		}
+	})
+
+	t.Run("missing exact amount", func(t *testing.T) {
+		err := VerifyPayment(testPaymentTx(t, 999, 500), issued)
+		var mismatch *PaymentMismatchError
+		if !stderrors.As(err, &mismatch) || len(mismatch.Missing) != 1 || mismatch.Missing[0] != issued[1] {
+			t.Errorf("expected the exact amount output to be missing, got %v", err)
+		}
+	})
+
+	t.Run("any amount below the dust limit", func(t *testing.T) {
+		err := VerifyPayment(testPaymentTx(t, 1000, 0), issued)
+		var mismatch *PaymentMismatchError
+		if !stderrors.As(err, &mismatch) || len(mismatch.Missing) != 1 || mismatch.Missing[0] != issued[0] {
+			t.Errorf("expected the any amount output to be missing, got %v", err)
+		}
+	})
+
+	t.Run("each output is paid once", func(t *testing.T) {
+		if err := VerifyPayment(testPaymentTx(t, 1000), issued); err == nil {
+			t.Error("expected a single tx output to pay a single issued output")
+		}
+	})
+
+	t.Run("missing issued outputs", func(t *testing.T) {
+		if err := VerifyPayment(testPaymentTx(t, 1000), nil); err == nil {
+			t.Error("expected an error without issued outputs")
+		}
+	})
+}
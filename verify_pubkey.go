@@ -1,10 +1,10 @@
 package paymail
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 /*
@@ -31,13 +31,31 @@ type VerificationPayload struct {
 	PubKey   string `json:"pubkey"`   // The related PubKey
 }
 
+// VerifyPubKeyOwner will try to match a handle and pubkey using the verify capability of the paymail provider
+//
+// The capability URL is discovered from the capabilities of the domain.
+//
+// Specs: https://bsvalias.org/05-verify-public-key-owner.html
+func (c *Client) VerifyPubKeyOwner(ctx context.Context, alias, domain, pubKey string) (*VerificationPayload, error) {
+	verifyURL, err := c.discoverCapabilityURL(ctx, domain, BRFCVerifyPublicKeyOwner, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var response *VerificationResponse
+	if response, err = c.VerifyPubKey(ctx, verifyURL, alias, domain, pubKey); err != nil {
+		return nil, err
+	}
+	return &response.VerificationPayload, nil
+}
+
 // VerifyPubKey will try to match a handle and pubkey
 //
 // Specs: https://bsvalias.org/05-verify-public-key-owner.html
-func (c *Client) VerifyPubKey(verifyURL, alias, domain, pubKey string) (response *VerificationResponse, err error) {
+func (c *Client) VerifyPubKey(ctx context.Context, verifyURL, alias, domain, pubKey string) (response *VerificationResponse, err error) {
 
 	// Require a valid url
-	if len(verifyURL) == 0 || !strings.Contains(verifyURL, "https://") {
+	if len(verifyURL) == 0 || !c.isAllowedURL(verifyURL) {
 		err = fmt.Errorf("invalid url: %s", verifyURL)
 		return
 	}
@@ -54,13 +72,21 @@ func (c *Client) VerifyPubKey(verifyURL, alias, domain, pubKey string) (response
 		return
 	}
 
+	// The capability must be advertised by the host (if its capabilities are cached)
+	if err = c.checkCapability(verifyURL, BRFCVerifyPublicKeyOwner); err != nil {
+		return
+	}
+
 	// Set the base url and path, assuming the url is from the prior GetCapabilities() request
 	// https://<host-discovery-target>/verifypubkey/{alias}@{domain.tld}/{pubkey}
-	reqURL := replacePubKey(replaceAliasDomain(verifyURL, alias, domain), pubKey)
+	var reqURL string
+	if reqURL, err = ResolvePubKeyURL(verifyURL, alias, domain, pubKey); err != nil {
+		return
+	}
 
 	// Fire the GET request
 	var resp StandardResponse
-	if resp, err = c.getRequest(reqURL); err != nil {
+	if resp, err = c.getRequest(ctx, "verify pubkey", reqURL); err != nil {
 		return
 	}
 
@@ -69,11 +95,7 @@ func (c *Client) VerifyPubKey(verifyURL, alias, domain, pubKey string) (response
 
 	// Test the status code (200 or 304 is valid)
 	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
-		serverError := &ServerError{}
-		if err = json.Unmarshal(resp.Body, serverError); err != nil {
-			return
-		}
-		err = fmt.Errorf("bad response from paymail provider: code %d, message: %s", response.StatusCode, serverError.Message)
+		err = c.prepareServerErrorResponse(&resp)
 		return
 	}
 